@@ -1,18 +1,132 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"share-docs/pkg/app/domain/apikeyapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/services"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
 
 type ApiKeyCmd struct {
-	Create ApiKeyCreateCmd `cmd:"create"`
+	Create ApiKeyCreateCmd `cmd:"create" help:"create an API key"`
+	List   ApiKeyListCmd   `cmd:"list" help:"list API keys"`
+	Revoke ApiKeyRevokeCmd `cmd:"revoke" help:"revoke an API key"`
+	Rotate ApiKeyRotateCmd `cmd:"rotate" help:"replace the secret of an API key"`
 }
 
 type ApiKeyCreateCmd struct {
-	Name string `arg:"" name:"name" help:"create an API for a client"`
+	Name      string        `arg:"" name:"name" help:"create an API for a client"`
+	Owner     string        `required:"" help:"email of the user the key acts as"`
+	Scopes    []string      `name:"scope" required:"" help:"scope granted to the key, repeatable (docs:read, docs:write, links:manage, webhooks:manage, admin)"`
+	ExpiresIn time.Duration `help:"lifetime of the key, e.g. 720h (default: never expires)"`
 }
 
 func (ak *ApiKeyCreateCmd) Run(ctx *Context) error {
 	clientName := ak.Name
 
 	fmt.Printf("creating an API key for client: %s \n", clientName)
+
+	var expiresAt *time.Time
+	if ak.ExpiresIn > 0 {
+		t := time.Now().Add(ak.ExpiresIn)
+		expiresAt = &t
+	}
+
+	apiKeyService := newAPIKeyService(ctx)
+
+	created, err := apiKeyService.CreateAPIKey(ak.Owner, clientName, ak.Scopes, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	printCreatedKey(created)
 	return nil
 }
+
+type ApiKeyListCmd struct {
+	Owner string `help:"only list keys owned by this email"`
+}
+
+func (ak *ApiKeyListCmd) Run(ctx *Context) error {
+	apiKeyService := newAPIKeyService(ctx)
+
+	keys, err := apiKeyService.ListAPIKeys(ak.Owner)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tOWNER\tSCOPES\tEXPIRES\tLAST USED\tSTATUS")
+
+	for _, k := range keys {
+		status := "active"
+		if k.RevokedAt != nil {
+			status = "revoked"
+		} else if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+			status = "expired"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			k.ID, k.Name, k.Prefix, k.OwnerEmail, strings.Join(k.Scopes, ","),
+			formatTime(k.ExpiresAt), formatTime(k.LastUsedAt), status)
+	}
+
+	return w.Flush()
+}
+
+type ApiKeyRevokeCmd struct {
+	ID string `arg:"" name:"id" help:"ID of the key to revoke"`
+}
+
+func (ak *ApiKeyRevokeCmd) Run(ctx *Context) error {
+	apiKeyService := newAPIKeyService(ctx)
+
+	if err := apiKeyService.RevokeAPIKey(ak.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("revoked API key %s\n", ak.ID)
+	return nil
+}
+
+type ApiKeyRotateCmd struct {
+	ID string `arg:"" name:"id" help:"ID of the key to rotate"`
+}
+
+func (ak *ApiKeyRotateCmd) Run(ctx *Context) error {
+	apiKeyService := newAPIKeyService(ctx)
+
+	created, err := apiKeyService.RotateAPIKey(ak.ID)
+	if err != nil {
+		return err
+	}
+
+	printCreatedKey(created)
+	return nil
+}
+
+func printCreatedKey(k *apikeyapp.CreatedAPIKey) {
+	fmt.Printf("id:      %s\n", k.ID)
+	fmt.Printf("owner:   %s\n", k.OwnerEmail)
+	fmt.Printf("scopes:  %s\n", strings.Join(k.Scopes, ","))
+	fmt.Printf("expires: %s\n", formatTime(k.ExpiresAt))
+	fmt.Printf("key:     %s\n", k.Key)
+	fmt.Println("store the key now, it cannot be shown again")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// newAPIKeyService builds the service for the key commands. They never
+// authenticate with a key, so there is no key use to audit.
+func newAPIKeyService(ctx *Context) *services.APIKeyService {
+	return services.NewAPIKeyService(ctx.DB, audit.Discard{})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"share-docs/pkg/db"
+	"text/tabwriter"
+
+	"github.com/pressly/goose/v3"
+)
+
+type MigrateCmd struct {
+	Up     MigrateUpCmd     `cmd:"up" help:"apply all pending migrations"`
+	Down   MigrateDownCmd   `cmd:"down" help:"roll back the latest migration"`
+	Status MigrateStatusCmd `cmd:"status" help:"list migrations and whether they are applied"`
+	Redo   MigrateRedoCmd   `cmd:"redo" help:"roll back the latest migration and apply it again"`
+}
+
+type MigrateUpCmd struct{}
+
+func (m *MigrateUpCmd) Run(ctx *Context) error {
+	migrator, err := db.NewMigrator(ctx.DB)
+	if err != nil {
+		return err
+	}
+
+	results, err := migrator.Up(context.Background())
+	printMigrationResults(results...)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	return nil
+}
+
+type MigrateDownCmd struct{}
+
+func (m *MigrateDownCmd) Run(ctx *Context) error {
+	migrator, err := db.NewMigrator(ctx.DB)
+	if err != nil {
+		return err
+	}
+
+	result, err := migrator.Down(context.Background())
+	if err != nil {
+		return err
+	}
+
+	printMigrationResults(result)
+	return nil
+}
+
+type MigrateRedoCmd struct{}
+
+func (m *MigrateRedoCmd) Run(ctx *Context) error {
+	migrator, err := db.NewMigrator(ctx.DB)
+	if err != nil {
+		return err
+	}
+
+	results, err := migrator.Redo(context.Background())
+	printMigrationResults(results...)
+	return err
+}
+
+type MigrateStatusCmd struct{}
+
+func (m *MigrateStatusCmd) Run(ctx *Context) error {
+	migrator, err := db.NewMigrator(ctx.DB)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMIGRATION\tSTATE\tAPPLIED AT")
+
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.State == goose.StateApplied {
+			appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04")
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, path.Base(s.Source.Path), s.State, appliedAt)
+	}
+
+	return w.Flush()
+}
+
+func printMigrationResults(results ...*goose.MigrationResult) {
+	for _, r := range results {
+		fmt.Println(r)
+	}
+}
@@ -2,25 +2,42 @@ package main
 
 import (
 	"fmt"
+	"share-docs/pkg/config"
+	"share-docs/pkg/db"
 
 	"github.com/alecthomas/kong"
+	"gorm.io/gorm"
 )
 
 type Context struct {
-	Debug bool
+	Debug  bool
+	Config *config.Config
+	DB     *gorm.DB
 }
 
 var CLI struct {
-	Debug bool `help:"Enable debug mode"`
+	Debug  bool   `help:"Enable debug mode"`
+	Config string `help:"YAML configuration file" type:"existingfile"`
 
-	ApiKey ApiKeyCmd `cmd:"api-key" help:"manage api keys"`
+	ApiKey  ApiKeyCmd  `cmd:"api-key" help:"manage api keys"`
+	Worker  WorkerCmd  `cmd:"worker" help:"run background jobs"`
+	Migrate MigrateCmd `cmd:"migrate" help:"apply or roll back database migrations"`
 }
 
 func main() {
 	fmt.Println("share-docs' CLI")
 
 	ctx := kong.Parse(&CLI)
-	err := ctx.Run(&Context{Debug: CLI.Debug})
+
+	var args []string
+	if CLI.Config != "" {
+		args = []string{"--config", CLI.Config}
+	}
+
+	cfg, err := config.Load(args)
+	ctx.FatalIfErrorf(err)
+
+	err = ctx.Run(&Context{Debug: CLI.Debug, Config: cfg, DB: db.Connect(cfg.Database)})
 
 	ctx.FatalIfErrorf(err)
 }
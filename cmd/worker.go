@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/logger"
+	"share-docs/pkg/mail"
+	"syscall"
+)
+
+type WorkerCmd struct {
+	Queues string `help:"queues to work and their concurrency (default: JOB_QUEUES)"`
+}
+
+// Run works the job queues until interrupted, then lets the running jobs
+// finish. Set JOBS_IN_PROCESS=false on the API when using this.
+func (w *WorkerCmd) Run(ctx *Context) error {
+	spec := w.Queues
+	if spec == "" {
+		spec = ctx.Config.Jobs.Queues
+	}
+
+	queues, err := jobs.ParseQueues(spec)
+	if err != nil {
+		return err
+	}
+
+	level := ctx.Config.Log.Level
+	if ctx.Debug {
+		level = "debug"
+	}
+
+	log, err := logger.NewLogger(logger.LogConfig{
+		Level:       level,
+		Environment: ctx.Config.Environment,
+		OutputPath:  ctx.Config.Log.Output,
+		ServiceName: "share-docs-worker",
+		Version:     "1.0.0",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialise logger: %w", err)
+	}
+
+	worker, err := jobs.NewStandardWorker(ctx.DB, log, mail.NewMailer(ctx.Config.Mail, log), queues)
+	if err != nil {
+		return err
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	worker.Run(runCtx)
+	return nil
+}
@@ -1,26 +1,76 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"share-docs/pkg/config"
+	"share-docs/pkg/metrics"
 	"share-docs/pkg/routes"
-	"time"
+	"share-docs/pkg/server"
+	"share-docs/pkg/tracing"
+	"syscall"
 )
 
 func main() {
-	r := routes.SetupRouter()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "share-docs", "1.0.0")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to set up tracing: %v", err))
+	}
+
+	// Background workers outlive the server until the requests are drained
+	workers, stopWorkers := context.WithCancel(context.Background())
+
+	app := routes.SetupRouter(workers, cfg)
+
+	s := server.New(cfg.HTTP, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "HEAD" {
+			req.Method = "GET"
+		}
+		app.Router.ServeHTTP(w, req)
+	}))
 
-	s := &http.Server{
-		Addr: ":8080",
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			if req.Method == "HEAD" {
-				req.Method = "GET"
+	// Metrics are unauthenticated, so they get a listener of their own
+	if cfg.HTTP.MetricsAddr != "" {
+		go func() {
+			if err := server.RunInternal(ctx, cfg.HTTP.MetricsAddr, metrics.Handler(), app.Log); err != nil {
+				app.Log.WithError(err).Error("Metrics server stopped")
 			}
-			r.ServeHTTP(w, req)
-		}),
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
-		MaxHeaderBytes: 1 << 20,
+		}()
 	}
 
-	s.ListenAndServe()
+	runErr := server.Run(ctx, cfg.HTTP, s, app.Log)
+	if runErr != nil {
+		app.Log.WithError(runErr).Error("HTTP server stopped")
+	}
+
+	stopWorkers()
+
+	if err := shutdownTracing(context.Background()); err != nil {
+		app.Log.WithError(err).Warn("Failed to flush traces")
+	}
+
+	if err := app.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to shut down cleanly: %v\n", err)
+	}
+
+	if runErr != nil {
+		os.Exit(1)
+	}
 }
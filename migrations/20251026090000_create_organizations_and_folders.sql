@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE organizations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE organization_memberships (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role VARCHAR(20) NOT NULL
+);
+
+CREATE TABLE organization_invitations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  invited_by_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  role VARCHAR(20) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  accepted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE folders (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+  parent_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+
+  name VARCHAR(255) NOT NULL
+);
+
+ALTER TABLE documents
+ADD organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+ADD folder_id UUID REFERENCES folders(id) ON DELETE SET NULL;
+
+--
+CREATE INDEX idx_organizations_deleted_at ON organizations(deleted_at);
+CREATE UNIQUE INDEX idx_organization_memberships_org_user ON organization_memberships(organization_id, user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_organization_memberships_user_id ON organization_memberships(user_id);
+CREATE INDEX idx_organization_memberships_deleted_at ON organization_memberships(deleted_at);
+CREATE UNIQUE INDEX idx_organization_invitations_token_hash ON organization_invitations(token_hash);
+CREATE INDEX idx_organization_invitations_organization_id ON organization_invitations(organization_id);
+CREATE INDEX idx_organization_invitations_deleted_at ON organization_invitations(deleted_at);
+CREATE INDEX idx_folders_user_id ON folders(user_id);
+CREATE INDEX idx_folders_organization_id ON folders(organization_id);
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+CREATE INDEX idx_folders_deleted_at ON folders(deleted_at);
+CREATE INDEX idx_documents_organization_id ON documents(organization_id);
+CREATE INDEX idx_documents_folder_id ON documents(folder_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_folder_id;
+DROP INDEX IF EXISTS idx_documents_organization_id;
+ALTER TABLE documents
+DROP COLUMN folder_id,
+DROP COLUMN organization_id;
+
+DROP TABLE IF EXISTS folders;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_memberships;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE document_permissions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+  user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+  invited_by_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  role VARCHAR(20) NOT NULL,
+  token_hash VARCHAR(64),
+  expires_at TIMESTAMP WITH TIME ZONE
+);
+
+--
+CREATE UNIQUE INDEX idx_document_permissions_document_email ON document_permissions(document_id, email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_document_permissions_user_id ON document_permissions(user_id);
+CREATE UNIQUE INDEX idx_document_permissions_token_hash ON document_permissions(token_hash);
+CREATE INDEX idx_document_permissions_deleted_at ON document_permissions(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS document_permissions;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE share_links (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+  created_by_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255),
+  token_hash VARCHAR(64) NOT NULL,
+  password_hash VARCHAR(255),
+  expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE link_views (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  share_link_id UUID NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+  document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) NOT NULL,
+  visitor_id VARCHAR(64) NOT NULL,
+  viewer_email VARCHAR(255),
+  ip_address VARCHAR(45),
+  user_agent VARCHAR(255),
+  referrer VARCHAR(1000),
+  page_count INTEGER
+);
+
+CREATE TABLE link_view_pages (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  link_view_id UUID NOT NULL REFERENCES link_views(id) ON DELETE CASCADE,
+  page INTEGER NOT NULL,
+  duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+--
+CREATE UNIQUE INDEX idx_share_links_token_hash ON share_links(token_hash);
+CREATE INDEX idx_share_links_document_id ON share_links(document_id);
+CREATE INDEX idx_share_links_deleted_at ON share_links(deleted_at);
+CREATE UNIQUE INDEX idx_link_views_token_hash ON link_views(token_hash);
+CREATE INDEX idx_link_views_share_link_id ON link_views(share_link_id);
+CREATE INDEX idx_link_views_document_id ON link_views(document_id);
+CREATE INDEX idx_link_views_deleted_at ON link_views(deleted_at);
+CREATE UNIQUE INDEX idx_link_view_pages_view_page ON link_view_pages(link_view_id, page);
+CREATE INDEX idx_link_view_pages_deleted_at ON link_view_pages(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS link_view_pages;
+DROP TABLE IF EXISTS link_views;
+DROP TABLE IF EXISTS share_links;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE share_links
+ADD require_email BOOLEAN NOT NULL DEFAULT FALSE,
+ADD verify_email BOOLEAN NOT NULL DEFAULT FALSE,
+ADD allowlist TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE link_views
+ADD email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE link_email_codes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  share_link_id UUID NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  code_hash VARCHAR(64) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+--
+CREATE INDEX idx_link_email_codes_link_email ON link_email_codes(share_link_id, email);
+CREATE INDEX idx_link_email_codes_deleted_at ON link_email_codes(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS link_email_codes;
+
+ALTER TABLE link_views
+DROP COLUMN email_verified;
+
+ALTER TABLE share_links
+DROP COLUMN allowlist,
+DROP COLUMN verify_email,
+DROP COLUMN require_email;
+-- +goose StatementEnd
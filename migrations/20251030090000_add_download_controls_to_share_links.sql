@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE share_links
+ADD allow_download BOOLEAN NOT NULL DEFAULT TRUE,
+ADD max_views INTEGER,
+ADD watermark BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE share_links
+DROP COLUMN watermark,
+DROP COLUMN max_views,
+DROP COLUMN allow_download;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE agreements (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  content TEXT,
+  document_id UUID REFERENCES documents(id) ON DELETE SET NULL
+);
+
+CREATE TABLE agreement_acceptances (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  agreement_id UUID NOT NULL REFERENCES agreements(id) ON DELETE CASCADE,
+  share_link_id UUID NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+  link_view_id UUID REFERENCES link_views(id) ON DELETE SET NULL,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  ip_address VARCHAR(45),
+  user_agent VARCHAR(255)
+);
+
+ALTER TABLE share_links
+ADD agreement_id UUID REFERENCES agreements(id) ON DELETE SET NULL;
+
+--
+CREATE INDEX idx_agreements_user_id ON agreements(user_id);
+CREATE INDEX idx_agreements_deleted_at ON agreements(deleted_at);
+CREATE INDEX idx_agreement_acceptances_agreement_id ON agreement_acceptances(agreement_id);
+CREATE INDEX idx_agreement_acceptances_share_link_id ON agreement_acceptances(share_link_id);
+CREATE INDEX idx_agreement_acceptances_deleted_at ON agreement_acceptances(deleted_at);
+CREATE INDEX idx_share_links_agreement_id ON share_links(agreement_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_share_links_agreement_id;
+ALTER TABLE share_links
+DROP COLUMN agreement_id;
+
+DROP TABLE IF EXISTS agreement_acceptances;
+DROP TABLE IF EXISTS agreements;
+-- +goose StatementEnd
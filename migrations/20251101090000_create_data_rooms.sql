@@ -0,0 +1,123 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE data_rooms (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  description VARCHAR(1000),
+
+  logo_url VARCHAR(1000),
+  brand_color VARCHAR(7),
+  welcome_message TEXT,
+
+  token_hash VARCHAR(64) NOT NULL,
+  link_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  allow_download BOOLEAN NOT NULL DEFAULT TRUE,
+  expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE data_room_items (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  data_room_id UUID NOT NULL REFERENCES data_rooms(id) ON DELETE CASCADE,
+  folder_id UUID REFERENCES folders(id) ON DELETE CASCADE,
+  document_id UUID REFERENCES documents(id) ON DELETE CASCADE,
+
+  CHECK ((folder_id IS NULL) <> (document_id IS NULL))
+);
+
+CREATE TABLE data_room_groups (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  data_room_id UUID NOT NULL REFERENCES data_rooms(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE data_room_group_items (
+  group_id UUID NOT NULL REFERENCES data_room_groups(id) ON DELETE CASCADE,
+  item_id UUID NOT NULL REFERENCES data_room_items(id) ON DELETE CASCADE,
+
+  PRIMARY KEY (group_id, item_id)
+);
+
+CREATE TABLE data_room_viewers (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  data_room_id UUID NOT NULL REFERENCES data_rooms(id) ON DELETE CASCADE,
+  group_id UUID REFERENCES data_room_groups(id) ON DELETE SET NULL,
+  email VARCHAR(255) NOT NULL,
+
+  code_hash VARCHAR(64),
+  code_expires_at TIMESTAMP WITH TIME ZONE,
+  code_attempts INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE data_room_visits (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  data_room_id UUID NOT NULL REFERENCES data_rooms(id) ON DELETE CASCADE,
+  viewer_id UUID NOT NULL REFERENCES data_room_viewers(id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) NOT NULL,
+  ip_address VARCHAR(45),
+  user_agent VARCHAR(255)
+);
+
+CREATE TABLE data_room_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  data_room_id UUID NOT NULL REFERENCES data_rooms(id) ON DELETE CASCADE,
+  viewer_id UUID NOT NULL REFERENCES data_room_viewers(id) ON DELETE CASCADE,
+  visit_id UUID NOT NULL REFERENCES data_room_visits(id) ON DELETE CASCADE,
+  type VARCHAR(30) NOT NULL,
+  document_id UUID REFERENCES documents(id) ON DELETE SET NULL
+);
+
+--
+CREATE INDEX idx_data_rooms_user_id ON data_rooms(user_id);
+CREATE INDEX idx_data_rooms_organization_id ON data_rooms(organization_id);
+CREATE UNIQUE INDEX idx_data_rooms_token_hash ON data_rooms(token_hash);
+CREATE INDEX idx_data_rooms_deleted_at ON data_rooms(deleted_at);
+CREATE UNIQUE INDEX idx_data_room_items_folder ON data_room_items(data_room_id, folder_id) WHERE deleted_at IS NULL AND folder_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_data_room_items_document ON data_room_items(data_room_id, document_id) WHERE deleted_at IS NULL AND document_id IS NOT NULL;
+CREATE INDEX idx_data_room_items_deleted_at ON data_room_items(deleted_at);
+CREATE INDEX idx_data_room_groups_data_room_id ON data_room_groups(data_room_id);
+CREATE INDEX idx_data_room_groups_deleted_at ON data_room_groups(deleted_at);
+CREATE UNIQUE INDEX idx_data_room_viewers_room_email ON data_room_viewers(data_room_id, email) WHERE deleted_at IS NULL;
+CREATE INDEX idx_data_room_viewers_deleted_at ON data_room_viewers(deleted_at);
+CREATE UNIQUE INDEX idx_data_room_visits_token_hash ON data_room_visits(token_hash);
+CREATE INDEX idx_data_room_visits_data_room_id ON data_room_visits(data_room_id);
+CREATE INDEX idx_data_room_visits_deleted_at ON data_room_visits(deleted_at);
+CREATE INDEX idx_data_room_events_data_room_id ON data_room_events(data_room_id, created_at);
+CREATE INDEX idx_data_room_events_deleted_at ON data_room_events(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS data_room_events;
+DROP TABLE IF EXISTS data_room_visits;
+DROP TABLE IF EXISTS data_room_viewers;
+DROP TABLE IF EXISTS data_room_group_items;
+DROP TABLE IF EXISTS data_room_groups;
+DROP TABLE IF EXISTS data_room_items;
+DROP TABLE IF EXISTS data_rooms;
+-- +goose StatementEnd
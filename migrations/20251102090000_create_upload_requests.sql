@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE upload_requests (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  organization_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+  folder_id UUID REFERENCES folders(id) ON DELETE SET NULL,
+  name VARCHAR(255) NOT NULL,
+  message VARCHAR(1000),
+
+  token_hash VARCHAR(64) NOT NULL,
+  password_hash VARCHAR(255),
+  expires_at TIMESTAMP WITH TIME ZONE,
+  max_file_size BIGINT,
+  allowed_types VARCHAR(1000) NOT NULL DEFAULT ''
+);
+
+ALTER TABLE documents
+ADD upload_request_id UUID REFERENCES upload_requests(id) ON DELETE SET NULL,
+ADD uploader_name VARCHAR(255),
+ADD uploader_email VARCHAR(255);
+
+--
+CREATE INDEX idx_upload_requests_user_id ON upload_requests(user_id);
+CREATE UNIQUE INDEX idx_upload_requests_token_hash ON upload_requests(token_hash);
+CREATE INDEX idx_upload_requests_deleted_at ON upload_requests(deleted_at);
+CREATE INDEX idx_documents_upload_request_id ON documents(upload_request_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_documents_upload_request_id;
+ALTER TABLE documents
+DROP COLUMN upload_request_id,
+DROP COLUMN uploader_name,
+DROP COLUMN uploader_email;
+
+DROP TABLE IF EXISTS upload_requests;
+-- +goose StatementEnd
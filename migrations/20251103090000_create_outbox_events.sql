@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  type VARCHAR(100) NOT NULL,
+  aggregate_id UUID NOT NULL,
+  payload JSONB NOT NULL,
+
+  attempts INTEGER NOT NULL DEFAULT 0,
+  available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_to VARCHAR(1000) NOT NULL DEFAULT '',
+  last_error VARCHAR(1000),
+  dispatched_at TIMESTAMP WITH TIME ZONE,
+  failed_at TIMESTAMP WITH TIME ZONE
+);
+
+--
+CREATE INDEX idx_outbox_events_pending ON outbox_events(available_at) WHERE dispatched_at IS NULL AND failed_at IS NULL;
+CREATE INDEX idx_outbox_events_aggregate_id ON outbox_events(aggregate_id);
+CREATE INDEX idx_outbox_events_deleted_at ON outbox_events(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP WITH TIME ZONE,
+
+  queue VARCHAR(100) NOT NULL DEFAULT 'default',
+  kind VARCHAR(100) NOT NULL,
+  args JSONB NOT NULL,
+  unique_key VARCHAR(255),
+
+  status VARCHAR(20) NOT NULL DEFAULT 'queued',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 10,
+  run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  locked_by VARCHAR(255),
+  locked_until TIMESTAMP WITH TIME ZONE,
+  started_at TIMESTAMP WITH TIME ZONE,
+  finished_at TIMESTAMP WITH TIME ZONE,
+  last_error VARCHAR(2000)
+);
+
+--
+CREATE INDEX idx_jobs_ready ON jobs(queue, run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running ON jobs(locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_status_kind ON jobs(status, kind);
+CREATE UNIQUE INDEX idx_jobs_unique_key ON jobs(unique_key);
+CREATE INDEX idx_jobs_deleted_at ON jobs(deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_events (
+  seq BIGINT PRIMARY KEY,
+  id UUID NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+  action VARCHAR(100) NOT NULL,
+  outcome VARCHAR(20) NOT NULL,
+  actor_type VARCHAR(20) NOT NULL,
+  actor_id UUID,
+  api_key_id UUID,
+  target_type VARCHAR(50),
+  target_id VARCHAR(255),
+
+  ip_address VARCHAR(64),
+  user_agent VARCHAR(512),
+  request_id VARCHAR(64),
+
+  changes JSONB,
+  metadata JSONB,
+
+  prev_hash VARCHAR(64) NOT NULL,
+  hash VARCHAR(64) NOT NULL
+);
+
+-- The log is append-only: rows can't be changed or removed, even by the app
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+--
+CREATE UNIQUE INDEX idx_audit_events_id ON audit_events(id);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX idx_audit_events_action ON audit_events(action);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries
+DROP COLUMN response_body;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries
+ADD response_body VARCHAR(1000);
+-- +goose StatementEnd
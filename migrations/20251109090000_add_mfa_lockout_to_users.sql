@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD mfa_failures INTEGER NOT NULL DEFAULT 0,
+ADD mfa_locked_until TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN mfa_locked_until,
+DROP COLUMN mfa_failures;
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+package apikeyapp
+
+import (
+	"share-docs/pkg/db/models"
+	"strings"
+	"time"
+)
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	OwnerID    string     `json:"owner_id"`
+	OwnerEmail string     `json:"owner_email"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// CreatedAPIKey carries the plaintext key, which is only available right
+// after creation or rotation.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+func ToAppAPIKey(mk models.APIKey) APIKey {
+	return APIKey{
+		ID:         mk.ID.String(),
+		Name:       mk.Name,
+		Prefix:     mk.Prefix,
+		OwnerID:    mk.UserID.String(),
+		OwnerEmail: mk.User.Email,
+		Scopes:     SplitScopes(mk.Scopes),
+		CreatedAt:  mk.CreatedAt,
+		ExpiresAt:  mk.ExpiresAt,
+		LastUsedAt: mk.LastUsedAt,
+		RevokedAt:  mk.RevokedAt,
+	}
+}
+
+func SplitScopes(scopes string) []string {
+	return strings.Fields(scopes)
+}
+
+func JoinScopes(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
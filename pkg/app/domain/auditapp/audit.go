@@ -0,0 +1,94 @@
+package auditapp
+
+import (
+	"encoding/json"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+type Event struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Action     string          `json:"action"`
+	Outcome    string          `json:"outcome"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    *string         `json:"actor_id"`
+	APIKeyID   *string         `json:"api_key_id"`
+	TargetType *string         `json:"target_type"`
+	TargetID   *string         `json:"target_id"`
+	IPAddress  *string         `json:"ip_address"`
+	UserAgent  *string         `json:"user_agent"`
+	RequestID  *string         `json:"request_id"`
+	Changes    json.RawMessage `json:"changes,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+func ToAppEvent(me models.AuditEvent) Event {
+	e := Event{
+		Seq:        me.Seq,
+		ID:         me.ID.String(),
+		CreatedAt:  me.CreatedAt,
+		Action:     me.Action,
+		Outcome:    me.Outcome,
+		ActorType:  me.ActorType,
+		ActorID:    uuidPtrToString(me.ActorID),
+		APIKeyID:   uuidPtrToString(me.APIKeyID),
+		TargetType: me.TargetType,
+		TargetID:   me.TargetID,
+		IPAddress:  me.IPAddress,
+		UserAgent:  me.UserAgent,
+		RequestID:  me.RequestID,
+		PrevHash:   me.PrevHash,
+		Hash:       me.Hash,
+	}
+
+	if me.Changes != nil {
+		e.Changes = json.RawMessage(*me.Changes)
+	}
+
+	if me.Metadata != nil {
+		e.Metadata = json.RawMessage(*me.Metadata)
+	}
+
+	return e
+}
+
+// Filter narrows audit queries. From is inclusive, To exclusive.
+type Filter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	Outcome    string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// Verification is the result of checking the hash chain. BrokenAtSeq is the
+// first event that fails the check.
+type Verification struct {
+	Valid       bool   `json:"valid"`
+	Checked     int64  `json:"checked"`
+	LastHash    string `json:"last_hash,omitempty"`
+	BrokenAtSeq *int64 `json:"broken_at_seq,omitempty"`
+	Problem     string `json:"problem,omitempty"`
+}
+
+func uuidPtrToString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
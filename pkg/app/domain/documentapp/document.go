@@ -3,6 +3,8 @@ package documentapp
 import (
 	"share-docs/pkg/app/domain/userapp"
 	"share-docs/pkg/db/models"
+
+	"github.com/google/uuid"
 )
 
 type Document struct {
@@ -17,11 +19,22 @@ type Document struct {
 	Tags        *string `json:"tags"`
 	IsPublic    bool    `json:"is_public"`
 
-	User userapp.User `json:"user"`
+	OrganizationID *string `json:"organization_id"`
+	FolderID       *string `json:"folder_id"`
+
+	User     userapp.User `json:"user"`
+	Uploader *Uploader    `json:"uploader,omitempty"`
+}
+
+// Uploader is who sent a document through an upload request.
+type Uploader struct {
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	UploadRequestID string `json:"upload_request_id,omitempty"`
 }
 
 func ToAppDocument(md models.Document) Document {
-	return Document{
+	d := Document{
 		ID:               md.ID.String(),
 		OriginalFilename: md.OriginalFilename,
 		FileSize:         md.FileSize,
@@ -32,8 +45,43 @@ func ToAppDocument(md models.Document) Document {
 		Tags:        md.Tags,
 		IsPublic:    md.IsPublic,
 
+		OrganizationID: uuidPtrToString(md.OrganizationID),
+		FolderID:       uuidPtrToString(md.FolderID),
+
 		User: userapp.ToAppUser(md.User),
 	}
+
+	if md.UploaderEmail != nil {
+		d.Uploader = &Uploader{Email: *md.UploaderEmail}
+		if md.UploaderName != nil {
+			d.Uploader.Name = *md.UploaderName
+		}
+		if md.UploadRequestID != nil {
+			d.Uploader.UploadRequestID = md.UploadRequestID.String()
+		}
+	}
+
+	return d
+}
+
+func uuidPtrToString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// Placement is where a new document goes. Both fields are optional; without
+// an organisation the document is personal to its uploader.
+type Placement struct {
+	OrganizationID *uuid.UUID
+	FolderID       *uuid.UUID
+}
+
+type ListFilter struct {
+	OrganizationID *uuid.UUID
+	FolderID       *uuid.UUID
 }
 
 type UpdateDocument struct {
@@ -41,10 +89,11 @@ type UpdateDocument struct {
 	Description *string `json:"description" validate:"omitempty"`
 	Tags        *string `json:"tags" validate:"omitempty"`
 	IsPublic    *bool   `json:"is_public" validate:"omitempty"`
+	FolderID    *string `json:"folder_id" binding:"omitempty,uuid"`
 }
 
 func (ud *UpdateDocument) HasAtLeastOneField() bool {
-	return ud.Title != nil || ud.Description != nil || ud.Tags != nil || ud.IsPublic != nil
+	return ud.Title != nil || ud.Description != nil || ud.Tags != nil || ud.IsPublic != nil || ud.FolderID != nil
 }
 
 func (ud *UpdateDocument) ToModelDocument() models.Document {
@@ -65,5 +114,10 @@ func (ud *UpdateDocument) ToModelDocument() models.Document {
 		d.IsPublic = *ud.IsPublic
 	}
 
+	if ud.FolderID != nil {
+		folderID := uuid.MustParse(*ud.FolderID)
+		d.FolderID = &folderID
+	}
+
 	return d
 }
@@ -0,0 +1,24 @@
+package documentapp
+
+import (
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+type Folder struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	ParentID       *string   `json:"parent_id"`
+	OrganizationID *string   `json:"organization_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func ToAppFolder(mf models.Folder) Folder {
+	return Folder{
+		ID:             mf.ID.String(),
+		Name:           mf.Name,
+		ParentID:       uuidPtrToString(mf.ParentID),
+		OrganizationID: uuidPtrToString(mf.OrganizationID),
+		CreatedAt:      mf.CreatedAt,
+	}
+}
@@ -0,0 +1,74 @@
+package documentapp
+
+import (
+	"share-docs/pkg/app/domain/userapp"
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+// PermissionRole is what a collaborator may do with a single document.
+// Commenters can read like viewers; the role is kept apart so comments can
+// be granted to them without a data migration.
+type PermissionRole string
+
+const (
+	PermissionViewer    PermissionRole = "viewer"
+	PermissionCommenter PermissionRole = "commenter"
+	PermissionEditor    PermissionRole = "editor"
+)
+
+var permissionRank = map[PermissionRole]int{
+	PermissionViewer:    1,
+	PermissionCommenter: 2,
+	PermissionEditor:    3,
+}
+
+func (r PermissionRole) IsValid() bool {
+	_, ok := permissionRank[r]
+	return ok
+}
+
+// PermissionRolesAtLeast lists the roles granting at least min, for SQL IN
+// filters.
+func PermissionRolesAtLeast(min PermissionRole) []string {
+	roles := []string{}
+	for role, rank := range permissionRank {
+		if rank >= permissionRank[min] {
+			roles = append(roles, string(role))
+		}
+	}
+	return roles
+}
+
+type Collaborator struct {
+	ID        string         `json:"id"`
+	Email     string         `json:"email"`
+	Role      PermissionRole `json:"role"`
+	Pending   bool           `json:"pending"`
+	User      *userapp.User  `json:"user,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func ToAppCollaborator(mp models.DocumentPermission) Collaborator {
+	c := Collaborator{
+		ID:        mp.ID.String(),
+		Email:     mp.Email,
+		Role:      PermissionRole(mp.Role),
+		Pending:   mp.IsPending(),
+		CreatedAt: mp.CreatedAt,
+	}
+
+	if mp.User != nil {
+		u := userapp.ToAppUser(*mp.User)
+		c.User = &u
+	}
+
+	return c
+}
+
+// SharedDocument is a document shared with the current user, with the role
+// they were given.
+type SharedDocument struct {
+	Document
+	Role PermissionRole `json:"role"`
+}
@@ -0,0 +1,27 @@
+package healthapp
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Report is the outcome of the readiness checks. Status is up only when
+// every component is.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+type Component struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Details   any     `json:"details,omitempty"`
+}
+
+// MigrationStatus compares the applied migrations with the ones shipped.
+type MigrationStatus struct {
+	Current int64   `json:"current"`
+	Latest  int64   `json:"latest"`
+	Pending []int64 `json:"pending,omitempty"`
+}
@@ -0,0 +1,53 @@
+package jobapp
+
+import (
+	"encoding/json"
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+type Job struct {
+	ID          string          `json:"id"`
+	Queue       string          `json:"queue"`
+	Kind        string          `json:"kind"`
+	Args        json.RawMessage `json:"args"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedBy    *string         `json:"locked_by"`
+	StartedAt   *time.Time      `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	LastError   *string         `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func ToAppJob(mj models.Job) Job {
+	return Job{
+		ID:          mj.ID.String(),
+		Queue:       mj.Queue,
+		Kind:        mj.Kind,
+		Args:        json.RawMessage(mj.Args),
+		Status:      mj.Status,
+		Attempts:    mj.Attempts,
+		MaxAttempts: mj.MaxAttempts,
+		RunAt:       mj.RunAt,
+		LockedBy:    mj.LockedBy,
+		StartedAt:   mj.StartedAt,
+		FinishedAt:  mj.FinishedAt,
+		LastError:   mj.LastError,
+		CreatedAt:   mj.CreatedAt,
+	}
+}
+
+type ListFilter struct {
+	Queue  string
+	Kind   string
+	Status string
+}
+
+// QueueStats counts the jobs of a queue by status.
+type QueueStats struct {
+	Queue  string           `json:"queue"`
+	Counts map[string]int64 `json:"counts"`
+}
@@ -0,0 +1,55 @@
+package linkapp
+
+import (
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+type Agreement struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Content    *string   `json:"content"`
+	DocumentID *string   `json:"document_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func ToAppAgreement(ma models.Agreement) Agreement {
+	return Agreement{
+		ID:         ma.ID.String(),
+		Name:       ma.Name,
+		Content:    ma.Content,
+		DocumentID: uuidPtrToString(ma.DocumentID),
+		CreatedAt:  ma.CreatedAt,
+	}
+}
+
+// CreateAgreement needs Content, DocumentID or both.
+type CreateAgreement struct {
+	Name       string  `json:"name" binding:"required,max=255"`
+	Content    *string `json:"content"`
+	DocumentID *string `json:"document_id" binding:"omitempty,uuid"`
+}
+
+type Acceptance struct {
+	ID          string    `json:"id"`
+	AgreementID string    `json:"agreement_id"`
+	ShareLinkID string    `json:"share_link_id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	AcceptedAt  time.Time `json:"accepted_at"`
+}
+
+func ToAppAcceptance(ma models.AgreementAcceptance) Acceptance {
+	return Acceptance{
+		ID:          ma.ID.String(),
+		AgreementID: ma.AgreementID.String(),
+		ShareLinkID: ma.ShareLinkID.String(),
+		Name:        ma.Name,
+		Email:       ma.Email,
+		IPAddress:   ma.IPAddress,
+		UserAgent:   ma.UserAgent,
+		AcceptedAt:  ma.CreatedAt,
+	}
+}
@@ -0,0 +1,61 @@
+package linkapp
+
+import (
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+type View struct {
+	ID          string    `json:"id"`
+	ShareLinkID string    `json:"share_link_id"`
+	ViewerEmail *string   `json:"viewer_email"`
+	Verified    bool      `json:"email_verified"`
+	IPAddress   string    `json:"ip_address"`
+	UserAgent   string    `json:"user_agent"`
+	Referrer    string    `json:"referrer"`
+	PageCount   *int      `json:"page_count"`
+	DurationMs  int64     `json:"duration_ms"`
+	PagesViewed int       `json:"pages_viewed"`
+	ViewedAt    time.Time `json:"viewed_at"`
+}
+
+func ToAppView(mv models.LinkView) View {
+	return View{
+		ID:          mv.ID.String(),
+		ShareLinkID: mv.ShareLinkID.String(),
+		ViewerEmail: mv.ViewerEmail,
+		Verified:    mv.EmailVerified,
+		IPAddress:   mv.IPAddress,
+		UserAgent:   mv.UserAgent,
+		Referrer:    mv.Referrer,
+		PageCount:   mv.PageCount,
+		ViewedAt:    mv.CreatedAt,
+	}
+}
+
+// PageBeacon is what the viewer reports while a PDF is open: time spent on a
+// page since the last beacon and, once known, the number of pages.
+type PageBeacon struct {
+	ViewToken  string `json:"view_token" binding:"required"`
+	Page       int    `json:"page" binding:"required,min=1"`
+	DurationMs int64  `json:"duration_ms" binding:"min=0"`
+	PageCount  *int   `json:"page_count" binding:"omitempty,min=1"`
+}
+
+type PageStats struct {
+	Page              int     `json:"page"`
+	Views             int64   `json:"views"`
+	AverageDurationMs float64 `json:"average_duration_ms"`
+}
+
+// Stats aggregates the views of a link or of all links of a document.
+// CompletionRate is the share of views that reached every page; it is nil
+// when no viewer reported a page count.
+type Stats struct {
+	Views             int64       `json:"views"`
+	UniqueViewers     int64       `json:"unique_viewers"`
+	AverageDurationMs float64     `json:"average_duration_ms"`
+	CompletionRate    *float64    `json:"completion_rate"`
+	LastViewedAt      *time.Time  `json:"last_viewed_at"`
+	Pages             []PageStats `json:"pages"`
+}
@@ -0,0 +1,161 @@
+package linkapp
+
+import (
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/db/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ShareLink struct {
+	ID          string     `json:"id"`
+	DocumentID  string     `json:"document_id"`
+	Name        *string    `json:"name"`
+	HasPassword bool       `json:"has_password"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	RequireEmail bool     `json:"require_email"`
+	VerifyEmail  bool     `json:"verify_email"`
+	Allowlist    []string `json:"allowlist"`
+
+	AllowDownload bool  `json:"allow_download"`
+	MaxViews      *int  `json:"max_views"`
+	Views         int64 `json:"views"`
+	Watermark     bool  `json:"watermark"`
+
+	AgreementID *string `json:"agreement_id"`
+}
+
+// CreatedShareLink carries the link URL, which is only available right after
+// creation.
+type CreatedShareLink struct {
+	ShareLink
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func ToAppShareLink(ml models.ShareLink) ShareLink {
+	return ShareLink{
+		ID:          ml.ID.String(),
+		DocumentID:  ml.DocumentID.String(),
+		Name:        ml.Name,
+		HasPassword: ml.PasswordHash != nil,
+		ExpiresAt:   ml.ExpiresAt,
+		CreatedAt:   ml.CreatedAt,
+
+		RequireEmail: ml.EmailGated(),
+		VerifyEmail:  ml.EmailVerificationRequired(),
+		Allowlist:    SplitAllowlist(ml.Allowlist),
+
+		AllowDownload: ml.AllowDownload,
+		MaxViews:      ml.MaxViews,
+		Watermark:     ml.Watermark,
+
+		AgreementID: uuidPtrToString(ml.AgreementID),
+	}
+}
+
+func SplitAllowlist(allowlist string) []string {
+	return strings.Fields(allowlist)
+}
+
+func JoinAllowlist(entries []string) string {
+	return strings.Join(entries, " ")
+}
+
+// CreateShareLink creates a link. Allowlist entries are emails or domains
+// ("acme.com" or "@acme.com"); setting any makes the link require a verified
+// email.
+type CreateShareLink struct {
+	Name         *string    `json:"name" binding:"omitempty,max=255"`
+	Password     *string    `json:"password" binding:"omitempty,min=4,max=72"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RequireEmail bool       `json:"require_email"`
+	VerifyEmail  bool       `json:"verify_email"`
+	Allowlist    []string   `json:"allowlist"`
+
+	// AllowDownload defaults to true when omitted
+	AllowDownload *bool `json:"allow_download"`
+	MaxViews      *int  `json:"max_views" binding:"omitempty,min=1"`
+	Watermark     bool  `json:"watermark"`
+
+	AgreementID *string `json:"agreement_id" binding:"omitempty,uuid"`
+}
+
+// UpdateShareLink changes link settings. An empty Password removes the
+// password, RemoveExpiry makes the link permanent, an empty Allowlist lifts
+// the restriction, a MaxViews of 0 removes the view limit and an empty
+// AgreementID detaches the agreement.
+type UpdateShareLink struct {
+	Name         *string    `json:"name" binding:"omitempty,max=255"`
+	Password     *string    `json:"password" binding:"omitempty,max=72"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RemoveExpiry bool       `json:"remove_expiry"`
+	RequireEmail *bool      `json:"require_email"`
+	VerifyEmail  *bool      `json:"verify_email"`
+	Allowlist    *[]string  `json:"allowlist"`
+
+	AllowDownload *bool `json:"allow_download"`
+	MaxViews      *int  `json:"max_views" binding:"omitempty,min=0"`
+	Watermark     *bool `json:"watermark"`
+
+	AgreementID *string `json:"agreement_id" binding:"omitempty,uuid"`
+}
+
+func (u *UpdateShareLink) HasAtLeastOneField() bool {
+	return u.Name != nil || u.Password != nil || u.ExpiresAt != nil || u.RemoveExpiry ||
+		u.RequireEmail != nil || u.VerifyEmail != nil || u.Allowlist != nil ||
+		u.AllowDownload != nil || u.MaxViews != nil || u.Watermark != nil ||
+		u.AgreementID != nil
+}
+
+// AccessRequest is what a viewer submits to open a gated link. Code is the
+// one-time code emailed for links that verify the email. Links with an
+// agreement need AcceptAgreement along with the viewer's Name and Email.
+type AccessRequest struct {
+	Password        string `json:"password"`
+	Email           string `json:"email" binding:"omitempty,email"`
+	Code            string `json:"code"`
+	Name            string `json:"name" binding:"max=255"`
+	AcceptAgreement bool   `json:"accept_agreement"`
+}
+
+// EmailCodeRequest asks for a one-time code to be emailed to the viewer.
+type EmailCodeRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Visitor describes who opened a link, as far as the request tells.
+type Visitor struct {
+	IPAddress string
+	UserAgent string
+	Referrer  string
+}
+
+// LinkAccess is returned when a viewer opens a link. ViewToken identifies the
+// view when fetching the file and reporting page dwell times.
+type LinkAccess struct {
+	Document      documentapp.Document `json:"document"`
+	ViewID        string               `json:"view_id"`
+	ViewToken     string               `json:"view_token"`
+	AllowDownload bool                 `json:"allow_download"`
+}
+
+// SharedFile is a file about to be served through a link. Watermark is the
+// text to stamp on it, empty when the link doesn't watermark.
+type SharedFile struct {
+	Document      documentapp.Document
+	AllowDownload bool
+	Watermark     string
+}
+
+func uuidPtrToString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
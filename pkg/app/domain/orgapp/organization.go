@@ -0,0 +1,95 @@
+package orgapp
+
+import (
+	"share-docs/pkg/app/domain/userapp"
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything min grants.
+func (r Role) AtLeast(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+// RolesAtLeast lists the roles granting at least min, for SQL IN filters.
+func RolesAtLeast(min Role) []string {
+	roles := []string{}
+	for role, rank := range roleRank {
+		if rank >= roleRank[min] {
+			roles = append(roles, string(role))
+		}
+	}
+	return roles
+}
+
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToAppOrganization(mo models.Organization) Organization {
+	return Organization{
+		ID:        mo.ID.String(),
+		Name:      mo.Name,
+		CreatedAt: mo.CreatedAt,
+	}
+}
+
+type Member struct {
+	User     userapp.User `json:"user"`
+	Role     Role         `json:"role"`
+	JoinedAt time.Time    `json:"joined_at"`
+}
+
+func ToAppMember(mm models.OrganizationMembership) Member {
+	return Member{
+		User:     userapp.ToAppUser(mm.User),
+		Role:     Role(mm.Role),
+		JoinedAt: mm.CreatedAt,
+	}
+}
+
+type Invitation struct {
+	ID             string     `json:"id"`
+	OrganizationID string     `json:"organization_id"`
+	Email          string     `json:"email"`
+	Role           Role       `json:"role"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func ToAppInvitation(mi models.OrganizationInvitation) Invitation {
+	return Invitation{
+		ID:             mi.ID.String(),
+		OrganizationID: mi.OrganizationID.String(),
+		Email:          mi.Email,
+		Role:           Role(mi.Role),
+		ExpiresAt:      mi.ExpiresAt,
+		AcceptedAt:     mi.AcceptedAt,
+		CreatedAt:      mi.CreatedAt,
+	}
+}
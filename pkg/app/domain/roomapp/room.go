@@ -0,0 +1,195 @@
+package roomapp
+
+import (
+	"path/filepath"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataRoom struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Description    *string `json:"description"`
+	OrganizationID *string `json:"organization_id"`
+
+	LogoURL        *string `json:"logo_url"`
+	BrandColor     *string `json:"brand_color"`
+	WelcomeMessage *string `json:"welcome_message"`
+
+	LinkEnabled   bool       `json:"link_enabled"`
+	AllowDownload bool       `json:"allow_download"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func ToAppDataRoom(mr models.DataRoom) DataRoom {
+	return DataRoom{
+		ID:             mr.ID.String(),
+		Name:           mr.Name,
+		Description:    mr.Description,
+		OrganizationID: uuidPtrToString(mr.OrganizationID),
+
+		LogoURL:        mr.LogoURL,
+		BrandColor:     mr.BrandColor,
+		WelcomeMessage: mr.WelcomeMessage,
+
+		LinkEnabled:   mr.LinkEnabled,
+		AllowDownload: mr.AllowDownload,
+		ExpiresAt:     mr.ExpiresAt,
+		CreatedAt:     mr.CreatedAt,
+	}
+}
+
+// LinkedDataRoom carries the room's link URL, which is only available when
+// the room is created or its link is rotated.
+type LinkedDataRoom struct {
+	DataRoom
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type CreateDataRoom struct {
+	Name           string  `json:"name" binding:"required,max=255"`
+	Description    *string `json:"description" binding:"omitempty,max=1000"`
+	OrganizationID *string `json:"organization_id" binding:"omitempty,uuid"`
+
+	LogoURL        *string `json:"logo_url" binding:"omitempty,url,max=1000"`
+	BrandColor     *string `json:"brand_color" binding:"omitempty,hexcolor,len=7"`
+	WelcomeMessage *string `json:"welcome_message"`
+
+	// AllowDownload defaults to true when omitted
+	AllowDownload *bool      `json:"allow_download"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+// UpdateDataRoom changes the fields that are set. Empty branding values clear
+// them.
+type UpdateDataRoom struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+
+	LogoURL        *string `json:"logo_url" binding:"omitempty,url,max=1000"`
+	BrandColor     *string `json:"brand_color" binding:"omitempty,hexcolor,len=7"`
+	WelcomeMessage *string `json:"welcome_message"`
+
+	LinkEnabled   *bool      `json:"link_enabled"`
+	AllowDownload *bool      `json:"allow_download"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	RemoveExpiry  bool       `json:"remove_expiry"`
+}
+
+func (u UpdateDataRoom) HasAtLeastOneField() bool {
+	return u.Name != nil || u.Description != nil || u.LogoURL != nil || u.BrandColor != nil ||
+		u.WelcomeMessage != nil || u.LinkEnabled != nil || u.AllowDownload != nil ||
+		u.ExpiresAt != nil || u.RemoveExpiry
+}
+
+// Item is a folder or a document in a room.
+type Item struct {
+	ID         string    `json:"id"`
+	FolderID   *string   `json:"folder_id"`
+	DocumentID *string   `json:"document_id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func ToAppItem(mi models.DataRoomItem) Item {
+	i := Item{
+		ID:         mi.ID.String(),
+		FolderID:   uuidPtrToString(mi.FolderID),
+		DocumentID: uuidPtrToString(mi.DocumentID),
+		CreatedAt:  mi.CreatedAt,
+	}
+
+	if mi.Folder != nil {
+		i.Name = mi.Folder.Name
+	}
+
+	if mi.Document != nil {
+		i.Name = DocumentName(*mi.Document)
+	}
+
+	return i
+}
+
+// AddItem adds either a folder or a document, not both.
+type AddItem struct {
+	FolderID   *string `json:"folder_id" binding:"omitempty,uuid"`
+	DocumentID *string `json:"document_id" binding:"omitempty,uuid"`
+}
+
+// Group is a set of viewers and the items they may see.
+type Group struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ItemIDs   []string  `json:"item_ids"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToAppGroup(mg models.DataRoomGroup) Group {
+	g := Group{
+		ID:        mg.ID.String(),
+		Name:      mg.Name,
+		ItemIDs:   make([]string, 0, len(mg.Items)),
+		CreatedAt: mg.CreatedAt,
+	}
+
+	for _, mi := range mg.Items {
+		g.ItemIDs = append(g.ItemIDs, mi.ID.String())
+	}
+
+	return g
+}
+
+type GroupRequest struct {
+	Name    string   `json:"name" binding:"required,max=255"`
+	ItemIDs []string `json:"item_ids" binding:"dive,uuid"`
+}
+
+type Viewer struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	GroupID   *string   `json:"group_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToAppViewer(mv models.DataRoomViewer) Viewer {
+	return Viewer{
+		ID:        mv.ID.String(),
+		Email:     mv.Email,
+		GroupID:   uuidPtrToString(mv.GroupID),
+		CreatedAt: mv.CreatedAt,
+	}
+}
+
+// AddViewers adds emails to the viewer list, optionally in a group. Emails
+// already on the list are moved to the group.
+type AddViewers struct {
+	Emails  []string `json:"emails" binding:"required,min=1,max=500,dive,email"`
+	GroupID *string  `json:"group_id" binding:"omitempty,uuid"`
+}
+
+// UpdateViewer moves a viewer to a group; an empty GroupID takes the viewer
+// out of their group.
+type UpdateViewer struct {
+	GroupID *string `json:"group_id" binding:"required"`
+}
+
+func uuidPtrToString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// DocumentName is the title of a document, or its file name without one.
+func DocumentName(md models.Document) string {
+	if md.Title != nil {
+		return *md.Title
+	}
+
+	return filepath.Base(md.OriginalFilename)
+}
@@ -0,0 +1,120 @@
+package roomapp
+
+import (
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+// Branding is what anyone with the room's link sees before signing in.
+type Branding struct {
+	Name           string  `json:"name"`
+	Description    *string `json:"description"`
+	LogoURL        *string `json:"logo_url"`
+	BrandColor     *string `json:"brand_color"`
+	WelcomeMessage *string `json:"welcome_message"`
+}
+
+func ToAppBranding(mr models.DataRoom) Branding {
+	return Branding{
+		Name:           mr.Name,
+		Description:    mr.Description,
+		LogoURL:        mr.LogoURL,
+		BrandColor:     mr.BrandColor,
+		WelcomeMessage: mr.WelcomeMessage,
+	}
+}
+
+type CodeRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type VerifyRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// RoomAccess is handed to a viewer who signed in. The view token is passed
+// to every other room request.
+type RoomAccess struct {
+	Branding
+	Email         string `json:"email"`
+	AllowDownload bool   `json:"allow_download"`
+	ViewToken     string `json:"view_token"`
+}
+
+type Folder struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+type RoomDocument struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	MimeType string  `json:"mime_type"`
+	FileSize int64   `json:"file_size"`
+	FolderID *string `json:"folder_id"`
+}
+
+// ToAppRoomDocument leaves out what viewers have no business seeing, such as
+// the owner and the storage path.
+func ToAppRoomDocument(md models.Document) RoomDocument {
+	return RoomDocument{
+		ID:       md.ID.String(),
+		Name:     DocumentName(md),
+		MimeType: md.MimeType,
+		FileSize: md.FileSize,
+		FolderID: uuidPtrToString(md.FolderID),
+	}
+}
+
+// Contents is what a viewer can see in a room. Folders and documents whose
+// parent is not visible have a nil ParentID/FolderID.
+type Contents struct {
+	Folders   []Folder       `json:"folders"`
+	Documents []RoomDocument `json:"documents"`
+}
+
+// RoomFile is a document a viewer opened.
+type RoomFile struct {
+	Path      string
+	MimeType  string
+	Watermark string
+}
+
+type Event struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	ViewerEmail  string    `json:"viewer_email"`
+	VisitID      string    `json:"visit_id"`
+	DocumentID   *string   `json:"document_id"`
+	DocumentName *string   `json:"document_name"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func ToAppEvent(me models.DataRoomEvent) Event {
+	e := Event{
+		ID:          me.ID.String(),
+		Type:        me.Type,
+		ViewerEmail: me.Viewer.Email,
+		VisitID:     me.VisitID.String(),
+		DocumentID:  uuidPtrToString(me.DocumentID),
+		CreatedAt:   me.CreatedAt,
+	}
+
+	if me.Document != nil {
+		name := DocumentName(*me.Document)
+		e.DocumentName = &name
+	}
+
+	return e
+}
+
+// Stats summarises a room's activity.
+type Stats struct {
+	Visits         int64      `json:"visits"`
+	UniqueViewers  int64      `json:"unique_viewers"`
+	DocumentViews  int64      `json:"document_views"`
+	Downloads      int64      `json:"downloads"`
+	LastActivityAt *time.Time `json:"last_activity_at"`
+}
@@ -0,0 +1,32 @@
+package sessionapp
+
+import (
+	"share-docs/pkg/db/models"
+	"time"
+)
+
+// ClientInfo describes the client a request came from, as captured by the
+// logging middleware.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+func ToAppSession(ms models.Session) Session {
+	return Session{
+		ID:         ms.ID.String(),
+		UserAgent:  ms.UserAgent,
+		IPAddress:  ms.IPAddress,
+		CreatedAt:  ms.CreatedAt,
+		LastUsedAt: ms.LastUsedAt,
+	}
+}
@@ -0,0 +1,154 @@
+package uploadapp
+
+import (
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/db/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxFileSize applies to upload requests without their own limit.
+const DefaultMaxFileSize int64 = 100 << 20
+
+type UploadRequest struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Message        *string    `json:"message"`
+	OrganizationID *string    `json:"organization_id"`
+	FolderID       *string    `json:"folder_id"`
+	HasPassword    bool       `json:"has_password"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	MaxFileSize    int64      `json:"max_file_size"`
+	AllowedTypes   []string   `json:"allowed_types"`
+	Uploads        int64      `json:"uploads"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func ToAppUploadRequest(mr models.UploadRequest) UploadRequest {
+	return UploadRequest{
+		ID:             mr.ID.String(),
+		Name:           mr.Name,
+		Message:        mr.Message,
+		OrganizationID: uuidPtrToString(mr.OrganizationID),
+		FolderID:       uuidPtrToString(mr.FolderID),
+		HasPassword:    mr.PasswordHash != nil,
+		ExpiresAt:      mr.ExpiresAt,
+		MaxFileSize:    MaxFileSize(mr),
+		AllowedTypes:   SplitTypes(mr.AllowedTypes),
+		CreatedAt:      mr.CreatedAt,
+	}
+}
+
+// CreatedUploadRequest carries the link URL, which is only available right
+// after creation.
+type CreatedUploadRequest struct {
+	UploadRequest
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// CreateUploadRequest creates an upload link. AllowedTypes entries are MIME
+// types ("application/pdf", "image/*") or extensions (".docx").
+type CreateUploadRequest struct {
+	Name           string     `json:"name" binding:"required,max=255"`
+	Message        *string    `json:"message" binding:"omitempty,max=1000"`
+	OrganizationID *string    `json:"organization_id" binding:"omitempty,uuid"`
+	FolderID       *string    `json:"folder_id" binding:"omitempty,uuid"`
+	Password       *string    `json:"password" binding:"omitempty,min=4,max=72"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	MaxFileSize    *int64     `json:"max_file_size" binding:"omitempty,min=1,max=2147483648"`
+	AllowedTypes   []string   `json:"allowed_types" binding:"dive,max=100"`
+}
+
+// UpdateUploadRequest changes the fields that are set. An empty Password
+// removes it and a MaxFileSize of 0 restores the default limit.
+type UpdateUploadRequest struct {
+	Name         *string    `json:"name" binding:"omitempty,min=1,max=255"`
+	Message      *string    `json:"message" binding:"omitempty,max=1000"`
+	Password     *string    `json:"password" binding:"omitempty,min=4,max=72"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RemoveExpiry bool       `json:"remove_expiry"`
+	MaxFileSize  *int64     `json:"max_file_size" binding:"omitempty,min=0,max=2147483648"`
+	AllowedTypes *[]string  `json:"allowed_types" binding:"omitempty,dive,max=100"`
+}
+
+func (u UpdateUploadRequest) HasAtLeastOneField() bool {
+	return u.Name != nil || u.Message != nil || u.Password != nil || u.ExpiresAt != nil ||
+		u.RemoveExpiry || u.MaxFileSize != nil || u.AllowedTypes != nil
+}
+
+// PublicUploadRequest is what the person uploading sees.
+type PublicUploadRequest struct {
+	Name             string     `json:"name"`
+	Message          *string    `json:"message"`
+	RequestedBy      string     `json:"requested_by"`
+	RequiresPassword bool       `json:"requires_password"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	MaxFileSize      int64      `json:"max_file_size"`
+	AllowedTypes     []string   `json:"allowed_types"`
+}
+
+func ToAppPublicUploadRequest(mr models.UploadRequest) PublicUploadRequest {
+	return PublicUploadRequest{
+		Name:             mr.Name,
+		Message:          mr.Message,
+		RequestedBy:      strings.TrimSpace(mr.User.FirstName + " " + mr.User.LastName),
+		RequiresPassword: mr.PasswordHash != nil,
+		ExpiresAt:        mr.ExpiresAt,
+		MaxFileSize:      MaxFileSize(mr),
+		AllowedTypes:     SplitTypes(mr.AllowedTypes),
+	}
+}
+
+// Upload describes a file someone is sending through an upload request. The
+// MIME type is sniffed from the content, not taken from the client.
+type Upload struct {
+	Password string
+	Filename string
+	Size     int64
+	MimeType string
+	Uploader documentapp.Uploader
+}
+
+// Target is where an accepted upload is stored and filed.
+type Target struct {
+	RequestID   uuid.UUID
+	RequestName string
+	OwnerID     uuid.UUID
+	OwnerEmail  string
+	Path        string
+	Placement   documentapp.Placement
+	Uploader    documentapp.Uploader
+}
+
+// ReceivedFile is the receipt handed back to the person uploading.
+type ReceivedFile struct {
+	Filename   string    `json:"filename"`
+	Size       int64     `json:"size"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+func MaxFileSize(mr models.UploadRequest) int64 {
+	if mr.MaxFileSize == nil {
+		return DefaultMaxFileSize
+	}
+	return *mr.MaxFileSize
+}
+
+func SplitTypes(types string) []string {
+	return strings.Fields(types)
+}
+
+func JoinTypes(types []string) string {
+	return strings.Join(types, " ")
+}
+
+func uuidPtrToString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
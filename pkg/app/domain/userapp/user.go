@@ -11,6 +11,14 @@ type User struct {
 	FirstName string     `json:"first_name"`
 	LastName  string     `json:"last_name"`
 	BirthDate *time.Time `json:"birth_date"`
+
+	MFAEnabled bool `json:"mfa_enabled"`
+}
+
+// TOTPEnrollment is handed to the user to set up an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
 }
 
 func ToAppUser(mu models.User) User {
@@ -20,5 +28,7 @@ func ToAppUser(mu models.User) User {
 		FirstName: mu.FirstName,
 		LastName:  mu.LastName,
 		BirthDate: mu.BirthDate,
+
+		MFAEnabled: mu.TOTPEnabled,
 	}
 }
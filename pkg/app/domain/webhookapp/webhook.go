@@ -0,0 +1,115 @@
+package webhookapp
+
+import (
+	"encoding/json"
+	"share-docs/pkg/db/models"
+	"strings"
+	"time"
+)
+
+// EventTypes are the events webhooks can subscribe to.
+var EventTypes = []string{
+	models.EventDocumentCreated,
+	models.EventDocumentUpdated,
+	models.EventDocumentDeleted,
+	models.EventLinkViewed,
+}
+
+type Endpoint struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Description         *string    `json:"description"`
+	EventTypes          []string   `json:"event_types"`
+	Enabled             bool       `json:"enabled"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      *string    `json:"disabled_reason"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+func ToAppEndpoint(me models.WebhookEndpoint) Endpoint {
+	return Endpoint{
+		ID:                  me.ID.String(),
+		URL:                 me.URL,
+		Description:         me.Description,
+		EventTypes:          SplitEventTypes(me.EventTypes),
+		Enabled:             me.Enabled,
+		ConsecutiveFailures: me.ConsecutiveFailures,
+		DisabledAt:          me.DisabledAt,
+		DisabledReason:      me.DisabledReason,
+		CreatedAt:           me.CreatedAt,
+	}
+}
+
+// EndpointWithSecret carries the signing secret, which is only returned when
+// the endpoint is created or its secret rotated.
+type EndpointWithSecret struct {
+	Endpoint
+	Secret string `json:"secret"`
+}
+
+type CreateEndpoint struct {
+	URL         string   `json:"url" binding:"required,url,max=2000"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1,dive,oneof=document.created document.updated document.deleted link.viewed"`
+}
+
+// UpdateEndpoint changes the fields that are set. Enabling an endpoint that
+// was disabled after failures resets its failure count.
+type UpdateEndpoint struct {
+	URL         *string   `json:"url" binding:"omitempty,url,max=2000"`
+	Description *string   `json:"description" binding:"omitempty,max=255"`
+	EventTypes  *[]string `json:"event_types" binding:"omitempty,min=1,dive,oneof=document.created document.updated document.deleted link.viewed"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+func (u UpdateEndpoint) HasAtLeastOneField() bool {
+	return u.URL != nil || u.Description != nil || u.EventTypes != nil || u.Enabled != nil
+}
+
+type Delivery struct {
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func ToAppDelivery(md models.WebhookDelivery) Delivery {
+	d := Delivery{
+		ID:             md.ID.String(),
+		EndpointID:     md.EndpointID.String(),
+		EventID:        md.EventID.String(),
+		EventType:      md.EventType,
+		Payload:        json.RawMessage(md.Payload),
+		Status:         md.Status,
+		Attempts:       md.Attempts,
+		LastAttemptAt:  md.LastAttemptAt,
+		ResponseStatus: md.ResponseStatus,
+		LastError:      md.LastError,
+		DeliveredAt:    md.DeliveredAt,
+		CreatedAt:      md.CreatedAt,
+	}
+
+	if md.Status == models.WebhookDeliveryPending {
+		d.NextAttemptAt = &md.NextAttemptAt
+	}
+
+	return d
+}
+
+func SplitEventTypes(types string) []string {
+	return strings.Fields(types)
+}
+
+func JoinEventTypes(types []string) string {
+	return strings.Join(types, " ")
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ActionLogin        = "auth.login"
+	ActionTokenRefresh = "auth.token_refresh"
+	ActionAPIKeyUse    = "api_key.use"
+
+	ActionDocumentCreate = "document.create"
+	ActionDocumentRead   = "document.read"
+	ActionDocumentUpdate = "document.update"
+	ActionDocumentDelete = "document.delete"
+
+	ActionPermissionGrant  = "permission.grant"
+	ActionPermissionUpdate = "permission.update"
+	ActionPermissionRevoke = "permission.revoke"
+
+	ActionLinkCreate = "link.create"
+	ActionLinkUpdate = "link.update"
+	ActionLinkDelete = "link.delete"
+	ActionLinkAccess = "link.access"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+const (
+	ActorUser      = "user"
+	ActorAPIKey    = "api_key"
+	ActorAnonymous = "anonymous"
+)
+
+const (
+	TargetUser         = "user"
+	TargetSession      = "session"
+	TargetAPIKey       = "api_key"
+	TargetDocument     = "document"
+	TargetOrganization = "organization"
+	TargetShareLink    = "share_link"
+)
+
+// Entry is an action to record. Actor and request details come from the
+// context; Actor stands in when nobody is signed in yet, as during a login.
+type Entry struct {
+	Action     string
+	Outcome    string
+	Actor      *Actor
+	TargetType string
+	TargetID   string
+	Changes    map[string]Change
+	Metadata   map[string]any
+}
+
+// Change is the value of a field before and after an update.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Recorder writes audit entries. Recording never fails the action being
+// audited; failures are logged by the recorder.
+type Recorder interface {
+	Record(ctx context.Context, entry Entry)
+}
+
+// Discard is a Recorder that records nothing.
+type Discard struct{}
+
+func (Discard) Record(ctx context.Context, entry Entry) {}
+
+// Diff compares the JSON forms of before and after and returns the fields
+// that changed, leaving out the ignored ones.
+func Diff(before, after any, ignore ...string) map[string]Change {
+	b, a := jsonFields(before), jsonFields(after)
+
+	changes := map[string]Change{}
+	for key := range b {
+		if !reflect.DeepEqual(b[key], a[key]) {
+			changes[key] = Change{Before: b[key], After: a[key]}
+		}
+	}
+
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			changes[key] = Change{Before: nil, After: a[key]}
+		}
+	}
+
+	for _, key := range ignore {
+		delete(changes, key)
+	}
+
+	return changes
+}
+
+func jsonFields(v any) map[string]any {
+	fields := map[string]any{}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+type contextKey int
+
+const (
+	requestKey contextKey = iota
+	actorKey
+)
+
+// Request is the HTTP request behind an action.
+type Request struct {
+	ID        string
+	IP        string
+	UserAgent string
+}
+
+// Actor is who is signed in. API keys act as their owner.
+type Actor struct {
+	Type     string
+	UserID   *uuid.UUID
+	APIKeyID *uuid.UUID
+}
+
+func WithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey, request)
+}
+
+func RequestFrom(ctx context.Context) Request {
+	request, _ := ctx.Value(requestKey).(Request)
+	return request
+}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFrom returns the signed in actor, or an anonymous one.
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey).(Actor)
+	if !ok {
+		return Actor{Type: ActorAnonymous}
+	}
+	return actor
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// API keys look like `sd_<prefix>_<secret>`. The prefix is stored in clear
+// for lookup, the secret only as a SHA-256 hash. Keys are random, so a fast
+// hash is enough and keeps per-request verification cheap.
+const (
+	apiKeyScheme    = "sd"
+	apiKeyPrefixLen = 8
+	apiKeySecretLen = 32
+)
+
+var ErrMalformedAPIKey = errors.New("malformed API key")
+
+// GenerateAPIKey returns a new plaintext key together with its prefix and
+// the hash to persist.
+func GenerateAPIKey() (key string, prefix string, hash string, err error) {
+	prefixBytes := make([]byte, apiKeyPrefixLen/2)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+
+	secretBytes := make([]byte, apiKeySecretLen)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = fmt.Sprintf("%s_%s_%s", apiKeyScheme, prefix, hex.EncodeToString(secretBytes))
+
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// ParseAPIKeyPrefix extracts the lookup prefix from a plaintext key.
+func ParseAPIKeyPrefix(key string) (string, error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyScheme || len(parts[1]) != apiKeyPrefixLen || parts[2] == "" {
+		return "", ErrMalformedAPIKey
+	}
+
+	return parts[1], nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareAPIKey reports whether key matches the stored hash in constant time.
+func CompareAPIKey(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
@@ -2,7 +2,7 @@ package auth
 
 import (
 	"errors"
-	"share-docs/pkg/util"
+	"share-docs/pkg/config"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -17,56 +17,89 @@ type TokenPair struct {
 type Claims struct {
 	UserID    uuid.UUID `json:"user_id"`
 	Email     string    `json:"email"`
+	SessionID uuid.UUID `json:"sid"`
+	Scopes    []string  `json:"scopes,omitempty"`
 	TokenType string    `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
-var (
-	accessTokenSecret  = []byte(util.MustGetEnv("JWT_ACCESS_TOKEN_SECRET"))
-	refreshTokenSecret = []byte(util.MustGetEnv("JWT_REFRESH_TOKEN_SECRET"))
-)
+// JWT signs and validates the tokens of every kind. Access and MFA challenge
+// tokens share a key set; refresh tokens have their own unless both come
+// from a keys file.
+type JWT struct {
+	accessTokenKeys  *KeySet
+	refreshTokenKeys *KeySet
+}
+
+func NewJWT(cfg config.JWT) (*JWT, error) {
+	if cfg.KeysFile == "" {
+		return &JWT{
+			accessTokenKeys:  NewHMACKeySet([]byte(cfg.AccessTokenSecret)),
+			refreshTokenKeys: NewHMACKeySet([]byte(cfg.RefreshTokenSecret)),
+		}, nil
+	}
+
+	keys, err := LoadKeySet(cfg.KeysFile, cfg.KeyOverlap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWT{accessTokenKeys: keys, refreshTokenKeys: keys}, nil
+}
+
+// PublicKeys returns the JWKS document for the token signing keys.
+func (j *JWT) PublicKeys() JWKS {
+	return j.accessTokenKeys.JWKS()
+}
 
 const (
-	accessTokenExpiration  = 24 * time.Hour
-	refreshTokenExpiration = 7 * 24 * time.Hour
+	accessTokenExpiration       = 15 * time.Minute
+	RefreshTokenExpiration      = 7 * 24 * time.Hour
+	mfaChallengeTokenExpiration = 5 * time.Minute
 )
 
-func RefreshAccessToken(c Claims) (*string, error) {
-	accessTokenClaims := &Claims{
-		UserID:    c.UserID,
-		Email:     c.Email,
-		TokenType: "access_token",
+// GenerateMFAChallengeToken issues a short-lived token proving the password
+// step of a login succeeded. It is exchanged for a TokenPair once the second
+// factor is verified against the challenge it names.
+func (j *JWT) GenerateMFAChallengeToken(challengeID uuid.UUID, userID uuid.UUID, email string) (string, error) {
+	now := time.Now()
+
+	claims := &Claims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: "mfa_challenge",
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID.String(),
 			Issuer:    "share-docs",
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS512, accessTokenClaims)
-	accessTokenSigned, err := accessToken.SignedString(accessTokenSecret)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &accessTokenSigned, err
+	return j.accessTokenKeys.Sign(claims)
 }
 
-func GenerateTokenPair(userID uuid.UUID, email string) (*TokenPair, error) {
+// GenerateTokenPair signs a new access/refresh pair. The refresh token carries
+// refreshTokenID as its `jti`, and both tokens carry sessionID (the refresh
+// token family) as `sid`. Scopes are only embedded in the access token; they
+// are recomputed from the user on every refresh.
+func (j *JWT) GenerateTokenPair(userID uuid.UUID, email string, scopes []string, sessionID uuid.UUID, refreshTokenID uuid.UUID) (*TokenPair, error) {
+	now := time.Now()
+
 	accessTokenClaims := &Claims{
 		UserID:    userID,
 		Email:     email,
+		SessionID: sessionID,
+		Scopes:    scopes,
 		TokenType: "access_token",
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "share-docs",
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS512, accessTokenClaims)
-	accessTokenSigned, err := accessToken.SignedString(accessTokenSecret)
+	accessTokenSigned, err := j.accessTokenKeys.Sign(accessTokenClaims)
 
 	if err != nil {
 		return nil, err
@@ -75,16 +108,17 @@ func GenerateTokenPair(userID uuid.UUID, email string) (*TokenPair, error) {
 	refreshTokenClaims := &Claims{
 		UserID:    userID,
 		Email:     email,
+		SessionID: sessionID,
 		TokenType: "refresh_token",
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshTokenID.String(),
 			Issuer:    "share-docs",
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(refreshTokenExpiration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(RefreshTokenExpiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS512, refreshTokenClaims)
-	refreshTokenSigned, err := refreshToken.SignedString(refreshTokenSecret)
+	refreshTokenSigned, err := j.refreshTokenKeys.Sign(refreshTokenClaims)
 
 	if err != nil {
 		return nil, err
@@ -103,23 +137,40 @@ type Token int
 const (
 	AccessToken Token = iota
 	RefreshToken
+	MFAChallengeToken
 )
 
-func ValidateToken(tokenString string, tokenType Token) (*Claims, error) {
+var tokenTypeNames = map[Token]string{
+	AccessToken:       "access_token",
+	RefreshToken:      "refresh_token",
+	MFAChallengeToken: "mfa_challenge",
+}
+
+var ErrWrongTokenType = errors.New("token type mismatch")
+
+func (j *JWT) ValidateToken(tokenString string, tokenType Token) (*Claims, error) {
+	var keys *KeySet
+
+	if tokenType == AccessToken || tokenType == MFAChallengeToken {
+		keys = j.accessTokenKeys
+	} else if tokenType == RefreshToken {
+		keys = j.refreshTokenKeys
+	} else {
+		return nil, errors.New("Unsupported JWT token type")
+	}
+
 	claims := &Claims{}
-	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if tokenType == AccessToken {
-			return accessTokenSecret, nil
-		} else if tokenType == RefreshToken {
-			return refreshTokenSecret, nil
-		} else {
-			return nil, errors.New("Unsupported JWT token type")
-		}
-	})
+	_, err := jwt.ParseWithClaims(tokenString, claims, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Token kinds may share a signing key, so the type claim is what keeps
+	// e.g. a challenge token from being used as an access token.
+	if claims.TokenType != tokenTypeNames[tokenType] {
+		return nil, ErrWrongTokenType
+	}
+
 	return claims, err
 }
@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownKeyID   = errors.New("unknown signing key")
+	ErrNoActiveKey    = errors.New("no active signing key")
+	ErrKeyAlgMismatch = errors.New("token algorithm does not match signing key")
+)
+
+// KeyConfig describes one signing key in the JWT keys file. Exactly one key
+// is Active and signs new tokens. The others stay valid for verification and
+// are published in the JWKS: keys without RetiredAt are upcoming keys, keys
+// with RetiredAt are accepted until RetiredAt plus the overlap window.
+type KeyConfig struct {
+	ID             string     `yaml:"kid"`
+	Algorithm      string     `yaml:"algorithm"`
+	PrivateKey     string     `yaml:"private_key"`
+	PrivateKeyFile string     `yaml:"private_key_file"`
+	Active         bool       `yaml:"active"`
+	RetiredAt      *time.Time `yaml:"retired_at"`
+}
+
+type KeysFile struct {
+	Keys []KeyConfig `yaml:"keys"`
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	retiredAt *time.Time
+}
+
+// KeySet signs tokens with its active key and verifies them with any key that
+// is still inside its overlap window.
+type KeySet struct {
+	active  *signingKey
+	keys    map[string]*signingKey
+	overlap time.Duration
+}
+
+// NewHMACKeySet wraps a single shared secret, the legacy HS512 setup. Tokens
+// signed by it carry no `kid`.
+func NewHMACKeySet(secret []byte) *KeySet {
+	key := &signingKey{
+		method:    jwt.SigningMethodHS512,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+
+	return &KeySet{
+		active: key,
+		keys:   map[string]*signingKey{"": key},
+	}
+}
+
+// LoadKeySet reads a YAML keys file. overlap is how long a retired key keeps
+// verifying tokens; it should be at least the refresh token lifetime.
+func LoadKeySet(path string, overlap time.Duration) (*KeySet, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT keys file: %w", err)
+	}
+
+	var file KeysFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse JWT keys file: %w", err)
+	}
+
+	return NewKeySet(file.Keys, overlap)
+}
+
+func NewKeySet(configs []KeyConfig, overlap time.Duration) (*KeySet, error) {
+	ks := &KeySet{
+		keys:    make(map[string]*signingKey, len(configs)),
+		overlap: overlap,
+	}
+
+	for _, kc := range configs {
+		if kc.ID == "" {
+			return nil, fmt.Errorf("JWT key without kid")
+		}
+
+		if _, exists := ks.keys[kc.ID]; exists {
+			return nil, fmt.Errorf("duplicate JWT key %q", kc.ID)
+		}
+
+		key, err := parseKeyConfig(kc)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q: %w", kc.ID, err)
+		}
+
+		if kc.Active {
+			if ks.active != nil {
+				return nil, fmt.Errorf("JWT keys %q and %q are both active", ks.active.id, kc.ID)
+			}
+			if kc.RetiredAt != nil {
+				return nil, fmt.Errorf("JWT key %q is both active and retired", kc.ID)
+			}
+			ks.active = key
+		}
+
+		ks.keys[kc.ID] = key
+	}
+
+	if ks.active == nil {
+		return nil, ErrNoActiveKey
+	}
+
+	return ks, nil
+}
+
+// Sign signs claims with the active key and stamps its `kid` in the header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method, claims)
+	if ks.active.id != "" {
+		token.Header["kid"] = ks.active.id
+	}
+
+	return token.SignedString(ks.active.signKey)
+}
+
+// Keyfunc resolves the verification key for a token from its `kid` header.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+	if !ok || !ks.usable(key, time.Now()) {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrKeyAlgMismatch
+	}
+
+	return key.verifyKey, nil
+}
+
+// ValidMethods lists the algorithms of all keys, for jwt.WithValidMethods.
+func (ks *KeySet) ValidMethods() []string {
+	seen := map[string]bool{}
+	methods := []string{}
+
+	for _, key := range ks.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+func (ks *KeySet) usable(key *signingKey, now time.Time) bool {
+	return key.retiredAt == nil || now.Before(key.retiredAt.Add(ks.overlap))
+}
+
+// JWK is a public key in RFC 7517 format.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that may currently verify tokens. Symmetric
+// keys are never published.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	now := time.Now()
+
+	for _, key := range ks.keys {
+		if !ks.usable(key, now) {
+			continue
+		}
+
+		jwk := JWK{
+			KeyID:     key.id,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	slices.SortFunc(jwks.Keys, func(a, b JWK) int {
+		return strings.Compare(a.KeyID, b.KeyID)
+	})
+
+	return jwks
+}
+
+func parseKeyConfig(kc KeyConfig) (*signingKey, error) {
+	pemBytes := []byte(kc.PrivateKey)
+	if kc.PrivateKeyFile != "" {
+		b, err := os.ReadFile(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pemBytes = b
+	}
+
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key found")
+	}
+
+	var private any
+	var err error
+
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		id:        kc.ID,
+		signKey:   private,
+		retiredAt: kc.RetiredAt,
+	}
+
+	switch kc.Algorithm {
+	case "RS256":
+		rsaKey, ok := private.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("RS256 requires an RSA private key")
+		}
+		key.method = jwt.SigningMethodRS256
+		key.verifyKey = &rsaKey.PublicKey
+	case "EdDSA":
+		edKey, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("EdDSA requires an Ed25519 private key")
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.verifyKey = edKey.Public()
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q (supported: RS256, EdDSA)", kc.Algorithm)
+	}
+
+	return key, nil
+}
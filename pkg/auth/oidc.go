@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownOIDCProvider = errors.New("unknown OIDC provider")
+	ErrOIDCNonceMismatch   = errors.New("OIDC nonce mismatch")
+	ErrOIDCMissingIDToken  = errors.New("OIDC token response has no id_token")
+	ErrOIDCMissingEmail    = errors.New("OIDC ID token has no email claim")
+)
+
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name"`
+	IssuerURL    string   `yaml:"issuer_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+type OIDCProvidersFile struct {
+	Providers []OIDCProviderConfig `yaml:"providers"`
+}
+
+// OIDCIdentity is what we keep from a verified ID token.
+type OIDCIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// OIDCProvider runs the authorization code flow with PKCE against one
+// identity provider. Discovery happens on first use so an unreachable IdP
+// doesn't keep the API from starting.
+type OIDCProvider struct {
+	config OIDCProviderConfig
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+type OIDCProviders struct {
+	providers map[string]*OIDCProvider
+}
+
+// LoadOIDCProviders reads the providers file. An empty path disables SSO.
+func LoadOIDCProviders(path string) (*OIDCProviders, error) {
+	providers := &OIDCProviders{providers: map[string]*OIDCProvider{}}
+
+	if path == "" {
+		return providers, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC providers file: %w", err)
+	}
+
+	var file OIDCProvidersFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC providers file: %w", err)
+	}
+
+	for _, pc := range file.Providers {
+		if pc.Name == "" || pc.IssuerURL == "" || pc.ClientID == "" || pc.RedirectURL == "" {
+			return nil, fmt.Errorf("OIDC provider %q: name, issuer_url, client_id and redirect_url are required", pc.Name)
+		}
+
+		if _, exists := providers.providers[pc.Name]; exists {
+			return nil, fmt.Errorf("duplicate OIDC provider %q", pc.Name)
+		}
+
+		if len(pc.Scopes) == 0 {
+			pc.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+
+		providers.providers[pc.Name] = &OIDCProvider{config: pc}
+	}
+
+	return providers, nil
+}
+
+func (p *OIDCProviders) Get(name string) (*OIDCProvider, error) {
+	provider, ok := p.providers[name]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	return provider, nil
+}
+
+func (p *OIDCProviders) Names() []string {
+	names := make([]string, 0, len(p.providers))
+	for name := range p.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL builds the redirect to the IdP's authorization endpoint.
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauth2Config, _, err := p.oauth2(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange redeems the authorization code and verifies the returned ID token,
+// including its nonce.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*OIDCIdentity, error) {
+	oauth2Config, provider, err := p.oauth2(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauth2Config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrOIDCMissingIDToken
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, ErrOIDCNonceMismatch
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified *bool  `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to parse ID token claims: %w", err)
+	}
+
+	if claims.Email == "" {
+		return nil, ErrOIDCMissingEmail
+	}
+
+	return &OIDCIdentity{
+		Provider:      p.config.Name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified != nil && *claims.EmailVerified,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+func (p *OIDCProvider) oauth2(ctx context.Context) (*oauth2.Config, *oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := oidc.NewProvider(ctx, p.config.IssuerURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("OIDC discovery for %q failed: %w", p.config.Name, err)
+		}
+		p.provider = provider
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     p.provider.Endpoint(),
+		Scopes:       p.config.Scopes,
+	}, p.provider, nil
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+)
+
+type Scope string
+
+const (
+	ScopeDocsRead       Scope = "docs:read"
+	ScopeDocsWrite      Scope = "docs:write"
+	ScopeLinksManage    Scope = "links:manage"
+	ScopeWebhooksManage Scope = "webhooks:manage"
+	ScopeAdmin          Scope = "admin"
+)
+
+var AllScopes = []Scope{
+	ScopeDocsRead,
+	ScopeDocsWrite,
+	ScopeLinksManage,
+	ScopeWebhooksManage,
+	ScopeAdmin,
+}
+
+// ScopesForUser returns the scopes embedded in tokens issued to an
+// interactive user login.
+func ScopesForUser(isAdmin bool) []string {
+	scopes := []string{
+		string(ScopeDocsRead),
+		string(ScopeDocsWrite),
+		string(ScopeLinksManage),
+		string(ScopeWebhooksManage),
+	}
+
+	if isAdmin {
+		scopes = append(scopes, string(ScopeAdmin))
+	}
+
+	return scopes
+}
+
+// HasScope reports whether granted covers required. The admin scope covers
+// every other scope.
+func HasScope(granted []string, required Scope) bool {
+	return slices.Contains(granted, string(required)) || slices.Contains(granted, string(ScopeAdmin))
+}
+
+// ValidateScopes rejects empty or unknown scopes.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+
+	for _, s := range scopes {
+		if !slices.Contains(AllScopes, Scope(s)) {
+			return fmt.Errorf("unknown scope %q", s)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package auth
+
+import "testing"
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required Scope
+		want     bool
+	}{
+		{"granted", []string{"docs:read", "docs:write"}, ScopeDocsWrite, true},
+		{"not granted", []string{"docs:read"}, ScopeDocsWrite, false},
+		{"admin covers everything", []string{"admin"}, ScopeWebhooksManage, true},
+		{"admin itself", []string{"admin"}, ScopeAdmin, true},
+		{"admin needs admin", []string{"docs:read", "docs:write", "links:manage", "webhooks:manage"}, ScopeAdmin, false},
+		{"nothing granted", nil, ScopeDocsRead, false},
+		{"no prefix matching", []string{"docs"}, ScopeDocsRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasScope(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasScope(%v, %s) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{"single scope", []string{"docs:read"}, false},
+		{"every scope", []string{"docs:read", "docs:write", "links:manage", "webhooks:manage", "admin"}, false},
+		{"none", nil, true},
+		{"empty list", []string{}, true},
+		{"unknown", []string{"docs:read", "docs:delete"}, true},
+		{"empty scope", []string{""}, true},
+		{"wrong case", []string{"Docs:Read"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScopes(tt.scopes); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScopes(%v) = %v, want error %v", tt.scopes, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScopesForUser(t *testing.T) {
+	if HasScope(ScopesForUser(false), ScopeAdmin) {
+		t.Error("non-admin users got the admin scope")
+	}
+
+	if !HasScope(ScopesForUser(true), ScopeAdmin) {
+		t.Error("admins did not get the admin scope")
+	}
+
+	for _, isAdmin := range []bool{false, true} {
+		if err := ValidateScopes(ScopesForUser(isAdmin)); err != nil {
+			t.Errorf("ScopesForUser(%v) returned invalid scopes: %v", isAdmin, err)
+		}
+	}
+}
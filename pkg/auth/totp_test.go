@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors,
+// "12345678901234567890", base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	// The RFC vectors are 8 digits; these are their last 6
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfc6238Secret, "287082", 59, 1, true},
+		{"previous step", rfc6238Secret, "287082", 89, 1, true},
+		{"next step", rfc6238Secret, "287082", 29, 1, true},
+		{"two steps old", rfc6238Secret, "287082", 90, 0, false},
+		{"two steps ahead", rfc6238Secret, "081804", 37037034 * 30, 0, false},
+		{"later vector", rfc6238Secret, "081804", 1111111109, 37037036, true},
+		{"surrounding spaces", rfc6238Secret, " 287082 ", 59, 1, true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", 59, 1, true},
+		{"wrong code", rfc6238Secret, "287083", 59, 0, false},
+		{"too short", rfc6238Secret, "28708", 59, 0, false},
+		{"eight digits", rfc6238Secret, "94287082", 59, 0, false},
+		{"invalid secret", "not base32!", "287082", 59, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.at, 0))
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) at %d = (%d, %v), want (%d, %v)", tt.code, tt.at, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
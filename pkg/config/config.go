@@ -0,0 +1,98 @@
+package config
+
+import (
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+// Config is everything share-docs reads from its environment. Each field is
+// loaded from, in increasing precedence, its `default` tag, the YAML file,
+// the `env` variable and the matching flag (HTTP_ADDR becomes --http-addr).
+// Fields tagged `secret` are redacted when the config is logged.
+type Config struct {
+	Environment string `yaml:"environment" env:"ENVIRONMENT" default:"development"`
+	BaseURL     string `yaml:"base_url" env:"APP_BASE_URL" default:"http://localhost:8080"`
+
+	Log      Log      `yaml:"log"`
+	HTTP     HTTP     `yaml:"http"`
+	Database Database `yaml:"database"`
+	Storage  Storage  `yaml:"storage"`
+	JWT      JWT      `yaml:"jwt"`
+	OIDC     OIDC     `yaml:"oidc"`
+	Mail     Mail     `yaml:"mail"`
+	Jobs     Jobs     `yaml:"jobs"`
+}
+
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" default:"info"`
+	Output string `yaml:"output" env:"LOG_OUTPUT" default:"stdout"`
+}
+
+// HTTP is how the server listens. ReadTimeout and WriteTimeout apply to every
+// request; routes moving files extend them to TransferTimeout. Metrics are
+// served on their own listener at MetricsAddr, never on Addr; an empty
+// MetricsAddr turns them off.
+type HTTP struct {
+	Addr              string        `yaml:"addr" env:"HTTP_ADDR" default:":8080"`
+	MetricsAddr       string        `yaml:"metrics_addr" env:"METRICS_ADDR" default:"localhost:9090"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"10s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"30s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"2m"`
+	TransferTimeout   time.Duration `yaml:"transfer_timeout" env:"HTTP_TRANSFER_TIMEOUT" default:"30m"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" default:"1m"`
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+func (h HTTP) TLS() bool {
+	return h.TLSCertFile != "" && h.TLSKeyFile != ""
+}
+
+// Database is the Postgres connection. With AutoMigrate the API applies
+// pending migrations on startup instead of leaving them to `cmd migrate up`.
+type Database struct {
+	Host        string `yaml:"host" env:"DB_HOST" default:"localhost"`
+	Port        int    `yaml:"port" env:"DB_PORT" default:"5432"`
+	User        string `yaml:"user" env:"DB_USER" default:"postgres"`
+	Password    string `yaml:"password" env:"DB_PASSWORD" default:"postgres" secret:"true"`
+	Name        string `yaml:"name" env:"DB_NAME" default:"share_docs"`
+	SSLMode     string `yaml:"ssl_mode" env:"DB_SSLMODE" default:"disable"`
+	AutoMigrate bool   `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE" default:"false"`
+}
+
+type Storage struct {
+	Type      string `yaml:"type" env:"STORAGE_TYPE"`
+	LocalPath string `yaml:"local_path" env:"STORAGE_LOCAL_PATH"`
+}
+
+// JWT selects the token signing keys. With KeysFile set, all tokens are signed
+// by the asymmetric keys listed there; otherwise the HS512 secrets are used,
+// one per token kind.
+type JWT struct {
+	KeysFile           string        `yaml:"keys_file" env:"JWT_KEYS_FILE"`
+	KeyOverlap         time.Duration `yaml:"key_overlap" env:"JWT_KEY_OVERLAP" default:"168h"`
+	AccessTokenSecret  string        `yaml:"access_token_secret" env:"JWT_ACCESS_TOKEN_SECRET" secret:"true"`
+	RefreshTokenSecret string        `yaml:"refresh_token_secret" env:"JWT_REFRESH_TOKEN_SECRET" secret:"true"`
+}
+
+type OIDC struct {
+	ProvidersFile string `yaml:"providers_file" env:"OIDC_PROVIDERS_FILE"`
+}
+
+// Mail is sent over SMTP when SMTPHost is set, and only logged otherwise.
+type Mail struct {
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `yaml:"from" env:"MAIL_FROM" default:"share-docs <no-reply@share-docs.local>"`
+}
+
+// Jobs configures the worker the API runs unless InProcess is false, for
+// deployments that run `cmd worker` instead.
+type Jobs struct {
+	InProcess bool   `yaml:"in_process" env:"JOBS_IN_PROCESS" default:"true"`
+	Queues    string `yaml:"queues" env:"JOB_QUEUES" default:"default=4,mail=2"`
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Load reads the configuration and validates it. args are the command-line
+// flags without the program name; --config, or CONFIG_FILE, names the YAML
+// file. Every invalid value is reported in the returned error, not just the
+// first. flag.ErrHelp is returned when --help was asked for.
+func Load(args []string) (*Config, error) {
+	cfg := &Config{}
+	fields := cfg.fields()
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+
+		if err := f.set(f.def); err != nil {
+			panic(fmt.Sprintf("invalid default for %s: %v", f.env, err))
+		}
+	}
+
+	flags := flag.NewFlagSet("share-docs", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration `file`")
+
+	// Flags win over everything, so they are only recorded while parsing and
+	// applied once the file and environment have been read
+	overrides := map[string]string{}
+	for _, f := range fields {
+		usage := "overrides $" + f.env
+		flags.Func(f.flag(), usage, func(v string) error {
+			overrides[f.env] = v
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file != "" {
+		if err := cfg.readFile(*file); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+
+	for _, f := range fields {
+		if v := os.Getenv(f.env); v != "" {
+			if err := f.set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := overrides[f.env]; ok {
+			if err := f.set(v); err != nil {
+				errs = append(errs, fmt.Errorf("--%s: %w", f.flag(), err))
+			}
+		}
+	}
+
+	errs = append(errs, cfg.Validate()...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(raw))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Redacted lists every setting by its environment variable, with secrets
+// masked, for logging the configuration a process started with.
+func (c *Config) Redacted() map[string]interface{} {
+	values := map[string]interface{}{}
+
+	for _, f := range c.fields() {
+		value := f.String()
+		if f.secret && value != "" {
+			value = redacted
+		}
+
+		values[f.env] = value
+	}
+
+	return values
+}
+
+type field struct {
+	env    string
+	def    string
+	secret bool
+	value  reflect.Value
+}
+
+// fields walks the config struct, sections included, for the fields that
+// have an env tag.
+func (c *Config) fields() []field {
+	var fields []field
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+
+			if sf.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+
+			env, ok := sf.Tag.Lookup("env")
+			if !ok {
+				continue
+			}
+
+			fields = append(fields, field{
+				env:    env,
+				def:    sf.Tag.Get("default"),
+				secret: sf.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+
+	walk(reflect.ValueOf(c).Elem())
+
+	return fields
+}
+
+// flag is the command-line name of the field, e.g. --http-addr for HTTP_ADDR.
+func (f field) flag() string {
+	return strings.ReplaceAll(strings.ToLower(f.env), "_", "-")
+}
+
+func (f field) set(raw string) error {
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(raw)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		f.value.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+		f.value.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s", raw)
+		}
+		f.value.SetInt(int64(d))
+	default:
+		panic(fmt.Sprintf("unsupported config field type %s", f.value.Type()))
+	}
+
+	return nil
+}
+
+func (f field) String() string {
+	return fmt.Sprint(f.value.Interface())
+}
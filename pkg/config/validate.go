@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var supportedStorageTypes = map[string]bool{
+	"local": true,
+}
+
+// Validate checks the settings that can be checked without connecting to
+// anything and returns one error per problem.
+func (c *Config) Validate() []error {
+	var errs []error
+
+	invalid := func(env, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", env, fmt.Sprintf(format, args...)))
+	}
+
+	if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		invalid("APP_BASE_URL", "%q is not an absolute URL", c.BaseURL)
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		invalid("LOG_LEVEL", "%q is not a log level", c.Log.Level)
+	}
+
+	timeouts := []struct {
+		env   string
+		value time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", c.HTTP.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", c.HTTP.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.HTTP.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.HTTP.IdleTimeout},
+		{"HTTP_TRANSFER_TIMEOUT", c.HTTP.TransferTimeout},
+		{"HTTP_SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value <= 0 {
+			invalid(t.env, "must be positive")
+		}
+	}
+
+	if c.HTTP.MetricsAddr != "" && c.HTTP.MetricsAddr == c.HTTP.Addr {
+		invalid("METRICS_ADDR", "must differ from HTTP_ADDR")
+	}
+
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		invalid("TLS_CERT_FILE", "TLS needs both TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if c.Database.Host == "" {
+		invalid("DB_HOST", "is required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		invalid("DB_PORT", "%d is not a port", c.Database.Port)
+	}
+	if c.Database.Name == "" {
+		invalid("DB_NAME", "is required")
+	}
+
+	if c.Storage.Type == "" {
+		invalid("STORAGE_TYPE", "is required")
+	} else if !supportedStorageTypes[c.Storage.Type] {
+		invalid("STORAGE_TYPE", "storage backend %q not supported", c.Storage.Type)
+	} else if c.Storage.Type == "local" {
+		if c.Storage.LocalPath == "" {
+			invalid("STORAGE_LOCAL_PATH", "is required for local storage")
+		} else if info, err := os.Stat(c.Storage.LocalPath); err != nil || !info.IsDir() {
+			invalid("STORAGE_LOCAL_PATH", "%q is not a directory", c.Storage.LocalPath)
+		}
+	}
+
+	if c.JWT.KeysFile == "" {
+		if c.JWT.AccessTokenSecret == "" {
+			invalid("JWT_ACCESS_TOKEN_SECRET", "is required unless JWT_KEYS_FILE is set")
+		}
+		if c.JWT.RefreshTokenSecret == "" {
+			invalid("JWT_REFRESH_TOKEN_SECRET", "is required unless JWT_KEYS_FILE is set")
+		}
+	}
+	if c.JWT.KeyOverlap < 0 {
+		invalid("JWT_KEY_OVERLAP", "must not be negative")
+	}
+
+	if c.Mail.SMTPHost != "" && (c.Mail.SMTPPort < 1 || c.Mail.SMTPPort > 65535) {
+		invalid("SMTP_PORT", "%d is not a port", c.Mail.SMTPPort)
+	}
+
+	return errs
+}
@@ -2,26 +2,17 @@ package db
 
 import (
 	"fmt"
-	"share-docs/pkg/util"
+	"share-docs/pkg/config"
+	"share-docs/pkg/tracing"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-
-	_ "github.com/joho/godotenv/autoload"
-)
-
-var (
-	host     = util.GetEnv("DB_HOST", "localhost")
-	port     = util.GetEnv("DB_PORT", "5432")
-	user     = util.GetEnv("DB_USER", "postgres")
-	password = util.GetEnv("DB_PASSWORD", "postgres")
-	dbname   = util.GetEnv("DB_NAME", "share_docs")
 )
 
-func Connect() *gorm.DB {
+func Connect(cfg config.Database) *gorm.DB {
 	connectionString := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		host, user, password, dbname, port,
+		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		cfg.Host, cfg.User, cfg.Password, cfg.Name, cfg.Port, cfg.SSLMode,
 	)
 
 	db, err := gorm.Open(postgres.Open(connectionString), &gorm.Config{})
@@ -30,6 +21,10 @@ func Connect() *gorm.DB {
 		panic(err)
 	}
 
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		panic(err)
+	}
+
 	fmt.Println("Successfully connected to database!")
 
 	return db
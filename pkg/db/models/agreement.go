@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Agreement is an NDA or terms that viewers of a share link have to accept
+// first. Its text is Content, a document (DocumentID), or both.
+type Agreement struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Name       string     `gorm:"size:255;not null"`
+	Content    *string    `gorm:"type:text"`
+	DocumentID *uuid.UUID `gorm:"type:uuid"`
+	Document   *Document  `gorm:"foreignKey:DocumentID"`
+}
+
+func (a *Agreement) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// AgreementAcceptance is the legal record of a viewer accepting an agreement.
+// Unlike link views it keeps the full IP address.
+type AgreementAcceptance struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	AgreementID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	ShareLinkID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	LinkViewID  *uuid.UUID `gorm:"type:uuid"`
+
+	Name      string `gorm:"size:255;not null"`
+	Email     string `gorm:"size:255;not null"`
+	IPAddress string `gorm:"size:45"`
+	UserAgent string `gorm:"size:255"`
+}
+
+func (a *AgreementAcceptance) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+
+	return nil
+}
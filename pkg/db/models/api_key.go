@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey is a long-lived credential for machine clients. Only a hash of the
+// secret is stored; Prefix is the public part used to find the row.
+type APIKey struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	User   User      `gorm:"foreignKey:UserID"`
+
+	Name       string `gorm:"size:255;not null"`
+	Prefix     string `gorm:"size:16;not null;uniqueIndex"`
+	SecretHash string `gorm:"size:64;not null"`
+	Scopes     string `gorm:"size:500"`
+
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+
+	return nil
+}
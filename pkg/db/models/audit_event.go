@@ -0,0 +1,101 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent is an entry of the append-only audit log. Unlike other models it
+// has no gorm.Model: rows are never updated or deleted, and the database
+// rejects attempts to. Seq orders the log; each row's Hash covers its content
+// and the previous row's hash, so editing or removing a row breaks the chain
+// from there on.
+type AuditEvent struct {
+	Seq       int64     `gorm:"primaryKey;autoIncrement:false"`
+	ID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+	CreatedAt time.Time `gorm:"not null"`
+
+	Action     string     `gorm:"size:100;not null"`
+	Outcome    string     `gorm:"size:20;not null"`
+	ActorType  string     `gorm:"size:20;not null"`
+	ActorID    *uuid.UUID `gorm:"type:uuid"`
+	APIKeyID   *uuid.UUID `gorm:"type:uuid"`
+	TargetType *string    `gorm:"size:50"`
+	TargetID   *string    `gorm:"size:255"`
+
+	IPAddress *string `gorm:"size:64"`
+	UserAgent *string `gorm:"size:512"`
+	RequestID *string `gorm:"size:64"`
+
+	Changes  *string `gorm:"type:jsonb"`
+	Metadata *string `gorm:"type:jsonb"`
+
+	PrevHash string `gorm:"size:64;not null"`
+	Hash     string `gorm:"size:64;not null"`
+}
+
+// ComputeHash hashes the event's content with the previous hash. JSON
+// columns are canonicalised first, since Postgres doesn't keep jsonb as
+// written.
+func (e *AuditEvent) ComputeHash() string {
+	content, _ := json.Marshal(struct {
+		Seq        int64
+		ID         string
+		CreatedAt  string
+		Action     string
+		Outcome    string
+		ActorType  string
+		ActorID    *uuid.UUID
+		APIKeyID   *uuid.UUID
+		TargetType *string
+		TargetID   *string
+		IPAddress  *string
+		UserAgent  *string
+		RequestID  *string
+		Changes    *string
+		Metadata   *string
+	}{
+		Seq:        e.Seq,
+		ID:         e.ID.String(),
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Action:     e.Action,
+		Outcome:    e.Outcome,
+		ActorType:  e.ActorType,
+		ActorID:    e.ActorID,
+		APIKeyID:   e.APIKeyID,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IPAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		Changes:    CanonicalJSON(e.Changes),
+		Metadata:   CanonicalJSON(e.Metadata),
+	})
+
+	sum := sha256.Sum256(append([]byte(e.PrevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// CanonicalJSON re-encodes JSON with sorted keys and no insignificant space.
+func CanonicalJSON(data *string) *string {
+	if data == nil {
+		return nil
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(*data), &v); err != nil {
+		return data
+	}
+
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+
+	s := string(canonical)
+	return &s
+}
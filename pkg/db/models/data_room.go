@@ -0,0 +1,161 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DataRoom is a branded space grouping documents and folders behind a single
+// link. Only the viewers on its list can open it, and viewer groups narrow
+// down which items they see. Like documents, a room belongs either to a user
+// alone or, when OrganizationID is set, to an organisation.
+type DataRoom struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index"`
+	Name           string     `gorm:"size:255;not null"`
+	Description    *string    `gorm:"size:1000"`
+
+	// Branding
+	LogoURL        *string `gorm:"size:1000"`
+	BrandColor     *string `gorm:"size:7"`
+	WelcomeMessage *string `gorm:"type:text"`
+
+	// The room's link; only a hash of its token is stored
+	TokenHash     string `gorm:"size:64;not null;uniqueIndex"`
+	LinkEnabled   bool   `gorm:"not null;default:true"`
+	AllowDownload bool   `gorm:"not null;default:true"`
+	ExpiresAt     *time.Time
+}
+
+func (r *DataRoom) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// IsOpen reports whether viewers can currently use the room's link.
+func (r *DataRoom) IsOpen(now time.Time) bool {
+	return r.LinkEnabled && (r.ExpiresAt == nil || now.Before(*r.ExpiresAt))
+}
+
+// DataRoomItem puts either a folder, with everything below it, or a single
+// document in a room.
+type DataRoomItem struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DataRoomID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	FolderID   *uuid.UUID `gorm:"type:uuid"`
+	Folder     *Folder    `gorm:"foreignKey:FolderID"`
+	DocumentID *uuid.UUID `gorm:"type:uuid"`
+	Document   *Document  `gorm:"foreignKey:DocumentID"`
+}
+
+func (i *DataRoomItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// DataRoomGroup is a set of viewers who only see the room items granted to
+// the group.
+type DataRoomGroup struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DataRoomID uuid.UUID      `gorm:"type:uuid;not null;index"`
+	Name       string         `gorm:"size:255;not null"`
+	Items      []DataRoomItem `gorm:"many2many:data_room_group_items;joinForeignKey:GroupID;joinReferences:ItemID"`
+}
+
+func (g *DataRoomGroup) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// DataRoomViewer is an email allowed into a room. Viewers prove they own the
+// email with a one-time code; only its hash is stored.
+type DataRoomViewer struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DataRoomID uuid.UUID      `gorm:"type:uuid;not null"`
+	GroupID    *uuid.UUID     `gorm:"type:uuid"`
+	Group      *DataRoomGroup `gorm:"foreignKey:GroupID"`
+	Email      string         `gorm:"size:255;not null"`
+
+	CodeHash      *string `gorm:"size:64"`
+	CodeExpiresAt *time.Time
+	CodeAttempts  int `gorm:"not null;default:0"`
+}
+
+func (v *DataRoomViewer) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// DataRoomVisit is a viewer's session in a room. The viewer gets a token for
+// it to browse and open documents; only its hash is stored. IPAddress is
+// anonymised before it is stored.
+type DataRoomVisit struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DataRoomID uuid.UUID      `gorm:"type:uuid;not null;index"`
+	ViewerID   uuid.UUID      `gorm:"type:uuid;not null"`
+	Viewer     DataRoomViewer `gorm:"foreignKey:ViewerID"`
+	TokenHash  string         `gorm:"size:64;not null;uniqueIndex"`
+	IPAddress  string         `gorm:"size:45"`
+	UserAgent  string         `gorm:"size:255"`
+}
+
+func (v *DataRoomVisit) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+
+	return nil
+}
+
+const (
+	DataRoomEventOpened             = "room_opened"
+	DataRoomEventDocumentViewed     = "document_viewed"
+	DataRoomEventDocumentDownloaded = "document_downloaded"
+)
+
+// DataRoomEvent is one entry of a room's activity log.
+type DataRoomEvent struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DataRoomID uuid.UUID      `gorm:"type:uuid;not null;index"`
+	ViewerID   uuid.UUID      `gorm:"type:uuid;not null"`
+	Viewer     DataRoomViewer `gorm:"foreignKey:ViewerID"`
+	VisitID    uuid.UUID      `gorm:"type:uuid;not null"`
+	Type       string         `gorm:"size:30;not null"`
+	DocumentID *uuid.UUID     `gorm:"type:uuid"`
+	Document   *Document      `gorm:"foreignKey:DocumentID"`
+}
+
+func (e *DataRoomEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	return nil
+}
@@ -23,8 +23,15 @@ type Document struct {
 	IsPublic    bool    `gorm:"type:bool"`
 
 	// Relationships
-	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
-	User   User      `gorm:"foreignKey:UserID"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User           User       `gorm:"foreignKey:UserID"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index"`
+	FolderID       *uuid.UUID `gorm:"type:uuid;index"`
+
+	// Set on documents received through an upload request
+	UploadRequestID *uuid.UUID `gorm:"type:uuid;index"`
+	UploaderName    *string    `gorm:"size:255"`
+	UploaderEmail   *string    `gorm:"size:255"`
 }
 
 func (d *Document) BeforeCreate(tx *gorm.DB) error {
@@ -35,6 +42,28 @@ func (d *Document) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (d *Document) AfterCreate(tx *gorm.DB) error {
-	// TODO: send message to pubsub, clear cache, etc
-	return nil
+	return RecordEvent(tx, EventDocumentCreated, d.ID, d.Event())
+}
+
+// AfterDelete records the deletion. Delete a loaded document, not a bare ID,
+// so the hook knows which one went away.
+func (d *Document) AfterDelete(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		return nil
+	}
+
+	return RecordEvent(tx, EventDocumentDeleted, d.ID, d.Event())
+}
+
+// Event is the payload of the document lifecycle events.
+func (d *Document) Event() DocumentEvent {
+	return DocumentEvent{
+		DocumentID:     d.ID,
+		UserID:         d.UserID,
+		OrganizationID: d.OrganizationID,
+		FolderID:       d.FolderID,
+		Title:          d.Title,
+		MimeType:       d.MimeType,
+		FileSize:       d.FileSize,
+	}
 }
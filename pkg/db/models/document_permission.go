@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentPermission grants one person access to one document. Until an
+// invitee without an account accepts, UserID is nil and only a hash of the
+// invitation token is stored.
+type DocumentPermission struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DocumentID  uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Document    Document   `gorm:"foreignKey:DocumentID"`
+	UserID      *uuid.UUID `gorm:"type:uuid;index"`
+	User        *User      `gorm:"foreignKey:UserID"`
+	InvitedByID uuid.UUID  `gorm:"type:uuid;not null"`
+
+	Email     string  `gorm:"size:255;not null"`
+	Role      string  `gorm:"size:20;not null"`
+	TokenHash *string `gorm:"size:64;uniqueIndex"`
+	ExpiresAt *time.Time
+}
+
+func (p *DocumentPermission) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// IsPending reports whether the invitation has not been accepted yet.
+func (p *DocumentPermission) IsPending() bool {
+	return p.UserID == nil
+}
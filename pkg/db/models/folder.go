@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Folder groups documents. Like documents, a folder belongs either to a user
+// alone or, when OrganizationID is set, to an organisation.
+type Folder struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index"`
+	ParentID       *uuid.UUID `gorm:"type:uuid;index"`
+
+	Name string `gorm:"size:255;not null"`
+}
+
+func (f *Folder) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+
+	return nil
+}
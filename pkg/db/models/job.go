@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+	JobCancelled = "cancelled"
+)
+
+// Job is a unit of background work. Workers claim queued jobs whose RunAt
+// has passed and lease them until LockedUntil; a job still running past its
+// lease is assumed lost and queued again. UniqueKey, when set, keeps a job
+// from being enqueued twice.
+type Job struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	Queue     string  `gorm:"size:100;not null;default:default"`
+	Kind      string  `gorm:"size:100;not null"`
+	Args      string  `gorm:"type:jsonb;not null"`
+	UniqueKey *string `gorm:"size:255;uniqueIndex"`
+
+	Status      string    `gorm:"size:20;not null;default:queued"`
+	Attempts    int       `gorm:"not null;default:0"`
+	MaxAttempts int       `gorm:"not null;default:10"`
+	RunAt       time.Time `gorm:"not null"`
+	LockedBy    *string   `gorm:"size:255"`
+	LockedUntil *time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	LastError   *string `gorm:"size:2000"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+
+	if j.RunAt.IsZero() {
+		j.RunAt = time.Now()
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkView records one access to a share link. The viewer gets a token for
+// it to fetch the file and report page dwell times; only its hash is stored.
+// IPAddress is anonymised before it is stored.
+type LinkView struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	ShareLinkID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ShareLink   ShareLink `gorm:"foreignKey:ShareLinkID"`
+	DocumentID  uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	TokenHash     string  `gorm:"size:64;not null;uniqueIndex"`
+	VisitorID     string  `gorm:"size:64;not null"`
+	ViewerEmail   *string `gorm:"size:255"`
+	EmailVerified bool    `gorm:"not null;default:false"`
+	IPAddress     string  `gorm:"size:45"`
+	UserAgent     string  `gorm:"size:255"`
+	Referrer      string  `gorm:"size:1000"`
+	PageCount     *int
+}
+
+func (v *LinkView) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// LinkViewPage is the time a viewer spent on one page of a PDF during a view.
+type LinkViewPage struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	LinkViewID uuid.UUID `gorm:"type:uuid;not null"`
+	Page       int       `gorm:"not null"`
+	DurationMs int64     `gorm:"not null;default:0"`
+}
+
+func (p *LinkViewPage) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Organization struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	Name string `gorm:"size:255;not null"`
+
+	Memberships []OrganizationMembership `gorm:"foreignKey:OrganizationID"`
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+
+	return nil
+}
+
+type OrganizationMembership struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+	UserID         uuid.UUID    `gorm:"type:uuid;not null;index"`
+	User           User         `gorm:"foreignKey:UserID"`
+	Role           string       `gorm:"size:20;not null"`
+}
+
+func (m *OrganizationMembership) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// OrganizationInvitation invites an email address, which may not have an
+// account yet, to join an organisation. Only a hash of the token is stored.
+type OrganizationInvitation struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	OrganizationID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID"`
+	InvitedByID    uuid.UUID    `gorm:"type:uuid;not null"`
+
+	Email      string `gorm:"size:255;not null"`
+	Role       string `gorm:"size:20;not null"`
+	TokenHash  string `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+}
+
+func (i *OrganizationInvitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+
+	return nil
+}
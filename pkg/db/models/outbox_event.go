@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	EventDocumentCreated = "document.created"
+	EventDocumentUpdated = "document.updated"
+	EventDocumentDeleted = "document.deleted"
+	EventLinkViewed      = "link.viewed"
+	EventUserRegistered  = "user.registered"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes. The events relay hands it to every sink and sets
+// DispatchedAt; until then it is retried, so delivery is at least once.
+// DeliveredTo lists the sinks that already took it, space separated.
+type OutboxEvent struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	Type        string    `gorm:"size:100;not null"`
+	AggregateID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Payload     string    `gorm:"type:jsonb;not null"`
+
+	Attempts     int       `gorm:"not null;default:0"`
+	AvailableAt  time.Time `gorm:"not null"`
+	DeliveredTo  string    `gorm:"size:1000;not null;default:''"`
+	LastError    *string   `gorm:"size:1000"`
+	DispatchedAt *time.Time
+	FailedAt     *time.Time
+}
+
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	if e.AvailableAt.IsZero() {
+		e.AvailableAt = time.Now()
+	}
+
+	return nil
+}
+
+// RecordEvent adds an event to the outbox. Call it with the transaction that
+// makes the change, so the event commits or rolls back together with it.
+func RecordEvent(tx *gorm.DB, eventType string, aggregateID uuid.UUID, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	event := &OutboxEvent{
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     string(data),
+	}
+
+	return tx.Session(&gorm.Session{NewDB: true}).Create(event).Error
+}
+
+type DocumentEvent struct {
+	DocumentID     uuid.UUID  `json:"document_id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	FolderID       *uuid.UUID `json:"folder_id,omitempty"`
+	Title          *string    `json:"title,omitempty"`
+	MimeType       string     `json:"mime_type"`
+	FileSize       int64      `json:"file_size"`
+}
+
+type LinkViewedEvent struct {
+	ShareLinkID uuid.UUID `json:"share_link_id"`
+	DocumentID  uuid.UUID `json:"document_id"`
+	ViewID      uuid.UUID `json:"view_id"`
+	ViewerEmail *string   `json:"viewer_email,omitempty"`
+}
+
+type UserRegisteredEvent struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a bcrypt hashed, single-use second factor.
+type RecoveryCode struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash string    `gorm:"size:255;not null"`
+	UsedAt   *time.Time
+}
+
+func (r *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// MFAChallenge is the second step of a login, between the password and the
+// two-factor code. It counts the codes tried against it and is deleted once
+// one is accepted.
+type MFAChallenge struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time
+
+	UserID    uuid.UUID `gorm:"type:uuid;not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	ExpiresAt time.Time
+}
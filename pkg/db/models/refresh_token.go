@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is the server-side record of an issued refresh token. Its ID
+// is the token's `jti`; every rotation creates a new row in the same family.
+// The family is the Session the token belongs to.
+type RefreshToken struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index"`
+	FamilyID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	ReplacedByID *uuid.UUID `gorm:"type:uuid"`
+
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// IsUsable reports whether the token can still be exchanged for a new pair.
+func (t *RefreshToken) IsUsable(now time.Time) bool {
+	return t.RevokedAt == nil && t.ReplacedByID == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session groups a refresh token family together with the client it was
+// issued to. A session's ID is the FamilyID of its refresh tokens.
+type Session struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	UserAgent  string `gorm:"size:512"`
+	IPAddress  string `gorm:"size:64"`
+	LastUsedAt time.Time
+	RevokedAt  *time.Time
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareLink gives anyone holding its token access to a document. Only a hash
+// of the token is stored. Allowlist is a space-separated list of emails and
+// "@domain" entries; a non-empty allowlist implies RequireEmail and
+// VerifyEmail.
+type ShareLink struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	DocumentID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	Document    Document  `gorm:"foreignKey:DocumentID"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null"`
+
+	Name         *string `gorm:"size:255"`
+	TokenHash    string  `gorm:"size:64;not null;uniqueIndex"`
+	PasswordHash *string `gorm:"size:255"`
+	ExpiresAt    *time.Time
+
+	RequireEmail bool   `gorm:"not null;default:false"`
+	VerifyEmail  bool   `gorm:"not null;default:false"`
+	Allowlist    string `gorm:"not null;default:''"`
+
+	// AllowDownload off makes the link view-only. MaxViews caps the number of
+	// times the link can be opened. Watermark stamps the viewer onto PDFs and
+	// images as they are served.
+	AllowDownload bool `gorm:"not null;default:true"`
+	MaxViews      *int
+	Watermark     bool `gorm:"not null;default:false"`
+
+	// AgreementID makes viewers accept an agreement before they get access
+	AgreementID *uuid.UUID `gorm:"type:uuid;index"`
+	Agreement   *Agreement `gorm:"foreignKey:AgreementID"`
+}
+
+func (l *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+
+	return nil
+}
+
+func (l *ShareLink) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && now.After(*l.ExpiresAt)
+}
+
+// EmailGated reports whether viewers have to enter an email.
+func (l *ShareLink) EmailGated() bool {
+	return l.RequireEmail || l.VerifyEmail || l.Allowlist != ""
+}
+
+// EmailVerificationRequired reports whether the email has to be confirmed
+// with a one-time code.
+func (l *ShareLink) EmailVerificationRequired() bool {
+	return l.VerifyEmail || l.Allowlist != ""
+}
+
+// LinkEmailCode is a one-time code emailed to a viewer of an email-verified
+// link. Only a hash of the code is stored.
+type LinkEmailCode struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	ShareLinkID uuid.UUID `gorm:"type:uuid;not null"`
+	Email       string    `gorm:"size:255;not null"`
+	CodeHash    string    `gorm:"size:64;not null"`
+	Attempts    int       `gorm:"not null;default:0"`
+	ExpiresAt   time.Time
+}
+
+func (c *LinkEmailCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UploadRequest is a link that lets people without an account upload files
+// into the owner's space, optionally into a folder. Only a hash of its token
+// is stored. AllowedTypes is a space-separated list of MIME types ("image/*"
+// included) and extensions; empty allows any type.
+type UploadRequest struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	User           User       `gorm:"foreignKey:UserID"`
+	OrganizationID *uuid.UUID `gorm:"type:uuid"`
+	FolderID       *uuid.UUID `gorm:"type:uuid"`
+	Name           string     `gorm:"size:255;not null"`
+	Message        *string    `gorm:"size:1000"`
+
+	TokenHash    string  `gorm:"size:64;not null;uniqueIndex"`
+	PasswordHash *string `gorm:"size:255"`
+	ExpiresAt    *time.Time
+	MaxFileSize  *int64
+	AllowedTypes string `gorm:"size:1000;not null;default:''"`
+}
+
+func (r *UploadRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+
+	return nil
+}
+
+func (r *UploadRequest) IsExpired(now time.Time) bool {
+	return r.ExpiresAt != nil && now.After(*r.ExpiresAt)
+}
@@ -18,7 +18,17 @@ type User struct {
 	BirthDate  *time.Time
 	IsActive   bool
 	IsVerified bool
+	IsAdmin    bool
 	// TODO: add last logged in at
+
+	// Two-factor authentication
+	TOTPSecret       *string `gorm:"column:totp_secret;size:64"`
+	TOTPEnabled      bool    `gorm:"column:totp_enabled"`
+	TOTPLastUsedStep int64   `gorm:"column:totp_last_used_step"`
+
+	// Wrong two-factor codes in a row, across challenges
+	MFAFailures    int        `gorm:"column:mfa_failures"`
+	MFALockedUntil *time.Time `gorm:"column:mfa_locked_until"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -28,3 +38,10 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 
 	return nil
 }
+
+func (u *User) AfterCreate(tx *gorm.DB) error {
+	return RecordEvent(tx, EventUserRegistered, u.ID, UserRegisteredEvent{
+		UserID: u.ID,
+		Email:  u.Email,
+	})
+}
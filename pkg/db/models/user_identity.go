@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to an account at an external OIDC provider.
+type UserIdentity struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Provider string    `gorm:"size:100;not null"`
+	Subject  string    `gorm:"size:255;not null"`
+	Email    string    `gorm:"size:255;not null"`
+}
+
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+
+	return nil
+}
+
+// OIDCLoginState holds the state, nonce and PKCE verifier of an OIDC login
+// between the redirect to the provider and the callback. Rows are single use.
+type OIDCLoginState struct {
+	State     string `gorm:"primaryKey;size:64"`
+	CreatedAt time.Time
+
+	Provider     string `gorm:"size:100;not null"`
+	Nonce        string `gorm:"size:64;not null"`
+	CodeVerifier string `gorm:"size:128;not null"`
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint receives the events listed in EventTypes, space separated,
+// for documents its owner can read. Secret signs the deliveries, so unlike
+// tokens it is stored as is.
+type WebhookEndpoint struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	User        User      `gorm:"foreignKey:UserID"`
+	URL         string    `gorm:"size:2000;not null"`
+	Description *string   `gorm:"size:255"`
+	EventTypes  string    `gorm:"size:1000;not null"`
+	Secret      string    `gorm:"size:64;not null"`
+
+	Enabled             bool `gorm:"not null;default:true"`
+	ConsecutiveFailures int  `gorm:"not null;default:0"`
+	DisabledAt          *time.Time
+	DisabledReason      *string `gorm:"size:255"`
+}
+
+func (e *WebhookEndpoint) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	return nil
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookDelivery is one event sent to one endpoint, with the outcome of its
+// latest attempt. Deliveries that run out of attempts are dead letters until
+// they are redelivered by hand.
+type WebhookDelivery struct {
+	gorm.Model
+	ID uuid.UUID `gorm:"type:uuid,primaryKey;default;gen_random_uuid()"`
+
+	EndpointID uuid.UUID `gorm:"type:uuid;not null"`
+	EventID    uuid.UUID `gorm:"type:uuid;not null"`
+	EventType  string    `gorm:"size:100;not null"`
+	Payload    string    `gorm:"type:jsonb;not null"`
+
+	Status         string    `gorm:"size:20;not null;default:pending"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"not null"`
+	LastAttemptAt  *time.Time
+	ResponseStatus *int
+	LastError      *string `gorm:"size:1000"`
+	DeliveredAt    *time.Time
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+
+	if d.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = time.Now()
+	}
+
+	return nil
+}
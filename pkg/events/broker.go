@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Publisher is the part of a message broker client the broker sink needs. A
+// NATS connection or a Redis PUBLISH wrapper satisfies it.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// BrokerSink publishes events as JSON on "<prefix>.<event type>", e.g.
+// "share-docs.document.created".
+type BrokerSink struct {
+	prefix    string
+	publisher Publisher
+}
+
+func NewBrokerSink(prefix string, publisher Publisher) *BrokerSink {
+	return &BrokerSink{prefix: prefix, publisher: publisher}
+}
+
+func (s *BrokerSink) Name() string {
+	return "broker"
+}
+
+func (s *BrokerSink) Deliver(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	return s.publisher.Publish(ctx, s.prefix+"."+event.Type, data)
+}
+
+// MemoryBroker is an in-process stand-in for NATS, for development and for
+// running without a broker. Subjects are dot separated; in a subscription "*"
+// matches one token and a trailing ">" matches the rest.
+type MemoryBroker struct {
+	mu            sync.RWMutex
+	subscriptions []memorySubscription
+}
+
+type memorySubscription struct {
+	pattern string
+	handler func(subject string, data []byte)
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+func (b *MemoryBroker) Subscribe(pattern string, handler func(subject string, data []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions = append(b.subscriptions, memorySubscription{pattern: pattern, handler: handler})
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, subject string, data []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subscriptions {
+		if subjectMatches(sub.pattern, subject) {
+			sub.handler(subject, data)
+		}
+	}
+
+	return nil
+}
+
+func subjectMatches(pattern string, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return i < len(subjectTokens)
+		}
+
+		if i >= len(subjectTokens) || (token != "*" && token != subjectTokens[i]) {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+type Handler func(ctx context.Context, event Event) error
+
+// Bus is the in-process sink: it calls the handlers subscribed to an event's
+// type. When one fails the whole event is retried, so handlers must be
+// idempotent.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *Bus) Name() string {
+	return "in-process"
+}
+
+func (b *Bus) Deliver(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := append(append([]Handler{}, b.handlers[event.Type]...), b.handlers[AllEvents]...)
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s handler failed: %w", event.Type, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
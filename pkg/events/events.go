@@ -0,0 +1,37 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a domain event as sinks receive it. Delivery is at least once, so
+// sinks and handlers should use ID to drop duplicates.
+type Event struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+func fromOutbox(e models.OutboxEvent) Event {
+	return Event{
+		ID:          e.ID,
+		Type:        e.Type,
+		AggregateID: e.AggregateID,
+		OccurredAt:  e.CreatedAt,
+		Payload:     json.RawMessage(e.Payload),
+	}
+}
+
+// Sink receives events from the relay. An error makes the relay retry the
+// event later for this sink; sinks that already took it are skipped.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, event Event) error
+}
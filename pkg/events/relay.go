@@ -0,0 +1,169 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/logger"
+	"share-docs/pkg/util"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	relayInterval    = time.Second
+	relayBatchSize   = 100
+	relayLease       = time.Minute
+	relayMaxAttempts = 20
+	relayMinBackoff  = 5 * time.Second
+	relayMaxBackoff  = time.Hour
+	deliveryTimeout  = 10 * time.Second
+)
+
+// Relay moves events from the outbox to the sinks. Claiming a batch pushes
+// its available_at forward by a lease, so a relay that dies mid-batch leaves
+// the events to be picked up again once the lease runs out. Several relays
+// can run side by side; SKIP LOCKED keeps them off each other's rows.
+type Relay struct {
+	db    *gorm.DB
+	log   *logger.Logger
+	sinks []Sink
+}
+
+func NewRelay(db *gorm.DB, log *logger.Logger, sinks ...Sink) *Relay {
+	return &Relay{db: db, log: log, sinks: sinks}
+}
+
+// Run dispatches events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.dispatchBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.log.WithError(err).Error("Failed to dispatch outbox events")
+			}
+
+			if err != nil || n < relayBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) dispatchBatch(ctx context.Context) (int, error) {
+	outbox, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range outbox {
+		if err := r.dispatch(ctx, event); err != nil {
+			return len(outbox), err
+		}
+	}
+
+	return len(outbox), nil
+}
+
+func (r *Relay) claim(ctx context.Context) ([]models.OutboxEvent, error) {
+	now := time.Now()
+
+	var outbox []models.OutboxEvent
+
+	result := r.db.WithContext(ctx).Raw(`
+		UPDATE outbox_events
+		SET attempts = attempts + 1, available_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE dispatched_at IS NULL AND failed_at IS NULL AND deleted_at IS NULL AND available_at <= ?
+			ORDER BY created_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(relayLease), now, now, relayBatchSize,
+	).Scan(&outbox)
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", result.Error)
+	}
+
+	// RETURNING has no order
+	slices.SortFunc(outbox, func(a, b models.OutboxEvent) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return outbox, nil
+}
+
+// dispatch hands an event to the sinks that haven't taken it yet and records
+// the outcome.
+func (r *Relay) dispatch(ctx context.Context, outbox models.OutboxEvent) error {
+	event := fromOutbox(outbox)
+	delivered := strings.Fields(outbox.DeliveredTo)
+
+	var errs []error
+	for _, sink := range r.sinks {
+		if slices.Contains(delivered, sink.Name()) {
+			continue
+		}
+
+		deliverCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+		err := sink.Deliver(deliverCtx, event)
+		cancel()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+
+		delivered = append(delivered, sink.Name())
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"delivered_to": strings.Join(delivered, " "),
+	}
+
+	if len(errs) == 0 {
+		updates["dispatched_at"] = now
+		updates["last_error"] = nil
+	} else {
+		updates["last_error"] = util.Truncate(errors.Join(errs...).Error(), 1000)
+
+		log := r.log.WithFields(map[string]interface{}{
+			"event_id":   outbox.ID,
+			"event_type": outbox.Type,
+			"attempts":   outbox.Attempts,
+		}).WithError(errors.Join(errs...))
+
+		if outbox.Attempts >= relayMaxAttempts {
+			updates["failed_at"] = now
+			log.Error("Giving up on outbox event")
+		} else {
+			updates["available_at"] = now.Add(util.Backoff(outbox.Attempts, relayMinBackoff, relayMaxBackoff))
+			log.Warn("Outbox event delivery failed, will retry")
+		}
+	}
+
+	// A cancelled relay still records what it delivered
+	result := r.db.WithContext(context.WithoutCancel(ctx)).Model(&models.OutboxEvent{}).Where("id = ?", outbox.ID).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update outbox event: %w", result.Error)
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AgreementHandler struct {
+	BaseHandler
+	agreementService services.AgreementServiceInterface
+}
+
+func NewAgreementHandler(agreementService services.AgreementServiceInterface, baseHandler BaseHandler) *AgreementHandler {
+	return &AgreementHandler{
+		BaseHandler:      baseHandler,
+		agreementService: agreementService,
+	}
+}
+
+func (h *AgreementHandler) CreateAgreement(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req linkapp.CreateAgreement
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	agreement, err := h.agreementService.CreateAgreement(c.Request.Context(), userID, req)
+
+	if err != nil {
+		h.handleAgreementError(c, err)
+		return
+	}
+
+	h.Created(c, agreement, "Agreement created")
+}
+
+func (h *AgreementHandler) ListAgreements(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	agreements, err := h.agreementService.ListAgreements(c.Request.Context(), userID)
+
+	if err != nil {
+		h.handleAgreementError(c, err)
+		return
+	}
+
+	h.Success(c, agreements, "")
+}
+
+func (h *AgreementHandler) GetAgreement(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	agreement, err := h.agreementService.GetAgreement(userID, c.Param("id"))
+
+	if err != nil {
+		h.handleAgreementError(c, err)
+		return
+	}
+
+	h.Success(c, agreement, "")
+}
+
+func (h *AgreementHandler) DeleteAgreement(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.agreementService.DeleteAgreement(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleAgreementError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Agreement deleted")
+}
+
+// ListAcceptances lists the acceptances of an agreement, or exports all of
+// them as a CSV file with ?format=csv.
+func (h *AgreementHandler) ListAcceptances(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		var out bytes.Buffer
+		if err := h.agreementService.ExportAcceptances(c.Request.Context(), userID, c.Param("id"), &out); err != nil {
+			h.handleAgreementError(c, err)
+			return
+		}
+
+		filename := fmt.Sprintf("agreement-%s-acceptances.csv", c.Param("id"))
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", out.Bytes())
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	acceptances, total, err := h.agreementService.ListAcceptances(c.Request.Context(), userID, c.Param("id"), page, limit)
+
+	if err != nil {
+		h.handleAgreementError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, acceptances, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *AgreementHandler) handleAgreementError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrAgreementEmpty:
+		h.BadRequest(c, err.Error())
+	case services.ErrAgreementNotFound:
+		h.NotFound(c, "Agreement not found")
+	case services.ErrDocumentNotFound:
+		h.NotFound(c, "Document not found")
+	default:
+		log.WithError(err).Error("Agreement request failed")
+		h.InternalError(c, "Agreement request failed")
+	}
+}
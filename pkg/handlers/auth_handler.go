@@ -1,18 +1,34 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"net/http"
+	"share-docs/pkg/app/domain/userapp"
 	"share-docs/pkg/auth"
 	"share-docs/pkg/services"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// oidcStateCookie binds an OIDC login to the browser that started it: the
+// callback is only accepted with the state the cookie holds. It lives as long
+// as the stored login state.
+const (
+	oidcStateCookie       = "oidc_state"
+	oidcStateCookieMaxAge = 10 * 60
+)
+
 type AuthHandler struct {
 	BaseHandler
-	userService services.UserServiceInterface
+	userService  services.UserServiceInterface
+	tokenService services.TokenServiceInterface
+	mfaService   services.MFAServiceInterface
+	oidcService  services.OIDCServiceInterface
+	jwt          *auth.JWT
 }
 
 type RegisterRequest struct {
@@ -28,10 +44,24 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required,min=8,max=128"`
 }
 
-func NewAuthHandler(userService services.UserServiceInterface, baseHandler BaseHandler) *AuthHandler {
+type MFAChallengeResponse struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=32"`
+}
+
+func NewAuthHandler(userService services.UserServiceInterface, tokenService services.TokenServiceInterface, mfaService services.MFAServiceInterface, oidcService services.OIDCServiceInterface, jwt *auth.JWT, baseHandler BaseHandler) *AuthHandler {
 	return &AuthHandler{
-		BaseHandler: baseHandler,
-		userService: userService,
+		BaseHandler:  baseHandler,
+		userService:  userService,
+		tokenService: tokenService,
+		mfaService:   mfaService,
+		oidcService:  oidcService,
+		jwt:          jwt,
 	}
 }
 
@@ -42,7 +72,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(req.Email, req.Password, req.FirstName, req.LastName, &req.BirthDate)
+	user, err := h.userService.CreateUser(c.Request.Context(), req.Email, req.Password, req.FirstName, req.LastName, &req.BirthDate)
 
 	if err != nil {
 		fmt.Printf("Error: %v", err)
@@ -63,36 +93,59 @@ func (h *AuthHandler) Register(c *gin.Context) {
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
-	log := h.GetLogger(c)
-
 	var req LoginRequest
 	if err := h.BindAndValidate(c, &req); err != nil {
 		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
 	}
 
-	user, err := h.userService.GetUserByEmail(req.Email)
+	user, err := h.userService.LoginWithEmailPassword(c.Request.Context(), req.Email, req.Password)
 
 	if err != nil {
 		switch err {
-		case services.ErrUserNotFound:
+		case services.ErrInvalidCredentials:
 			h.Unauthorized(c, "Invalid email or password")
 		default:
+			h.GetLogger(c).WithError(err).Error("Failed to login user")
 			h.InternalError(c, "Failed to login user")
 		}
 		return
 	}
 
-	user, err = h.userService.LoginWithEmailPassword(req.Email, req.Password)
-	if err != nil {
-		h.InternalError(c, "Failed validating password")
-		return
-	}
+	h.completeLogin(c, user)
+}
+
+// completeLogin issues a TokenPair for an authenticated user, or an MFA
+// challenge when the user has two-factor authentication enabled.
+func (h *AuthHandler) completeLogin(c *gin.Context, user *userapp.User) {
+	log := h.GetLogger(c)
 
 	// Generate JWT token
 	userID := uuid.MustParse(user.ID)
 	userEmail := user.Email
 
-	tokenPair, err := auth.GenerateTokenPair(userID, userEmail)
+	if user.MFAEnabled {
+		challengeID, err := h.mfaService.BeginChallenge(c.Request.Context(), userID)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to open MFA challenge")
+			h.InternalError(c, "Failed to generate MFA challenge")
+			return
+		}
+
+		challengeToken, err := h.jwt.GenerateMFAChallengeToken(challengeID, userID, userEmail)
+
+		if err != nil {
+			log.WithError(err).Error("Failed to issue MFA challenge")
+			h.InternalError(c, "Failed to generate MFA challenge")
+			return
+		}
+
+		h.Success(c, MFAChallengeResponse{MFARequired: true, ChallengeToken: challengeToken}, "Two-factor authentication required")
+		return
+	}
+
+	tokenPair, err := h.tokenService.IssueTokenPair(c.Request.Context(), userID, userEmail, h.GetClientInfo(c))
 
 	if err != nil {
 		log.WithFields(map[string]any{
@@ -107,6 +160,60 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	h.Success(c, tokenPair, "")
 }
 
+// LoginMFA completes a login started by Login for users with two-factor
+// authentication, exchanging the challenge token and a TOTP or recovery code
+// for a TokenPair.
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	var req MFALoginRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	claims, err := h.jwt.ValidateToken(req.ChallengeToken, auth.MFAChallengeToken)
+
+	if err != nil {
+		h.Unauthorized(c, "MFA challenge has expired! Login again!")
+		return
+	}
+
+	challengeID, err := uuid.Parse(claims.ID)
+
+	if err != nil {
+		h.Unauthorized(c, "MFA challenge has expired! Login again!")
+		return
+	}
+
+	if err := h.mfaService.Verify(c.Request.Context(), challengeID, claims.UserID, req.Code); err != nil {
+		switch err {
+		case services.ErrMFAChallengeClosed:
+			h.Unauthorized(c, "MFA challenge has expired or had too many attempts! Login again!")
+		case services.ErrInvalidMFACode:
+			h.Unauthorized(c, "Invalid two-factor code")
+		case services.ErrMFALocked:
+			h.failedRequest(c, "Too many invalid two-factor codes! Try again later!", http.StatusTooManyRequests)
+		case services.ErrMFANotEnabled, services.ErrUserNotFound:
+			h.Unauthorized(c, "Two-factor authentication is not enabled")
+		default:
+			log.WithError(err).Error("Failed verifying MFA code")
+			h.InternalError(c, "Failed to verify two-factor code")
+		}
+		return
+	}
+
+	tokenPair, err := h.tokenService.IssueTokenPair(c.Request.Context(), claims.UserID, claims.Email, h.GetClientInfo(c))
+
+	if err != nil {
+		log.WithError(err).Error("JWT failed signature!")
+		h.InternalError(c, "Failed to generate JWT token")
+		return
+	}
+
+	h.Success(c, tokenPair, "")
+}
+
 type RefreshAccessTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
@@ -122,28 +229,159 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	log.WithField("refresh_token", req.RefreshToken).Info("Token from request")
+	tokenPair, err := h.tokenService.RotateRefreshToken(c.Request.Context(), req.RefreshToken, h.GetClientInfo(c))
 
-	rtc, err := auth.ValidateToken(req.RefreshToken, auth.RefreshToken)
+	if err != nil {
+		switch err {
+		case services.ErrRefreshTokenReused:
+			log.WithError(err).Warn("Refresh token replayed, session revoked")
+			h.Unauthorized(c, "Refresh token has already been used! Login to generate a new one!")
+		case services.ErrInvalidRefreshToken:
+			log.WithError(err).Error("Invalid refresh token!")
+			h.Unauthorized(c, "Refresh token has expired! Login to generate a new one!")
+		default:
+			log.WithError(err).Error("Failed to rotate refresh token")
+			h.InternalError(c, "Failed to refresh token")
+		}
+		return
+	}
+
+	h.Success(c, tokenPair, "Successfully refreshed token")
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	var req LogoutRequest
+
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, "Invalid request body! 'refresh_token' required")
+		return
+	}
+
+	if err := h.tokenService.RevokeRefreshToken(c.Request.Context(), req.RefreshToken); err != nil {
+		switch err {
+		case services.ErrInvalidRefreshToken:
+			h.Unauthorized(c, "Invalid refresh token")
+		default:
+			log.WithError(err).Error("Failed to revoke refresh token")
+			h.InternalError(c, "Failed to logout")
+		}
+		return
+	}
+
+	h.Success(c, nil, "Successfully logged out")
+}
+
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	userID, err := h.GetUserIDFromContext(c)
 
 	if err != nil {
-		log.WithError(err).Error("Invalid refresh token!")
-		h.Unauthorized(c, "Refresh token has expired! Login to generate a new one!")
+		h.Unauthorized(c, "Failed getting UserID!")
 		return
 	}
 
-	accessToken, err := auth.RefreshAccessToken(*rtc)
+	if err := h.tokenService.RevokeAllForUser(c.Request.Context(), userID); err != nil {
+		log.WithError(err).Error("Failed to revoke refresh tokens")
+		h.InternalError(c, "Failed to logout from all sessions")
+		return
+	}
+
+	h.Success(c, nil, "Successfully logged out from all sessions")
+}
+
+// JWKS publishes the public token signing keys so other services can verify
+// our tokens.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwt.PublicKeys())
+}
+
+func (h *AuthHandler) OIDCProviders(c *gin.Context) {
+	h.Success(c, h.oidcService.Providers(), "")
+}
+
+// OIDCLogin redirects the browser to the identity provider.
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	redirectURL, state, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"))
 
 	if err != nil {
-		log.WithError(err).Error("Failed to issue new access_token")
-		h.InternalError(c, "Failed to issue new access_token")
+		switch err {
+		case auth.ErrUnknownOIDCProvider:
+			h.NotFound(c, "Unknown identity provider")
+		default:
+			log.WithError(err).Error("Failed to start OIDC login")
+			h.InternalError(c, "Failed to start single sign-on")
+		}
+		return
+	}
+
+	h.setOIDCStateCookie(c, state, oidcStateCookieMaxAge)
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// setOIDCStateCookie scopes the state cookie to the provider's login and
+// callback routes. A negative maxAge removes it.
+func (h *AuthHandler) setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	path := c.Request.URL.Path[:strings.LastIndex(c.Request.URL.Path, "/")]
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, path, "", secure, true)
+}
+
+// OIDCCallback finishes the authorization code flow and logs the user in.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		log.WithField("error_description", c.Query("error_description")).Error("Identity provider returned an error")
+		h.Unauthorized(c, fmt.Sprintf("Identity provider error: %s", providerErr))
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		h.BadRequest(c, "'state' and 'code' query parameters are required")
 		return
 	}
 
-	var res = &auth.TokenPair{
-		AccessToken:  *accessToken,
-		RefreshToken: req.RefreshToken,
+	// A callback opened in another browser than the login, as in login CSRF,
+	// has no cookie to match
+	cookie, _ := c.Cookie(oidcStateCookie)
+	h.setOIDCStateCookie(c, "", -1)
+
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		h.BadRequest(c, "Login session expired, try again")
+		return
+	}
+
+	user, err := h.oidcService.CompleteLogin(c.Request.Context(), c.Param("provider"), state, code)
+
+	if err != nil {
+		switch err {
+		case auth.ErrUnknownOIDCProvider:
+			h.NotFound(c, "Unknown identity provider")
+		case services.ErrInvalidOIDCState:
+			h.BadRequest(c, "Login session expired, try again")
+		case services.ErrUnverifiedSSOEmail:
+			h.Forbidden(c, "Your identity provider has not verified your email, so it cannot be linked to an existing account")
+		case services.ErrInvalidEmail, auth.ErrOIDCMissingEmail:
+			h.BadRequest(c, "Identity provider did not return a usable email")
+		default:
+			log.WithError(err).Error("Failed to complete OIDC login")
+			h.Unauthorized(c, "Single sign-on failed")
+		}
+		return
 	}
 
-	h.Success(c, res, "Successfully refreshed token")
+	h.completeLogin(c, user)
 }
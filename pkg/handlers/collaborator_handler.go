@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollaboratorHandler struct {
+	BaseHandler
+	collaboratorService services.CollaboratorServiceInterface
+}
+
+type AddCollaboratorRequest struct {
+	Email string                     `json:"email" binding:"required,email"`
+	Role  documentapp.PermissionRole `json:"role" binding:"required"`
+}
+
+type UpdateCollaboratorRequest struct {
+	Role documentapp.PermissionRole `json:"role" binding:"required"`
+}
+
+func NewCollaboratorHandler(collaboratorService services.CollaboratorServiceInterface, baseHandler BaseHandler) *CollaboratorHandler {
+	return &CollaboratorHandler{
+		BaseHandler:         baseHandler,
+		collaboratorService: collaboratorService,
+	}
+}
+
+func (h *CollaboratorHandler) ListCollaborators(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	collaborators, err := h.collaboratorService.ListCollaborators(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleCollaboratorError(c, err)
+		return
+	}
+
+	h.Success(c, collaborators, "")
+}
+
+func (h *CollaboratorHandler) AddCollaborator(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req AddCollaboratorRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	collaborator, err := h.collaboratorService.AddCollaborator(c.Request.Context(), userID, c.Param("id"), req.Email, req.Role)
+
+	if err != nil {
+		h.handleCollaboratorError(c, err)
+		return
+	}
+
+	h.Created(c, collaborator, "Document shared")
+}
+
+func (h *CollaboratorHandler) UpdateCollaborator(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req UpdateCollaboratorRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	collaborator, err := h.collaboratorService.UpdateCollaborator(c.Request.Context(), userID, c.Param("id"), c.Param("email"), req.Role)
+
+	if err != nil {
+		h.handleCollaboratorError(c, err)
+		return
+	}
+
+	h.Success(c, collaborator, "Collaborator updated")
+}
+
+func (h *CollaboratorHandler) RemoveCollaborator(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.collaboratorService.RemoveCollaborator(c.Request.Context(), userID, c.Param("id"), c.Param("email")); err != nil {
+		h.handleCollaboratorError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Collaborator removed")
+}
+
+func (h *CollaboratorHandler) AcceptInvitation(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req AcceptInvitationRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	document, err := h.collaboratorService.AcceptInvitation(c.Request.Context(), userID, req.Token)
+
+	if err != nil {
+		h.handleCollaboratorError(c, err)
+		return
+	}
+
+	h.Success(c, document, "Invitation accepted")
+}
+
+func (h *CollaboratorHandler) handleCollaboratorError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid document ID")
+	case services.ErrInvalidPermissionRole, services.ErrCollaboratorExists, services.ErrCannotShareWithOwner, services.ErrInvitationExpired:
+		h.BadRequest(c, err.Error())
+	case services.ErrDocumentNotManageable, services.ErrInvitationEmailMismatch:
+		h.Forbidden(c, err.Error())
+	case services.ErrDocumentNotFound:
+		h.NotFound(c, "Document not found")
+	case services.ErrCollaboratorNotFound:
+		h.NotFound(c, "Collaborator not found")
+	case services.ErrInvitationNotFound:
+		h.NotFound(c, "Invitation not found")
+	default:
+		log.WithError(err).Error("Collaborator request failed")
+		h.InternalError(c, "Collaborator request failed")
+	}
+}
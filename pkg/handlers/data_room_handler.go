@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"fmt"
+	"share-docs/pkg/app/domain/roomapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DataRoomHandler manages data rooms for their owners.
+type DataRoomHandler struct {
+	BaseHandler
+	roomService services.DataRoomServiceInterface
+}
+
+func NewDataRoomHandler(roomService services.DataRoomServiceInterface, baseHandler BaseHandler) *DataRoomHandler {
+	return &DataRoomHandler{
+		BaseHandler: baseHandler,
+		roomService: roomService,
+	}
+}
+
+func (h *DataRoomHandler) CreateDataRoom(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.CreateDataRoom
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	room, err := h.roomService.CreateDataRoom(c.Request.Context(), userID, req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Created(c, room, "Data room created")
+}
+
+// ListDataRooms lists the rooms the caller manages, or those of
+// ?organization_id=.
+func (h *DataRoomHandler) ListDataRooms(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	orgID := optionalUUID(c.Query("organization_id"))
+	if c.Query("organization_id") != "" && orgID == nil {
+		h.BadRequest(c, "Invalid organization_id")
+		return
+	}
+
+	rooms, err := h.roomService.ListDataRooms(c.Request.Context(), userID, orgID)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, rooms, "")
+}
+
+func (h *DataRoomHandler) GetDataRoom(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	room, err := h.roomService.GetDataRoom(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, room, "")
+}
+
+func (h *DataRoomHandler) UpdateDataRoom(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.UpdateDataRoom
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if !req.HasAtLeastOneField() {
+		h.BadRequest(c, "no fields to update")
+		return
+	}
+
+	room, err := h.roomService.UpdateDataRoom(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, room, "Data room updated")
+}
+
+func (h *DataRoomHandler) DeleteDataRoom(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.roomService.DeleteDataRoom(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Data room deleted")
+}
+
+func (h *DataRoomHandler) RotateLink(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	room, err := h.roomService.RotateLink(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, room, "Data room link rotated")
+}
+
+func (h *DataRoomHandler) ListItems(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	items, err := h.roomService.ListItems(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, items, "")
+}
+
+func (h *DataRoomHandler) AddItem(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.AddItem
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	item, err := h.roomService.AddItem(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Created(c, item, "Item added")
+}
+
+func (h *DataRoomHandler) RemoveItem(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.roomService.RemoveItem(c.Request.Context(), userID, c.Param("id"), c.Param("itemId")); err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Item removed")
+}
+
+func (h *DataRoomHandler) ListGroups(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	groups, err := h.roomService.ListGroups(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, groups, "")
+}
+
+func (h *DataRoomHandler) CreateGroup(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.GroupRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	group, err := h.roomService.CreateGroup(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Created(c, group, "Group created")
+}
+
+func (h *DataRoomHandler) UpdateGroup(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.GroupRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	group, err := h.roomService.UpdateGroup(c.Request.Context(), userID, c.Param("id"), c.Param("groupId"), req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, group, "Group updated")
+}
+
+func (h *DataRoomHandler) DeleteGroup(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.roomService.DeleteGroup(c.Request.Context(), userID, c.Param("id"), c.Param("groupId")); err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Group deleted")
+}
+
+func (h *DataRoomHandler) ListViewers(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	viewers, err := h.roomService.ListViewers(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, viewers, "")
+}
+
+func (h *DataRoomHandler) AddViewers(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.AddViewers
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	viewers, err := h.roomService.AddViewers(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Created(c, viewers, "Viewers added")
+}
+
+func (h *DataRoomHandler) UpdateViewer(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req roomapp.UpdateViewer
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	viewer, err := h.roomService.UpdateViewer(c.Request.Context(), userID, c.Param("id"), c.Param("viewerId"), req)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, viewer, "Viewer updated")
+}
+
+func (h *DataRoomHandler) RemoveViewer(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.roomService.RemoveViewer(c.Request.Context(), userID, c.Param("id"), c.Param("viewerId")); err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Viewer removed")
+}
+
+func (h *DataRoomHandler) ListActivity(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	events, total, err := h.roomService.ListActivity(c.Request.Context(), userID, c.Param("id"), page, limit)
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, events, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *DataRoomHandler) RoomStats(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	stats, err := h.roomService.RoomStats(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleDataRoomError(c, err)
+		return
+	}
+
+	h.Success(c, stats, "")
+}
+
+func (h *DataRoomHandler) handleDataRoomError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrInvalidDataRoomItem, services.ErrDataRoomItemExists:
+		h.BadRequest(c, err.Error())
+	case services.ErrDataRoomNotFound:
+		h.NotFound(c, "Data room not found")
+	case services.ErrDataRoomItemNotFound:
+		h.NotFound(c, "Item not found")
+	case services.ErrDataRoomGroupNotFound:
+		h.NotFound(c, "Group not found")
+	case services.ErrDataRoomViewerNotFound:
+		h.NotFound(c, "Viewer not found")
+	case services.ErrOrganizationNotFound:
+		h.NotFound(c, "Organization not found")
+	case services.ErrFolderNotFound:
+		h.NotFound(c, "Folder not found")
+	case services.ErrDocumentNotFound:
+		h.NotFound(c, "Document not found")
+	default:
+		log.WithError(err).Error("Data room request failed")
+		h.InternalError(c, "Data room request failed")
+	}
+}
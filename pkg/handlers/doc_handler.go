@@ -7,6 +7,7 @@ import (
 	"share-docs/pkg/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type DocHandler struct {
@@ -26,6 +27,8 @@ func NewDocHandler(ds services.DocumentService, ss services.StorageService, bs B
 type DocHandlerInterface interface {
 	CreateDocument(c *gin.Context)
 	GetDocument(c *gin.Context)
+	ListDocuments(c *gin.Context)
+	ListSharedDocuments(c *gin.Context)
 	GetFile(c *gin.Context)
 	UpdateDocument(c *gin.Context)
 }
@@ -39,8 +42,17 @@ type DocHandlerInterface interface {
 // 4. return document reference
 
 type CreateDocumentRequest struct {
-	File     *multipart.FileHeader `form:"file" binding:"required"`
-	IsPublic bool                  `form:"is_public"`
+	File           *multipart.FileHeader `form:"file" binding:"required"`
+	IsPublic       bool                  `form:"is_public"`
+	OrganizationID string                `form:"organization_id" binding:"omitempty,uuid"`
+	FolderID       string                `form:"folder_id" binding:"omitempty,uuid"`
+}
+
+func (r CreateDocumentRequest) Placement() documentapp.Placement {
+	return documentapp.Placement{
+		OrganizationID: optionalUUID(r.OrganizationID),
+		FolderID:       optionalUUID(r.FolderID),
+	}
 }
 
 func (h *DocHandler) CreateDocument(c *gin.Context) {
@@ -73,9 +85,14 @@ func (h *DocHandler) CreateDocument(c *gin.Context) {
 		return
 	}
 
+	placement := req.Placement()
+
 	filepath := fmt.Sprintf("%s/", userID)
+	if placement.OrganizationID != nil {
+		filepath = fmt.Sprintf("orgs/%s/", placement.OrganizationID)
+	}
 
-	so, err := h.storageService.UploadDocument(f, filepath, req.File.Filename)
+	so, err := h.storageService.UploadDocument(c.Request.Context(), f, filepath, req.File.Filename)
 
 	if err != nil {
 		log.WithError(err).Error("Failed uploading document")
@@ -86,9 +103,14 @@ func (h *DocHandler) CreateDocument(c *gin.Context) {
 	(*so).IsPublic = req.IsPublic
 
 	log.WithField("storage_object", so).Info("Storage object debug")
-	doc, err := h.documentService.CreateDocument(userID, *so)
+	doc, err := h.documentService.CreateDocument(c.Request.Context(), userID, *so, placement)
 
 	if err != nil {
+		if err == services.ErrOrganizationNotFound || err == services.ErrFolderNotFound {
+			h.handlerRetrieveDocumentError(c, err)
+			return
+		}
+
 		log.WithError(err).Error("Failed creating document reference")
 		h.InternalError(c, fmt.Sprintf("Failed creating document reference"))
 		return
@@ -98,9 +120,14 @@ func (h *DocHandler) CreateDocument(c *gin.Context) {
 }
 
 func (h *DocHandler) GetDocument(c *gin.Context) {
-	documentId := c.Param("id")
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
 
-	document, err := h.documentService.GetDocument(documentId)
+	document, err := h.documentService.GetDocument(c.Request.Context(), userID, c.Param("id"))
 
 	if err != nil {
 		h.handlerRetrieveDocumentError(c, err)
@@ -110,18 +137,87 @@ func (h *DocHandler) GetDocument(c *gin.Context) {
 	h.Success(c, document, "document found")
 }
 
-func (h *DocHandler) GetFile(c *gin.Context) {
-	documentId := c.Param("id")
+// ListDocuments lists the caller's personal documents, or an organisation's
+// documents with ?organization_id=. Both can be narrowed with ?folder_id=.
+func (h *DocHandler) ListDocuments(c *gin.Context) {
+	log := h.GetLogger(c)
 
-	document, err := h.documentService.GetDocument(documentId)
+	userID, err := h.GetUserIDFromContext(c)
 
 	if err != nil {
-		h.handlerRetrieveDocumentError(c, err)
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	filter := documentapp.ListFilter{
+		OrganizationID: optionalUUID(c.Query("organization_id")),
+		FolderID:       optionalUUID(c.Query("folder_id")),
+	}
+
+	if (c.Query("organization_id") != "" && filter.OrganizationID == nil) || (c.Query("folder_id") != "" && filter.FolderID == nil) {
+		h.BadRequest(c, "Invalid organization_id or folder_id")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	documents, total, err := h.documentService.ListDocuments(c.Request.Context(), userID, filter, page, limit)
+
+	if err != nil {
+		log.WithError(err).Error("Failed listing documents")
+		h.InternalError(c, "Failed to list documents")
+		return
+	}
+
+	h.SuccessWithMeta(c, documents, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// ListSharedDocuments lists the documents other people shared with the caller.
+func (h *DocHandler) ListSharedDocuments(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	documents, total, err := h.documentService.ListSharedDocuments(c.Request.Context(), userID, page, limit)
+
+	if err != nil {
+		log.WithError(err).Error("Failed listing shared documents")
+		h.InternalError(c, "Failed to list shared documents")
+		return
+	}
+
+	h.SuccessWithMeta(c, documents, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *DocHandler) GetFile(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
 		return
 	}
 
-	if !document.IsPublic {
-		h.Unauthorized(c, "document is not public")
+	document, err := h.documentService.GetDocument(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handlerRetrieveDocumentError(c, err)
 		return
 	}
 
@@ -139,6 +235,15 @@ func (h *DocHandler) handlerRetrieveDocumentError(c *gin.Context, err error) {
 	case services.ErrInvalidId:
 		h.BadRequest(c, "Invalid document ID")
 		return
+	case services.ErrOrganizationNotFound:
+		h.NotFound(c, "Organization not found")
+		return
+	case services.ErrFolderNotFound:
+		h.NotFound(c, "Folder not found")
+		return
+	case services.ErrDocumentForbidden:
+		h.Forbidden(c, err.Error())
+		return
 	default:
 		h.InternalError(c, "Internal server error")
 		return
@@ -148,10 +253,10 @@ func (h *DocHandler) handlerRetrieveDocumentError(c *gin.Context, err error) {
 func (h *DocHandler) UpdateDocument(c *gin.Context) {
 	log := h.GetLogger(c)
 
-	_, err := h.documentService.GetDocument(c.Param("id"))
+	userID, err := h.GetUserIDFromContext(c)
 
 	if err != nil {
-		h.handlerRetrieveDocumentError(c, err)
+		h.Unauthorized(c, "Failed getting UserID!")
 		return
 	}
 
@@ -166,7 +271,7 @@ func (h *DocHandler) UpdateDocument(c *gin.Context) {
 		return
 	}
 
-	doc, err := h.documentService.UpdateDocument(c.Param("id"), ud)
+	doc, err := h.documentService.UpdateDocument(c.Request.Context(), userID, c.Param("id"), ud)
 
 	if err != nil {
 		h.handlerRetrieveDocumentError(c, err)
@@ -177,3 +282,36 @@ func (h *DocHandler) UpdateDocument(c *gin.Context) {
 
 	h.Success(c, doc, "updated")
 }
+
+// DeleteDocument deletes a personal document of the caller's, or one of an
+// organisation they administer.
+func (h *DocHandler) DeleteDocument(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.documentService.DeleteDocument(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handlerRetrieveDocumentError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Document deleted")
+}
+
+// optionalUUID parses an optional UUID form or query value, returning nil when
+// it is empty or malformed.
+func optionalUUID(value string) *uuid.UUID {
+	if value == "" {
+		return nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil
+	}
+
+	return &id
+}
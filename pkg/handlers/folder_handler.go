@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FolderHandler struct {
+	BaseHandler
+	folderService services.FolderServiceInterface
+}
+
+type CreateFolderRequest struct {
+	Name           string `json:"name" binding:"required,max=255"`
+	ParentID       string `json:"parent_id" binding:"omitempty,uuid"`
+	OrganizationID string `json:"organization_id" binding:"omitempty,uuid"`
+}
+
+func NewFolderHandler(folderService services.FolderServiceInterface, baseHandler BaseHandler) *FolderHandler {
+	return &FolderHandler{
+		BaseHandler:   baseHandler,
+		folderService: folderService,
+	}
+}
+
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req CreateFolderRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(c.Request.Context(), userID, req.Name, optionalUUID(req.ParentID), optionalUUID(req.OrganizationID))
+
+	if err != nil {
+		h.handleFolderError(c, err)
+		return
+	}
+
+	h.Created(c, folder, "Folder created")
+}
+
+// ListFolders lists top-level folders, or the children of ?parent_id=, in the
+// caller's personal space or in ?organization_id=.
+func (h *FolderHandler) ListFolders(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	orgID := optionalUUID(c.Query("organization_id"))
+	parentID := optionalUUID(c.Query("parent_id"))
+
+	if (c.Query("organization_id") != "" && orgID == nil) || (c.Query("parent_id") != "" && parentID == nil) {
+		h.BadRequest(c, "Invalid organization_id or parent_id")
+		return
+	}
+
+	folders, err := h.folderService.ListFolders(c.Request.Context(), userID, orgID, parentID)
+
+	if err != nil {
+		h.handleFolderError(c, err)
+		return
+	}
+
+	h.Success(c, folders, "")
+}
+
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.folderService.DeleteFolder(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleFolderError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Folder deleted")
+}
+
+func (h *FolderHandler) handleFolderError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid folder ID")
+	case services.ErrFolderNotFound:
+		h.NotFound(c, "Folder not found")
+	case services.ErrOrganizationNotFound:
+		h.NotFound(c, "Organization not found")
+	default:
+		log.WithError(err).Error("Folder request failed")
+		h.InternalError(c, "Folder request failed")
+	}
+}
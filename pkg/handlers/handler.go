@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"share-docs/pkg/app/domain/sessionapp"
 	"share-docs/pkg/logger"
 	"share-docs/pkg/util"
 	"strconv"
@@ -23,6 +24,7 @@ type BaseHandlerInterface interface {
 	InternalError(c *gin.Context, message string)
 	ValidationError(c *gin.Context, errors map[string]string)
 	GetUserIDFromContext(c *gin.Context) (uuid.UUID, error)
+	GetClientInfo(c *gin.Context) sessionapp.ClientInfo
 	GetUUIDParam(c *gin.Context, param string) (uuid.UUID, error)
 	GetPaginationParams(c *gin.Context) (page, limit int)
 	BindAndValidate(c *gin.Context, obj interface{}) error
@@ -171,6 +173,25 @@ func (h *BaseHandler) GetUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
 	return id, nil
 }
 
+// GetClientInfo returns the client IP and user agent captured by the logging
+// middleware, falling back to the raw request when it did not run.
+func (h *BaseHandler) GetClientInfo(c *gin.Context) sessionapp.ClientInfo {
+	ip := c.GetString("client_ip")
+	if ip == "" {
+		ip = c.ClientIP()
+	}
+
+	userAgent := c.GetString("user_agent")
+	if userAgent == "" {
+		userAgent = c.Request.UserAgent()
+	}
+
+	return sessionapp.ClientInfo{
+		IPAddress: ip,
+		UserAgent: userAgent,
+	}
+}
+
 func (h *BaseHandler) GetUUIDParam(c *gin.Context, param string) (uuid.UUID, error) {
 	paramStr := c.Param(param)
 	if paramStr == "" {
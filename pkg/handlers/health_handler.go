@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"share-docs/pkg/app/domain/healthapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler answers the orchestrator's liveness and readiness probes.
+// They reply with plain JSON rather than the standard envelope; only the
+// status code matters to the prober.
+type HealthHandler struct {
+	BaseHandler
+	healthService services.HealthServiceInterface
+}
+
+func NewHealthHandler(healthService services.HealthServiceInterface, baseHandler BaseHandler) *HealthHandler {
+	return &HealthHandler{
+		BaseHandler:   baseHandler,
+		healthService: healthService,
+	}
+}
+
+// Liveness reports that the process is up and serving HTTP. It checks no
+// dependencies, so an outage elsewhere doesn't get the instance restarted.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": healthapp.StatusUp})
+}
+
+// Readiness checks the database, storage and migrations, answering 503 when
+// any of them is down so traffic is routed elsewhere.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthService.Readiness(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status != healthapp.StatusUp {
+		status = http.StatusServiceUnavailable
+		h.logger.WithField("components", report.Components).Warn("Readiness check failed")
+	}
+
+	c.JSON(status, report)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"share-docs/pkg/app/domain/jobapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler shows administrators the background job queue.
+type JobHandler struct {
+	BaseHandler
+	jobService services.JobServiceInterface
+}
+
+func NewJobHandler(jobService services.JobServiceInterface, baseHandler BaseHandler) *JobHandler {
+	return &JobHandler{
+		BaseHandler: baseHandler,
+		jobService:  jobService,
+	}
+}
+
+// ListJobs lists jobs, newest first, narrowed by ?queue=, ?kind= and ?status=.
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	filter := jobapp.ListFilter{
+		Queue:  c.Query("queue"),
+		Kind:   c.Query("kind"),
+		Status: c.Query("status"),
+	}
+
+	switch filter.Status {
+	case "", models.JobQueued, models.JobRunning, models.JobSucceeded, models.JobDead, models.JobCancelled:
+	default:
+		h.BadRequest(c, "status must be queued, running, succeeded, dead or cancelled")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	jobs, total, err := h.jobService.ListJobs(c.Request.Context(), filter, page, limit)
+
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, jobs, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *JobHandler) QueueStats(c *gin.Context) {
+	stats, err := h.jobService.QueueStats(c.Request.Context())
+
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	h.Success(c, stats, "")
+}
+
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.jobService.GetJob(c.Request.Context(), c.Param("id"))
+
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	h.Success(c, job, "")
+}
+
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	job, err := h.jobService.RetryJob(c.Request.Context(), c.Param("id"))
+
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	h.Success(c, job, "Job queued")
+}
+
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	job, err := h.jobService.CancelJob(c.Request.Context(), c.Param("id"))
+
+	if err != nil {
+		h.handleJobError(c, err)
+		return
+	}
+
+	h.Success(c, job, "Job cancelled")
+}
+
+func (h *JobHandler) handleJobError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrJobNotFound:
+		h.NotFound(c, "Job not found")
+	case services.ErrJobNotRetried, services.ErrJobNotQueued:
+		h.failedRequest(c, err.Error(), http.StatusConflict)
+	default:
+		log.WithError(err).Error("Job request failed")
+		h.InternalError(c, "Job request failed")
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LinkHandler struct {
+	BaseHandler
+	linkService      services.ShareLinkServiceInterface
+	analyticsService services.LinkAnalyticsServiceInterface
+}
+
+func NewLinkHandler(linkService services.ShareLinkServiceInterface, analyticsService services.LinkAnalyticsServiceInterface, baseHandler BaseHandler) *LinkHandler {
+	return &LinkHandler{
+		BaseHandler:      baseHandler,
+		linkService:      linkService,
+		analyticsService: analyticsService,
+	}
+}
+
+func (h *LinkHandler) CreateLink(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req linkapp.CreateShareLink
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	link, err := h.linkService.CreateLink(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.Created(c, link, "Share link created")
+}
+
+func (h *LinkHandler) ListLinks(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	links, err := h.linkService.ListLinks(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.Success(c, links, "")
+}
+
+func (h *LinkHandler) UpdateLink(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req linkapp.UpdateShareLink
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if !req.HasAtLeastOneField() {
+		h.BadRequest(c, "no fields to update")
+		return
+	}
+
+	link, err := h.linkService.UpdateLink(c.Request.Context(), userID, c.Param("linkId"), req)
+
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.Success(c, link, "Share link updated")
+}
+
+func (h *LinkHandler) DeleteLink(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.linkService.DeleteLink(c.Request.Context(), userID, c.Param("linkId")); err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Share link deleted")
+}
+
+func (h *LinkHandler) LinkStats(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	stats, err := h.analyticsService.LinkStats(c.Request.Context(), userID, c.Param("linkId"))
+
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.Success(c, stats, "")
+}
+
+func (h *LinkHandler) ListViews(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	views, total, err := h.analyticsService.ListViews(c.Request.Context(), userID, c.Param("linkId"), page, limit)
+
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, views, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *LinkHandler) DocumentStats(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	stats, err := h.analyticsService.DocumentStats(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleLinkError(c, err)
+		return
+	}
+
+	h.Success(c, stats, "")
+}
+
+func (h *LinkHandler) handleLinkError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	if errors.Is(err, services.ErrInvalidAllowlist) {
+		h.BadRequest(c, err.Error())
+		return
+	}
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrDocumentNotFound:
+		h.NotFound(c, "Document not found")
+	case services.ErrShareLinkNotFound:
+		h.NotFound(c, "Share link not found")
+	case services.ErrAgreementNotFound:
+		h.NotFound(c, "Agreement not found")
+	default:
+		log.WithError(err).Error("Share link request failed")
+		h.InternalError(c, "Share link request failed")
+	}
+}
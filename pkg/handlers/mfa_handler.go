@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	BaseHandler
+	mfaService services.MFAServiceInterface
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+type DisableMFARequest struct {
+	// Left out by accounts that only sign in through SSO
+	Password string `json:"password" binding:"max=128"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func NewMFAHandler(mfaService services.MFAServiceInterface, baseHandler BaseHandler) *MFAHandler {
+	return &MFAHandler{
+		BaseHandler: baseHandler,
+		mfaService:  mfaService,
+	}
+}
+
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(c.Request.Context(), userID)
+
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	h.Success(c, enrollment, "Scan the URI with an authenticator app and confirm with a code")
+}
+
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(c.Request.Context(), userID, req.Code)
+
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	h.Success(c, RecoveryCodesResponse{RecoveryCodes: codes}, "Two-factor authentication enabled")
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req DisableMFARequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if err := h.mfaService.Disable(c.Request.Context(), userID, req.Password, req.Code); err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Two-factor authentication disabled")
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+
+	if err != nil {
+		h.handleMFAError(c, err)
+		return
+	}
+
+	h.Success(c, RecoveryCodesResponse{RecoveryCodes: codes}, "Recovery codes regenerated")
+}
+
+func (h *MFAHandler) handleMFAError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrMFAAlreadyEnabled, services.ErrMFANotEnabled, services.ErrMFANotEnrolled:
+		h.BadRequest(c, err.Error())
+	case services.ErrInvalidMFACode, services.ErrInvalidMFAPassword:
+		h.Unauthorized(c, err.Error())
+	case services.ErrMFALocked:
+		h.failedRequest(c, err.Error(), http.StatusTooManyRequests)
+	case services.ErrUserNotFound:
+		h.NotFound(c, "User not found")
+	default:
+		log.WithError(err).Error("Two-factor request failed")
+		h.InternalError(c, "Two-factor request failed")
+	}
+}
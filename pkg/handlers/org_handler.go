@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"fmt"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrgHandler struct {
+	BaseHandler
+	orgService services.OrganizationServiceInterface
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type MemberRoleRequest struct {
+	Role orgapp.Role `json:"role" binding:"required"`
+}
+
+type InviteMemberRequest struct {
+	Email string      `json:"email" binding:"required,email"`
+	Role  orgapp.Role `json:"role" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func NewOrgHandler(orgService services.OrganizationServiceInterface, baseHandler BaseHandler) *OrgHandler {
+	return &OrgHandler{
+		BaseHandler: baseHandler,
+		orgService:  orgService,
+	}
+}
+
+func (h *OrgHandler) CreateOrganization(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req OrganizationRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(c.Request.Context(), userID, req.Name)
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Created(c, org, "Organization created")
+}
+
+func (h *OrgHandler) ListOrganizations(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	orgs, err := h.orgService.ListOrganizations(c.Request.Context(), userID)
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, orgs, "")
+}
+
+func (h *OrgHandler) GetOrganization(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	org, err := h.orgService.GetOrganization(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, org, "")
+}
+
+func (h *OrgHandler) UpdateOrganization(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req OrganizationRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	org, err := h.orgService.UpdateOrganization(c.Request.Context(), userID, c.Param("id"), req.Name)
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, org, "Organization updated")
+}
+
+func (h *OrgHandler) DeleteOrganization(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.orgService.DeleteOrganization(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Organization deleted")
+}
+
+func (h *OrgHandler) ListMembers(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	members, err := h.orgService.ListMembers(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, members, "")
+}
+
+func (h *OrgHandler) UpdateMemberRole(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req MemberRoleRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if err := h.orgService.UpdateMemberRole(c.Request.Context(), userID, c.Param("id"), c.Param("userId"), req.Role); err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Member role updated")
+}
+
+func (h *OrgHandler) RemoveMember(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.orgService.RemoveMember(c.Request.Context(), userID, c.Param("id"), c.Param("userId")); err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Member removed")
+}
+
+func (h *OrgHandler) InviteMember(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req InviteMemberRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	invitation, err := h.orgService.InviteMember(c.Request.Context(), userID, c.Param("id"), req.Email, req.Role)
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Created(c, invitation, "Invitation sent")
+}
+
+func (h *OrgHandler) ListInvitations(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	invitations, err := h.orgService.ListInvitations(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, invitations, "")
+}
+
+func (h *OrgHandler) RevokeInvitation(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.orgService.RevokeInvitation(c.Request.Context(), userID, c.Param("id"), c.Param("invitationId")); err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Invitation revoked")
+}
+
+func (h *OrgHandler) AcceptInvitation(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req AcceptInvitationRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	org, err := h.orgService.AcceptInvitation(c.Request.Context(), userID, req.Token)
+
+	if err != nil {
+		h.handleOrgError(c, err)
+		return
+	}
+
+	h.Success(c, org, "Invitation accepted")
+}
+
+func (h *OrgHandler) handleOrgError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrInvalidRole, services.ErrLastOwner, services.ErrAlreadyMember, services.ErrInvitationExpired:
+		h.BadRequest(c, err.Error())
+	case services.ErrInsufficientRole, services.ErrInvitationEmailMismatch:
+		h.Forbidden(c, err.Error())
+	case services.ErrOrganizationNotFound:
+		h.NotFound(c, "Organization not found")
+	case services.ErrMemberNotFound:
+		h.NotFound(c, "Member not found")
+	case services.ErrInvitationNotFound:
+		h.NotFound(c, "Invitation not found")
+	default:
+		log.WithError(err).Error("Organization request failed")
+		h.InternalError(c, "Organization request failed")
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/app/domain/roomapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoomHandler serves data rooms to their viewers.
+type RoomHandler struct {
+	BaseHandler
+	roomService services.DataRoomServiceInterface
+}
+
+func NewRoomHandler(roomService services.DataRoomServiceInterface, baseHandler BaseHandler) *RoomHandler {
+	return &RoomHandler{
+		BaseHandler: baseHandler,
+		roomService: roomService,
+	}
+}
+
+// GetRoom returns the room's branding for the sign-in page.
+func (h *RoomHandler) GetRoom(c *gin.Context) {
+	branding, err := h.roomService.RoomBranding(c.Request.Context(), c.Param("token"))
+
+	if err != nil {
+		h.handleRoomError(c, err)
+		return
+	}
+
+	h.Success(c, branding, "")
+}
+
+// RequestCode emails a one-time code to a viewer on the room's list.
+func (h *RoomHandler) RequestCode(c *gin.Context) {
+	var req roomapp.CodeRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if err := h.roomService.RequestRoomCode(c.Request.Context(), c.Param("token"), req.Email); err != nil {
+		h.handleRoomError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Verification code sent")
+}
+
+// Verify signs a viewer in with their code.
+func (h *RoomHandler) Verify(c *gin.Context) {
+	var req roomapp.VerifyRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	client := h.GetClientInfo(c)
+
+	access, err := h.roomService.VerifyRoomViewer(c.Request.Context(), c.Param("token"), req, linkapp.Visitor{
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Referrer:  c.Request.Referer(),
+	})
+
+	if err != nil {
+		h.handleRoomError(c, err)
+		return
+	}
+
+	h.Success(c, access, "")
+}
+
+// GetContents lists what the viewer may see (?view_token=).
+func (h *RoomHandler) GetContents(c *gin.Context) {
+	contents, err := h.roomService.RoomContents(c.Request.Context(), c.Param("token"), c.Query("view_token"))
+
+	if err != nil {
+		h.handleRoomError(c, err)
+		return
+	}
+
+	h.Success(c, contents, "")
+}
+
+// GetFile serves a document of the room inline.
+func (h *RoomHandler) GetFile(c *gin.Context) {
+	h.serveFile(c, false)
+}
+
+// Download serves a document of the room as an attachment, unless the room
+// is view-only.
+func (h *RoomHandler) Download(c *gin.Context) {
+	h.serveFile(c, true)
+}
+
+func (h *RoomHandler) serveFile(c *gin.Context, download bool) {
+	client := h.GetClientInfo(c)
+
+	file, err := h.roomService.OpenRoomDocument(c.Request.Context(), c.Param("token"), c.Query("view_token"), c.Param("documentId"), linkapp.Visitor{
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Referrer:  c.Request.Referer(),
+	}, download)
+
+	if err != nil {
+		h.handleRoomError(c, err)
+		return
+	}
+
+	disposition := "inline"
+	if download {
+		disposition = "attachment"
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filepath.Base(file.Path)}))
+	c.Header("Cache-Control", "no-store")
+
+	if file.Watermark == "" {
+		c.File(file.Path)
+		return
+	}
+
+	h.serveWatermarked(c, file.Path, file.MimeType, file.Watermark)
+}
+
+func (h *RoomHandler) handleRoomError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrDataRoomNotFound, services.ErrDataRoomClosed:
+		h.NotFound(c, "Data room not found or closed")
+	case services.ErrDocumentNotFound:
+		h.NotFound(c, "Document not found")
+	case services.ErrLinkEmailCodeInvalid, services.ErrInvalidViewToken:
+		h.Unauthorized(c, err.Error())
+	case services.ErrEmailNotAllowed, services.ErrDownloadNotAllowed, services.ErrPreviewNotAvailable:
+		h.Forbidden(c, err.Error())
+	default:
+		log.WithError(err).Error("Data room request failed")
+		h.InternalError(c, "Data room request failed")
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	BaseHandler
+	tokenService services.TokenServiceInterface
+}
+
+func NewSessionHandler(tokenService services.TokenServiceInterface, baseHandler BaseHandler) *SessionHandler {
+	return &SessionHandler{
+		BaseHandler:  baseHandler,
+		tokenService: tokenService,
+	}
+}
+
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	sessions, err := h.tokenService.ListSessions(c.Request.Context(), userID)
+
+	if err != nil {
+		log.WithError(err).Error("Failed listing sessions")
+		h.InternalError(c, "Failed to list sessions")
+		return
+	}
+
+	currentSessionID := c.GetString("SessionID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	h.Success(c, sessions, "")
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.tokenService.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		switch err {
+		case services.ErrInvalidId:
+			h.BadRequest(c, "Invalid session ID")
+		case services.ErrSessionNotFound:
+			h.NotFound(c, "Session not found")
+		default:
+			log.WithError(err).Error("Failed revoking session")
+			h.InternalError(c, "Failed to revoke session")
+		}
+		return
+	}
+
+	h.Success(c, nil, "Session revoked")
+}
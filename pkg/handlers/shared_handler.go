@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/services"
+	"share-docs/pkg/watermark"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SharedHandler serves share links to anonymous viewers.
+type SharedHandler struct {
+	BaseHandler
+	linkService      services.ShareLinkServiceInterface
+	analyticsService services.LinkAnalyticsServiceInterface
+}
+
+func NewSharedHandler(linkService services.ShareLinkServiceInterface, analyticsService services.LinkAnalyticsServiceInterface, baseHandler BaseHandler) *SharedHandler {
+	return &SharedHandler{
+		BaseHandler:      baseHandler,
+		linkService:      linkService,
+		analyticsService: analyticsService,
+	}
+}
+
+// Access opens a link that has no gates.
+func (h *SharedHandler) Access(c *gin.Context) {
+	h.access(c, linkapp.AccessRequest{})
+}
+
+// Verify opens a gated link with the credentials the viewer entered.
+func (h *SharedHandler) Verify(c *gin.Context) {
+	var req linkapp.AccessRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	h.access(c, req)
+}
+
+func (h *SharedHandler) access(c *gin.Context, req linkapp.AccessRequest) {
+	client := h.GetClientInfo(c)
+
+	access, err := h.linkService.Access(c.Request.Context(), c.Param("token"), req, linkapp.Visitor{
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Referrer:  c.Request.Referer(),
+	})
+
+	if err != nil {
+		h.handleSharedError(c, err)
+		return
+	}
+
+	h.Success(c, access, "")
+}
+
+// RequestCode emails a one-time code for links that verify the viewer's
+// email.
+func (h *SharedHandler) RequestCode(c *gin.Context) {
+	var req linkapp.EmailCodeRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if err := h.linkService.RequestEmailCode(c.Request.Context(), c.Param("token"), req.Email); err != nil {
+		h.handleSharedError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Verification code sent")
+}
+
+// GetAgreement returns the agreement the viewer has to accept before opening
+// the link.
+func (h *SharedHandler) GetAgreement(c *gin.Context) {
+	agreement, err := h.linkService.LinkAgreement(c.Request.Context(), c.Param("token"))
+
+	if err != nil {
+		h.handleSharedError(c, err)
+		return
+	}
+
+	h.Success(c, agreement, "")
+}
+
+// GetAgreementFile serves the document of the link's agreement inline.
+func (h *SharedHandler) GetAgreementFile(c *gin.Context) {
+	document, err := h.linkService.OpenAgreementFile(c.Request.Context(), c.Param("token"))
+
+	if err != nil {
+		h.handleSharedError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filepath.Base(document.OriginalFilename)}))
+	c.File(document.OriginalFilename)
+}
+
+// GetFile serves the document of a link inline, for the viewer's preview.
+func (h *SharedHandler) GetFile(c *gin.Context) {
+	h.serveFile(c, false)
+}
+
+// Download serves the document of a link as an attachment, unless the link
+// is view-only.
+func (h *SharedHandler) Download(c *gin.Context) {
+	h.serveFile(c, true)
+}
+
+func (h *SharedHandler) serveFile(c *gin.Context, download bool) {
+	client := h.GetClientInfo(c)
+
+	file, err := h.linkService.OpenFile(c.Request.Context(), c.Param("token"), c.Query("view_token"), linkapp.Visitor{
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Referrer:  c.Request.Referer(),
+	}, download)
+
+	if err != nil {
+		h.handleSharedError(c, err)
+		return
+	}
+
+	path := file.Document.OriginalFilename
+
+	disposition := "inline"
+	if download {
+		disposition = "attachment"
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filepath.Base(path)}))
+
+	if !file.AllowDownload || file.Watermark != "" {
+		c.Header("Cache-Control", "no-store")
+	}
+
+	if file.Watermark == "" || !watermark.Supported(file.Document.MimeType) {
+		c.File(path)
+		return
+	}
+
+	h.serveWatermarked(c, path, file.Document.MimeType, file.Watermark)
+}
+
+// serveWatermarked stamps text onto a PDF or image as it is served.
+// Watermarked copies are made per request and never stored.
+func (h *BaseHandler) serveWatermarked(c *gin.Context, path string, mimeType string, text string) {
+	log := h.GetLogger(c)
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.WithError(err).Error("Failed opening shared file")
+		h.InternalError(c, "Failed to open file")
+		return
+	}
+	defer f.Close()
+
+	var out bytes.Buffer
+	if err := watermark.Stamp(f, &out, mimeType, text); err != nil {
+		if errors.Is(err, watermark.ErrImageTooLarge) {
+			h.Forbidden(c, "Image is too large to preview")
+			return
+		}
+
+		log.WithError(err).Error("Failed watermarking shared file")
+		h.InternalError(c, "Failed to prepare file")
+		return
+	}
+
+	c.Data(http.StatusOK, mimeType, out.Bytes())
+}
+
+// Beacon records page dwell time reported by the document viewer.
+func (h *SharedHandler) Beacon(c *gin.Context) {
+	var beacon linkapp.PageBeacon
+	if err := h.BindAndValidate(c, &beacon); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if err := h.analyticsService.RecordPageView(c.Request.Context(), c.Param("token"), beacon); err != nil {
+		h.handleSharedError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "")
+}
+
+func (h *SharedHandler) handleSharedError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrShareLinkNotFound, services.ErrShareLinkExpired:
+		h.NotFound(c, "Share link not found or expired")
+	case services.ErrLinkPasswordRequired, services.ErrInvalidLinkPassword, services.ErrInvalidViewToken,
+		services.ErrLinkEmailRequired, services.ErrLinkEmailCodeInvalid, services.ErrAgreementNotAccepted:
+		h.Unauthorized(c, err.Error())
+	case services.ErrAgreementNotFound:
+		h.NotFound(c, "Agreement not found")
+	case services.ErrEmailNotAllowed, services.ErrDownloadNotAllowed, services.ErrPreviewNotAvailable:
+		h.Forbidden(c, err.Error())
+	case services.ErrShareLinkExhausted:
+		h.failedRequest(c, err.Error(), http.StatusGone)
+	default:
+		log.WithError(err).Error("Shared document request failed")
+		h.InternalError(c, "Shared document request failed")
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/uploadapp"
+	"share-docs/pkg/services"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadHandler receives files sent through upload requests by people
+// without an account.
+type UploadHandler struct {
+	BaseHandler
+	uploadService  services.UploadRequestServiceInterface
+	storageService services.StorageService
+}
+
+type UploadFileRequest struct {
+	File     *multipart.FileHeader `form:"file" binding:"required"`
+	Name     string                `form:"name" binding:"required,max=255"`
+	Email    string                `form:"email" binding:"required,email,max=255"`
+	Password string                `form:"password"`
+}
+
+// uploadFormOverhead leaves room for the other form fields and multipart
+// boundaries on top of the file size limit.
+const uploadFormOverhead = 1 << 20
+
+func NewUploadHandler(uploadService services.UploadRequestServiceInterface, storageService services.StorageService, baseHandler BaseHandler) *UploadHandler {
+	return &UploadHandler{
+		BaseHandler:    baseHandler,
+		uploadService:  uploadService,
+		storageService: storageService,
+	}
+}
+
+// GetUploadRequest describes the request to the person uploading.
+func (h *UploadHandler) GetUploadRequest(c *gin.Context) {
+	request, err := h.uploadService.PublicUploadRequest(c.Request.Context(), c.Param("token"))
+
+	if err != nil {
+		h.handleUploadError(c, err)
+		return
+	}
+
+	h.Success(c, request, "")
+}
+
+// Upload stores a file sent through an upload request as a document of the
+// request owner.
+func (h *UploadHandler) Upload(c *gin.Context) {
+	log := h.GetLogger(c)
+
+	request, err := h.uploadService.PublicUploadRequest(c.Request.Context(), c.Param("token"))
+
+	if err != nil {
+		h.handleUploadError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, request.MaxFileSize+uploadFormOverhead)
+
+	var req UploadFileRequest
+	if err := h.BindFormAndValidate(c, &req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.handleUploadError(c, services.ErrUploadTooLarge)
+			return
+		}
+
+		h.BadRequest(c, fmt.Sprintf("Invalid request! %s", err.Error()))
+		return
+	}
+
+	if req.File.Size <= 0 {
+		h.BadRequest(c, fmt.Sprintf("Empty file! Size: %d", req.File.Size))
+		return
+	}
+
+	f, err := req.File.Open()
+	if err != nil {
+		log.WithError(err).Error("Failed opening file")
+		h.BadRequest(c, "Failed opening file!")
+		return
+	}
+	defer f.Close()
+
+	// Type limits go by the content, not by what the client claims
+	mimeType, err := mimetype.DetectReader(f)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Failed reading file")
+		h.BadRequest(c, "Failed reading file!")
+		return
+	}
+
+	target, err := h.uploadService.AcceptUpload(c.Request.Context(), c.Param("token"), uploadapp.Upload{
+		Password: req.Password,
+		Filename: req.File.Filename,
+		Size:     req.File.Size,
+		MimeType: mimeType.String(),
+		Uploader: documentapp.Uploader{
+			Name:  req.Name,
+			Email: req.Email,
+		},
+	})
+
+	if err != nil {
+		h.handleUploadError(c, err)
+		return
+	}
+
+	so, err := h.storageService.UploadDocument(c.Request.Context(), f, target.Path, req.File.Filename)
+
+	if err != nil {
+		log.WithError(err).Error("Failed uploading document")
+		h.InternalError(c, "Failed uploading document!")
+		return
+	}
+
+	received, err := h.uploadService.CompleteUpload(c.Request.Context(), target, *so)
+
+	if err != nil {
+		h.handleUploadError(c, err)
+		return
+	}
+
+	h.Created(c, received, "File received")
+}
+
+func (h *UploadHandler) handleUploadError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrUploadRequestNotFound, services.ErrUploadRequestExpired,
+		services.ErrOrganizationNotFound, services.ErrFolderNotFound:
+		h.NotFound(c, "Upload request not found or expired")
+	case services.ErrUploadPasswordRequired, services.ErrInvalidUploadPassword:
+		h.Unauthorized(c, err.Error())
+	case services.ErrUploadTooLarge:
+		h.failedRequest(c, err.Error(), http.StatusRequestEntityTooLarge)
+	case services.ErrUploadTypeNotAllowed:
+		h.failedRequest(c, err.Error(), http.StatusUnsupportedMediaType)
+	default:
+		log.WithError(err).Error("Upload failed")
+		h.InternalError(c, "Upload failed")
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/uploadapp"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadRequestHandler manages upload requests for their owners.
+type UploadRequestHandler struct {
+	BaseHandler
+	uploadService services.UploadRequestServiceInterface
+}
+
+func NewUploadRequestHandler(uploadService services.UploadRequestServiceInterface, baseHandler BaseHandler) *UploadRequestHandler {
+	return &UploadRequestHandler{
+		BaseHandler:   baseHandler,
+		uploadService: uploadService,
+	}
+}
+
+func (h *UploadRequestHandler) CreateUploadRequest(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req uploadapp.CreateUploadRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	request, err := h.uploadService.CreateUploadRequest(c.Request.Context(), userID, req)
+
+	if err != nil {
+		h.handleUploadRequestError(c, err)
+		return
+	}
+
+	h.Created(c, request, "Upload request created")
+}
+
+func (h *UploadRequestHandler) ListUploadRequests(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	requests, err := h.uploadService.ListUploadRequests(c.Request.Context(), userID)
+
+	if err != nil {
+		h.handleUploadRequestError(c, err)
+		return
+	}
+
+	h.Success(c, requests, "")
+}
+
+func (h *UploadRequestHandler) GetUploadRequest(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	request, err := h.uploadService.GetUploadRequest(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleUploadRequestError(c, err)
+		return
+	}
+
+	h.Success(c, request, "")
+}
+
+func (h *UploadRequestHandler) UpdateUploadRequest(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req uploadapp.UpdateUploadRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if !req.HasAtLeastOneField() {
+		h.BadRequest(c, "no fields to update")
+		return
+	}
+
+	request, err := h.uploadService.UpdateUploadRequest(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleUploadRequestError(c, err)
+		return
+	}
+
+	h.Success(c, request, "Upload request updated")
+}
+
+func (h *UploadRequestHandler) DeleteUploadRequest(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.uploadService.DeleteUploadRequest(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleUploadRequestError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Upload request deleted")
+}
+
+func (h *UploadRequestHandler) ListReceivedDocuments(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	documents, total, err := h.uploadService.ListReceivedDocuments(c.Request.Context(), userID, c.Param("id"), page, limit)
+
+	if err != nil {
+		h.handleUploadRequestError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, documents, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *UploadRequestHandler) handleUploadRequestError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	if errors.Is(err, services.ErrInvalidUploadType) {
+		h.BadRequest(c, err.Error())
+		return
+	}
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrUploadRequestNotFound:
+		h.NotFound(c, "Upload request not found")
+	case services.ErrOrganizationNotFound:
+		h.NotFound(c, "Organization not found")
+	case services.ErrFolderNotFound:
+		h.NotFound(c, "Folder not found")
+	default:
+		log.WithError(err).Error("Upload request failed")
+		h.InternalError(c, "Upload request failed")
+	}
+}
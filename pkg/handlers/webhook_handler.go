@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"fmt"
+	"share-docs/pkg/app/domain/webhookapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	BaseHandler
+	webhookService services.WebhookServiceInterface
+}
+
+func NewWebhookHandler(webhookService services.WebhookServiceInterface, baseHandler BaseHandler) *WebhookHandler {
+	return &WebhookHandler{
+		BaseHandler:    baseHandler,
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req webhookapp.CreateEndpoint
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), userID, req)
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Created(c, webhook, "Webhook created")
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context(), userID)
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Success(c, webhooks, "")
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Success(c, webhook, "")
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	var req webhookapp.UpdateEndpoint
+	if err := h.BindAndValidate(c, &req); err != nil {
+		h.BadRequest(c, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if !req.HasAtLeastOneField() {
+		h.BadRequest(c, "no fields to update")
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), userID, c.Param("id"), req)
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Success(c, webhook, "Webhook updated")
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), userID, c.Param("id")); err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Success(c, nil, "Webhook deleted")
+}
+
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	webhook, err := h.webhookService.RotateSecret(c.Request.Context(), userID, c.Param("id"))
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Success(c, webhook, "Webhook secret rotated")
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		h.BadRequest(c, "status must be pending, succeeded or dead")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), userID, c.Param("id"), status, page, limit)
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, deliveries, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *WebhookHandler) ListDeadLetters(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	page, limit := h.GetPaginationParams(c)
+
+	deliveries, total, err := h.webhookService.ListDeadLetters(c.Request.Context(), userID, page, limit)
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.SuccessWithMeta(c, deliveries, "", &Meta{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, err := h.GetUserIDFromContext(c)
+
+	if err != nil {
+		h.Unauthorized(c, "Failed getting UserID!")
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), userID, c.Param("id"), c.Param("deliveryId"))
+
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	h.Success(c, delivery, "Delivery queued")
+}
+
+func (h *WebhookHandler) handleWebhookError(c *gin.Context, err error) {
+	log := h.GetLogger(c)
+
+	switch err {
+	case services.ErrInvalidId:
+		h.BadRequest(c, "Invalid ID")
+	case services.ErrInvalidWebhookURL:
+		h.BadRequest(c, err.Error())
+	case services.ErrWebhookNotFound:
+		h.NotFound(c, "Webhook not found")
+	case services.ErrWebhookDeliveryNotFound:
+		h.NotFound(c, "Delivery not found")
+	default:
+		log.WithError(err).Error("Webhook request failed")
+		h.InternalError(c, "Webhook request failed")
+	}
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultQueue       = "default"
+	DefaultMaxAttempts = 10
+)
+
+// Args are the typed arguments of a job. Kind names the handler that runs
+// it and must not depend on the receiver's value.
+type Args interface {
+	Kind() string
+}
+
+type Options struct {
+	Queue       string
+	RunAt       time.Time
+	MaxAttempts int
+	// UniqueKey drops the job when one with the same key was ever enqueued
+	UniqueKey string
+}
+
+// Enqueue adds a job. Pass the transaction of the change that calls for it,
+// and the job only exists if the change commits.
+func Enqueue(db *gorm.DB, args Args, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s job: %w", args.Kind(), err)
+	}
+
+	job := &models.Job{
+		Queue:       opts.Queue,
+		Kind:        args.Kind(),
+		Args:        string(data),
+		Status:      models.JobQueued,
+		RunAt:       opts.RunAt,
+		MaxAttempts: opts.MaxAttempts,
+	}
+
+	if job.Queue == "" {
+		job.Queue = DefaultQueue
+	}
+
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+	}
+
+	result := db.Session(&gorm.Session{NewDB: true}).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	if result.Error != nil {
+		return fmt.Errorf("failed to enqueue %s job: %w", job.Kind, result.Error)
+	}
+
+	return nil
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying; the job goes
+// straight to dead.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+type handlerFunc func(ctx context.Context, job *models.Job) error
+
+// Registry maps job kinds to their handlers.
+type Registry struct {
+	handlers map[string]handlerFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]handlerFunc)}
+}
+
+// Register adds the handler for the jobs of T's kind. Arguments that don't
+// decode into T fail the job permanently.
+func Register[T Args](r *Registry, handler func(ctx context.Context, job *models.Job, args T) error) {
+	var zero T
+
+	r.handlers[zero.Kind()] = func(ctx context.Context, job *models.Job) error {
+		var args T
+		if err := json.Unmarshal([]byte(job.Args), &args); err != nil {
+			return Permanent(fmt.Errorf("failed to decode arguments: %w", err))
+		}
+
+		return handler(ctx, job, args)
+	}
+}
+
+func (r *Registry) handler(kind string) (handlerFunc, bool) {
+	h, ok := r.handlers[kind]
+	return h, ok
+}
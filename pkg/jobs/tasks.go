@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/logger"
+	"share-docs/pkg/mail"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MailQueue keeps slow mail servers from holding up other jobs.
+const MailQueue = "mail"
+
+// SendEmailArgs sends an email outside the request. Enqueue it on MailQueue.
+type SendEmailArgs struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+}
+
+func (SendEmailArgs) Kind() string { return "email.send" }
+
+// EnqueueEmail queues a message on MailQueue. Pass the transaction of the
+// change the message announces, so that it is only sent if the change
+// commits.
+func EnqueueEmail(db *gorm.DB, msg mail.Message) error {
+	return Enqueue(db, SendEmailArgs{To: msg.To, Subject: msg.Subject, Body: msg.Body}, &Options{Queue: MailQueue})
+}
+
+// PurgeArgs removes bookkeeping rows that are done with: dispatched outbox
+// events, finished jobs and delivered webhooks older than OlderThanDays.
+type PurgeArgs struct {
+	OlderThanDays int `json:"older_than_days"`
+}
+
+func (PurgeArgs) Kind() string { return "maintenance.purge" }
+
+// NewStandardWorker is a worker with the application's job handlers and
+// schedules, for the API process and the worker command alike.
+func NewStandardWorker(db *gorm.DB, log *logger.Logger, mailer mail.Mailer, queues map[string]int) (*Worker, error) {
+	registry := NewRegistry()
+
+	Register(registry, func(ctx context.Context, job *models.Job, args SendEmailArgs) error {
+		return mailer.Send(ctx, mail.Message{To: args.To, Subject: args.Subject, Body: args.Body})
+	})
+
+	Register(registry, func(ctx context.Context, job *models.Job, args PurgeArgs) error {
+		return purge(db.WithContext(ctx), time.Now().AddDate(0, 0, -args.OlderThanDays))
+	})
+
+	worker := NewWorker(db, log, registry, queues)
+
+	if err := worker.Schedule("purge", "0 3 * * *", PurgeArgs{OlderThanDays: 30}, nil); err != nil {
+		return nil, err
+	}
+
+	return worker, nil
+}
+
+func purge(db *gorm.DB, before time.Time) error {
+	result := db.Unscoped().Where("dispatched_at < ?", before).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge outbox events: %w", result.Error)
+	}
+
+	result = db.Unscoped().Where("finished_at < ?", before).Delete(&models.Job{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge jobs: %w", result.Error)
+	}
+
+	result = db.Unscoped().Where("status = ? AND delivered_at < ?", models.WebhookDeliverySucceeded, before).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge webhook deliveries: %w", result.Error)
+	}
+
+	return nil
+}
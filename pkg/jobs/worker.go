@@ -0,0 +1,349 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/logger"
+	"share-docs/pkg/metrics"
+	"share-docs/pkg/util"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	pollInterval   = time.Second
+	reapInterval   = 30 * time.Second
+	jobTimeout     = 5 * time.Minute
+	leaseGrace     = 30 * time.Second
+	minBackoff     = 10 * time.Second
+	maxBackoff     = time.Hour
+	lastErrorLimit = 2000
+)
+
+// Worker runs jobs from its queues, each with its own concurrency limit, and
+// enqueues the scheduled jobs when they are due. Any number of workers can
+// share the database: claiming uses FOR UPDATE SKIP LOCKED, and scheduled
+// jobs are enqueued under a unique key so only one worker gets each run.
+type Worker struct {
+	db        *gorm.DB
+	log       *logger.Logger
+	registry  *Registry
+	queues    map[string]int
+	schedules []schedule
+	id        string
+}
+
+type schedule struct {
+	name string
+	spec cron.Schedule
+	args Args
+	opts Options
+	next time.Time
+}
+
+func NewWorker(db *gorm.DB, log *logger.Logger, registry *Registry, queues map[string]int) *Worker {
+	hostname, _ := os.Hostname()
+
+	return &Worker{
+		db:       db,
+		log:      log,
+		registry: registry,
+		queues:   queues,
+		id:       fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8]),
+	}
+}
+
+// Schedule enqueues a job on a cron spec ("0 3 * * *", "@hourly", "@every
+// 10m"). Runs missed while no worker was up are skipped.
+func (w *Worker) Schedule(name string, spec string, args Args, opts *Options) error {
+	parsed, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for %s: %w", spec, name, err)
+	}
+
+	s := schedule{name: name, spec: parsed, args: args}
+	if opts != nil {
+		s.opts = *opts
+	}
+
+	w.schedules = append(w.schedules, s)
+	return nil
+}
+
+// Run works the queues until ctx is cancelled, then waits for the jobs in
+// flight to finish.
+func (w *Worker) Run(ctx context.Context) {
+	w.log.WithFields(map[string]interface{}{
+		"worker_id": w.id,
+		"queues":    w.queues,
+	}).Info("Job worker started")
+
+	var wg sync.WaitGroup
+
+	for queue, concurrency := range w.queues {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.runQueue(ctx, queue, concurrency)
+		}()
+	}
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		w.runScheduler(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		w.runReaper(ctx)
+	}()
+
+	wg.Wait()
+	w.log.WithField("worker_id", w.id).Info("Job worker stopped")
+}
+
+func (w *Worker) runQueue(ctx context.Context, queue string, concurrency int) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var running sync.WaitGroup
+	defer running.Wait()
+
+	slots := make(chan struct{}, concurrency)
+	done := make(chan struct{}, concurrency)
+
+	for {
+		if free := concurrency - len(slots); free > 0 {
+			claimed, err := w.claim(ctx, queue, free)
+			if err != nil && ctx.Err() == nil {
+				w.log.WithError(err).WithField("queue", queue).Error("Failed to claim jobs")
+			}
+
+			for _, job := range claimed {
+				slots <- struct{}{}
+				running.Add(1)
+
+				go func() {
+					defer running.Done()
+					defer func() {
+						<-slots
+						select {
+						case done <- struct{}{}:
+						default:
+						}
+					}()
+
+					w.run(ctx, job)
+				}()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-done:
+		}
+	}
+}
+
+func (w *Worker) claim(ctx context.Context, queue string, limit int) ([]models.Job, error) {
+	now := time.Now()
+
+	var claimed []models.Job
+
+	result := w.db.WithContext(ctx).Raw(`
+		UPDATE jobs
+		SET status = ?, attempts = attempts + 1, locked_by = ?, locked_until = ?, started_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE queue = ? AND status = ? AND run_at <= ? AND deleted_at IS NULL
+			ORDER BY run_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		models.JobRunning, w.id, now.Add(jobTimeout+leaseGrace), now, now,
+		queue, models.JobQueued, now, limit,
+	).Scan(&claimed)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return claimed, nil
+}
+
+// run executes a claimed job and records the outcome. The job keeps running
+// through a shutdown, bounded by its timeout, so it isn't cut off halfway.
+func (w *Worker) run(ctx context.Context, job models.Job) {
+	log := w.log.WithFields(map[string]interface{}{
+		"job_id":   job.ID,
+		"job_kind": job.Kind,
+		"queue":    job.Queue,
+		"attempt":  job.Attempts,
+	})
+
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobTimeout)
+	defer cancel()
+
+	var err error
+	if handler, ok := w.registry.handler(job.Kind); ok {
+		err = w.safeRun(jobCtx, handler, &job)
+	} else {
+		err = Permanent(fmt.Errorf("no handler for job kind %s", job.Kind))
+	}
+
+	now := time.Now()
+	updates := map[string]any{
+		"locked_by":    nil,
+		"locked_until": nil,
+	}
+
+	switch {
+	case err == nil:
+		metrics.JobsProcessed.WithLabelValues(job.Kind, "succeeded").Inc()
+		updates["status"] = models.JobSucceeded
+		updates["finished_at"] = now
+		updates["last_error"] = nil
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		metrics.JobsProcessed.WithLabelValues(job.Kind, "dead").Inc()
+		updates["status"] = models.JobDead
+		updates["finished_at"] = now
+		updates["last_error"] = util.Truncate(err.Error(), lastErrorLimit)
+		log.WithError(err).Error("Job failed for good")
+	default:
+		metrics.JobsProcessed.WithLabelValues(job.Kind, "retried").Inc()
+		updates["status"] = models.JobQueued
+		updates["run_at"] = now.Add(util.Backoff(job.Attempts, minBackoff, maxBackoff))
+		updates["last_error"] = util.Truncate(err.Error(), lastErrorLimit)
+		log.WithError(err).Warn("Job failed, will retry")
+	}
+
+	// Only record the outcome while the job is still ours
+	result := w.db.WithContext(context.WithoutCancel(ctx)).Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.JobRunning, w.id).
+		Updates(updates)
+	if result.Error != nil {
+		log.WithError(result.Error).Error("Failed to record job outcome")
+	}
+}
+
+func (w *Worker) safeRun(ctx context.Context, handler handlerFunc, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// runScheduler enqueues each scheduled job when its time comes.
+func (w *Worker) runScheduler(ctx context.Context) {
+	if len(w.schedules) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for i := range w.schedules {
+		w.schedules[i].next = w.schedules[i].spec.Next(now)
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+
+		for i := range w.schedules {
+			s := &w.schedules[i]
+			if now.Before(s.next) {
+				continue
+			}
+
+			opts := s.opts
+			opts.RunAt = s.next
+			opts.UniqueKey = fmt.Sprintf("schedule:%s:%d", s.name, s.next.Unix())
+
+			if err := Enqueue(w.db.WithContext(ctx), s.args, &opts); err != nil {
+				w.log.WithError(err).WithField("schedule", s.name).Error("Failed to enqueue scheduled job")
+				continue
+			}
+
+			s.next = s.spec.Next(now)
+		}
+	}
+}
+
+// runReaper puts jobs whose worker vanished back in their queue, or buries
+// them once they are out of attempts.
+func (w *Worker) runReaper(ctx context.Context) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+
+		result := w.db.WithContext(ctx).Exec(`
+			UPDATE jobs
+			SET status = CASE WHEN attempts >= max_attempts THEN ? ELSE ? END,
+				finished_at = CASE WHEN attempts >= max_attempts THEN ? END,
+				run_at = ?, locked_by = NULL, locked_until = NULL, updated_at = ?,
+				last_error = 'worker stopped responding'
+			WHERE status = ? AND locked_until < ? AND deleted_at IS NULL`,
+			models.JobDead, models.JobQueued, now, now, now, models.JobRunning, now,
+		)
+
+		if result.Error != nil && ctx.Err() == nil {
+			w.log.WithError(result.Error).Error("Failed to reap lost jobs")
+		} else if result.RowsAffected > 0 {
+			w.log.WithField("jobs", result.RowsAffected).Warn("Recovered jobs from lost workers")
+		}
+	}
+}
+
+// ParseQueues reads "queue=concurrency" pairs separated by commas, e.g.
+// "default=4,mail=2".
+func ParseQueues(spec string) (map[string]int, error) {
+	queues := make(map[string]int)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(entry, "=")
+		concurrency, err := strconv.Atoi(strings.TrimSpace(value))
+		if !found || strings.TrimSpace(name) == "" || err != nil || concurrency < 1 {
+			return nil, fmt.Errorf("invalid queue %q, want name=concurrency", entry)
+		}
+
+		queues[strings.TrimSpace(name)] = concurrency
+	}
+
+	if len(queues) == 0 {
+		return nil, fmt.Errorf("no queues configured")
+	}
+
+	return queues, nil
+}
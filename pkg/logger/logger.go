@@ -1,8 +1,10 @@
 package logger
 
 import (
+	"context"
 	"os"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -117,6 +119,20 @@ func (l *Logger) WithRequestID(requestID string) *Logger {
 	return l.WithField("request_id", requestID)
 }
 
+// WithTrace adds the trace and span IDs of the span in ctx, if any, so log
+// lines can be matched with their trace.
+func (l *Logger) WithTrace(ctx context.Context) *Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+
+	return l.WithFields(map[string]interface{}{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+	})
+}
+
 // Sugar returns the sugared logger for printf-style logging
 func (l *Logger) Sugar() *zap.SugaredLogger {
 	return l.sugar
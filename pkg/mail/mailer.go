@@ -0,0 +1,138 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	netmail "net/mail"
+	"net/smtp"
+	"share-docs/pkg/config"
+	"share-docs/pkg/logger"
+	"strings"
+)
+
+// ErrInvalidHeader is returned when an address or the subject contains a
+// line break, which would otherwise let it inject extra headers.
+var ErrInvalidHeader = errors.New("mail header contains a line break")
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer returns an SMTP mailer when an SMTP host is configured, and
+// otherwise a mailer that only logs messages, which is what local development
+// wants.
+func NewMailer(cfg config.Mail, log *logger.Logger) Mailer {
+	if cfg.SMTPHost == "" {
+		return &LogMailer{logger: log}
+	}
+
+	return &SMTPMailer{
+		addr:     fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort),
+		host:     cfg.SMTPHost,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.From,
+	}
+}
+
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	body, err := buildMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	// The envelope sender is the bare address; MAIL_FROM may carry a name.
+	from, err := netmail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %w", m.from, err)
+	}
+
+	if err := smtp.SendMail(m.addr, auth, from.Address, msg.To, body); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// buildMessage renders the headers and body of msg. Addresses and the subject
+// are refused when they contain CR or LF, and the subject is Q-encoded so any
+// non-ASCII text in it survives transport.
+func buildMessage(from string, msg Message) ([]byte, error) {
+	fromHeader, err := formatAddress(from)
+	if err != nil {
+		return nil, err
+	}
+
+	to := make([]string, 0, len(msg.To))
+	for _, addr := range msg.To {
+		formatted, err := formatAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		to = append(to, formatted)
+	}
+
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, ErrInvalidHeader
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", fromHeader)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	return []byte(b.String()), nil
+}
+
+// formatAddress parses addr, which may carry a display name, and renders it
+// back through net/mail so the name is quoted or encoded as needed.
+func formatAddress(addr string) (string, error) {
+	if strings.ContainsAny(addr, "\r\n") {
+		return "", ErrInvalidHeader
+	}
+
+	parsed, err := netmail.ParseAddress(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %q: %w", addr, err)
+	}
+
+	return parsed.String(), nil
+}
+
+type LogMailer struct {
+	logger *logger.Logger
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.WithFields(map[string]interface{}{
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	}).Info("Email not sent, SMTP_HOST is not configured")
+
+	return nil
+}
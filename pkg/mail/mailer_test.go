@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessageRejectsLineBreaks(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		msg  Message
+	}{
+		{"subject", "a@example.com", Message{To: []string{"b@example.com"}, Subject: "hi\r\nBcc: c@example.com"}},
+		{"recipient", "a@example.com", Message{To: []string{"b@example.com\r\nBcc: c@example.com"}, Subject: "hi"}},
+		{"sender", "a@example.com\nBcc: c@example.com", Message{To: []string{"b@example.com"}, Subject: "hi"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildMessage(tt.from, tt.msg); !errors.Is(err, ErrInvalidHeader) {
+				t.Errorf("buildMessage() error = %v, want ErrInvalidHeader", err)
+			}
+		})
+	}
+}
+
+func TestBuildMessageEncodesHeaders(t *testing.T) {
+	body, err := buildMessage("share-docs <no-reply@example.com>", Message{
+		To:      []string{"b@example.com"},
+		Subject: "Zoë uploaded a file",
+		Body:    "hello",
+	})
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+
+	got := string(body)
+	for _, want := range []string{
+		"From: \"share-docs\" <no-reply@example.com>\r\n",
+		"To: <b@example.com>\r\n",
+		"Subject: =?utf-8?q?Zo=C3=AB_uploaded_a_file?=\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -1,15 +1,21 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
+	"share-docs/pkg/audit"
 	"share-docs/pkg/auth"
 	"share-docs/pkg/handlers"
+	"share-docs/pkg/services"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func AuthMiddleware(h handlers.BaseHandlerInterface) gin.HandlerFunc {
+// AuthMiddleware accepts either `Authorization: Bearer <jwt>` or
+// `Authorization: ApiKey <key>`.
+func AuthMiddleware(h handlers.BaseHandlerInterface, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		log := h.GetLogger(c)
 
@@ -22,18 +28,93 @@ func AuthMiddleware(h handlers.BaseHandlerInterface) gin.HandlerFunc {
 			return
 		}
 
+		if key, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+			apiKey, err := apiKeyService.Authenticate(c.Request.Context(), strings.TrimSpace(key))
+
+			if err != nil {
+				log.WithField("error", err).Error("failed validating API key")
+				h.Unauthorized(c, err.Error())
+				c.Abort()
+				return
+			}
+
+			c.Set("UserID", apiKey.OwnerID)
+			c.Set("APIKeyID", apiKey.ID)
+			c.Set("Scopes", apiKey.Scopes)
+
+			ownerID, _ := uuid.Parse(apiKey.OwnerID)
+			keyID, _ := uuid.Parse(apiKey.ID)
+			c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+				Type:     audit.ActorAPIKey,
+				UserID:   &ownerID,
+				APIKeyID: &keyID,
+			}))
+
+			c.Next()
+			return
+		}
+
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		claims, err := auth.ValidateToken(tokenString, auth.AccessToken)
+		claims, err := tokenService.AuthenticateAccessToken(c.Request.Context(), tokenString)
 
 		if err != nil {
 			log.WithField("error", err).Error("failed validating token")
-			h.Unauthorized(c, fmt.Sprintf("%s", err.Error()))
+
+			if errors.Is(err, services.ErrInvalidAccessToken) || errors.Is(err, services.ErrSessionRevoked) {
+				h.Unauthorized(c, err.Error())
+			} else {
+				h.InternalError(c, "failed validating token")
+			}
+
 			c.Abort()
 			return
 		}
 
 		c.Set("UserID", claims.UserID.String())
+		c.Set("SessionID", claims.SessionID.String())
+		c.Set("Scopes", claims.Scopes)
+
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			Type:   audit.ActorUser,
+			UserID: &claims.UserID,
+		}))
+
+		c.Next()
+	}
+}
+
+// RequireScope rejects callers whose token or API key was not granted scope.
+// It must run after AuthMiddleware.
+func RequireScope(h handlers.BaseHandlerInterface, scope auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := h.GetLogger(c)
+
+		if !auth.HasScope(c.GetStringSlice("Scopes"), scope) {
+			log.WithField("required_scope", scope).Error("missing required scope")
+			h.Forbidden(c, fmt.Sprintf("missing required scope '%s'", scope))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireUserSession rejects API keys. Account security settings, such as
+// MFA and sessions, are only managed by the user signed in with a password
+// or single sign-on, whatever scopes a key was granted. It must run after
+// AuthMiddleware.
+func RequireUserSession(h handlers.BaseHandlerInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := h.GetLogger(c)
+
+		if _, isAPIKey := c.Get("APIKeyID"); isAPIKey {
+			log.Error("API key used on a user-only route")
+			h.Forbidden(c, "this endpoint requires a user session, not an API key")
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"share-docs/pkg/audit"
 	"share-docs/pkg/logger"
 	"time"
 
@@ -13,15 +14,27 @@ func LoggingMiddleware(log *logger.Logger) gin.HandlerFunc {
 		start := time.Now()
 
 		requestID := uuid.New().String()
+		clientIP := c.ClientIP()
+		userAgent := c.Request.UserAgent()
+
 		c.Set("request_id", requestID)
+		c.Set("client_ip", clientIP)
+		c.Set("user_agent", userAgent)
+
+		// Services read these for the audit log
+		c.Request = c.Request.WithContext(audit.WithRequest(c.Request.Context(), audit.Request{
+			ID:        requestID,
+			IP:        clientIP,
+			UserAgent: userAgent,
+		}))
 
-		reqLogger := log.WithFields(map[string]interface{}{
+		reqLogger := log.WithTrace(c.Request.Context()).WithFields(map[string]interface{}{
 			"request_id": requestID,
 			"method":     c.Request.Method,
 			"path":       c.Request.URL.Path,
 			"query":      c.Request.URL.RawQuery,
-			"ip":         c.ClientIP(),
-			"user_agent": c.Request.UserAgent(),
+			"ip":         clientIP,
+			"user_agent": userAgent,
 		})
 
 		c.Set("Logger", reqLogger)
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"share-docs/pkg/metrics"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware counts and times requests per route. Routes are labelled
+// by their pattern, e.g. /api/v1/docs/:id, so IDs don't blow up the series;
+// requests that match no route share the "unmatched" label.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		duration := time.Since(start).Seconds()
+		status := strconv.Itoa(c.Writer.Status())
+
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(duration)
+
+		if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+			if c.Request.ContentLength > 0 {
+				metrics.UploadBytes.WithLabelValues(route).Observe(float64(c.Request.ContentLength))
+			}
+			metrics.UploadDuration.WithLabelValues(route).Observe(duration)
+		}
+	}
+}
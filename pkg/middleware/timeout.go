@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadlines replaces the server's read and write timeouts for a route, so
+// uploads and downloads of large files aren't cut off halfway. It has to run
+// before the handler reads the body.
+func Deadlines(read, write time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rc := http.NewResponseController(c.Writer)
+		now := time.Now()
+
+		// Writers that don't support deadlines keep the server's
+		rc.SetReadDeadline(now.Add(read))
+		rc.SetWriteDeadline(now.Add(write))
+
+		c.Next()
+	}
+}
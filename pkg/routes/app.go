@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"errors"
+	"share-docs/pkg/logger"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// App is the API router together with what has to be released on exit.
+type App struct {
+	Router *gin.Engine
+	Log    *logger.Logger
+	DB     *gorm.DB
+
+	workers sync.WaitGroup
+}
+
+// background runs a worker loop that returns once the context SetupRouter
+// was given is cancelled.
+func (a *App) background(run func()) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		run()
+	}()
+}
+
+// Close waits for the background workers to stop, then closes the database
+// pool and flushes the log. Cancel the SetupRouter context first.
+func (a *App) Close() error {
+	a.workers.Wait()
+
+	var errs []error
+
+	if sqlDB, err := a.DB.DB(); err != nil {
+		errs = append(errs, err)
+	} else if err := sqlDB.Close(); err != nil {
+		errs = append(errs, err)
+	}
+
+	a.Log.Info("Shut down")
+
+	// Syncing stdout fails on some platforms; that is not worth reporting
+	a.Log.Sync()
+
+	return errors.Join(errs...)
+}
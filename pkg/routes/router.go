@@ -1,48 +1,324 @@
 package routes
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"share-docs/migrations"
+	"share-docs/pkg/auth"
+	"share-docs/pkg/config"
 	"share-docs/pkg/db"
+	"share-docs/pkg/events"
 	"share-docs/pkg/handlers"
+	"share-docs/pkg/jobs"
 	"share-docs/pkg/logger"
+	"share-docs/pkg/mail"
+	"share-docs/pkg/metrics"
 	"share-docs/pkg/middleware"
 	"share-docs/pkg/services"
-	"share-docs/pkg/util"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func setupAuthRoutes(r *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+func setupAuthRoutes(r *gin.RouterGroup, authHandler *handlers.AuthHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
 	auth := r.Group("/auth")
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
+		auth.POST("/mfa", authHandler.LoginMFA)
 		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/logout-all", middleware.AuthMiddleware(authHandler, tokenService, apiKeyService), middleware.RequireUserSession(authHandler), authHandler.LogoutAll)
+		auth.GET("/oidc", authHandler.OIDCProviders)
+		auth.GET("/oidc/:provider/login", authHandler.OIDCLogin)
+		auth.GET("/oidc/:provider/callback", authHandler.OIDCCallback)
 	}
 }
 
-func setupUserRoutes(r *gin.RouterGroup, userHandler *handlers.UserHandler) {
+func setupUserRoutes(r *gin.RouterGroup, userHandler *handlers.UserHandler, sessionHandler *handlers.SessionHandler, mfaHandler *handlers.MFAHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
 	user := r.Group("/user")
-	user.Use(middleware.AuthMiddleware(userHandler))
+	user.Use(middleware.AuthMiddleware(userHandler, tokenService, apiKeyService))
+
+	read := middleware.RequireScope(userHandler, auth.ScopeDocsRead)
+	session := middleware.RequireUserSession(userHandler)
 	{
-		user.GET("/", userHandler.GetUser)
+		user.GET("/", read, userHandler.GetUser)
+		user.GET("/sessions", session, sessionHandler.ListSessions)
+		user.DELETE("/sessions/:id", session, sessionHandler.RevokeSession)
+		user.POST("/mfa/enroll", session, mfaHandler.Enroll)
+		user.POST("/mfa/confirm", session, mfaHandler.Confirm)
+		user.POST("/mfa/disable", session, mfaHandler.Disable)
+		user.POST("/mfa/recovery-codes", session, mfaHandler.RegenerateRecoveryCodes)
 	}
 }
 
-func setupDocumentRoutes(r *gin.RouterGroup, documentHandler *handlers.DocHandler) {
+func setupDocumentRoutes(r *gin.RouterGroup, documentHandler *handlers.DocHandler, collaboratorHandler *handlers.CollaboratorHandler, linkHandler *handlers.LinkHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface, transfer gin.HandlerFunc) {
 	docs := r.Group("/docs")
-	docs.Use(middleware.AuthMiddleware(documentHandler))
+	docs.Use(middleware.AuthMiddleware(documentHandler, tokenService, apiKeyService))
+
+	read := middleware.RequireScope(documentHandler, auth.ScopeDocsRead)
+	write := middleware.RequireScope(documentHandler, auth.ScopeDocsWrite)
+	links := middleware.RequireScope(documentHandler, auth.ScopeLinksManage)
+	{
+		docs.GET("/", read, documentHandler.ListDocuments)
+		docs.GET("/shared", read, documentHandler.ListSharedDocuments)
+		docs.POST("/invitations/accept", read, collaboratorHandler.AcceptInvitation)
+		docs.GET("/:id", read, documentHandler.GetDocument)
+		docs.GET("/:id/file", transfer, read, documentHandler.GetFile)
+		docs.POST("/", transfer, write, documentHandler.CreateDocument)
+		docs.PUT(":id", write, documentHandler.UpdateDocument)
+		docs.DELETE("/:id", write, documentHandler.DeleteDocument)
+		docs.GET("/:id/collaborators", read, collaboratorHandler.ListCollaborators)
+		docs.POST("/:id/collaborators", write, collaboratorHandler.AddCollaborator)
+		docs.PUT("/:id/collaborators/:email", write, collaboratorHandler.UpdateCollaborator)
+		docs.DELETE("/:id/collaborators/:email", write, collaboratorHandler.RemoveCollaborator)
+		docs.GET("/:id/links", links, linkHandler.ListLinks)
+		docs.POST("/:id/links", links, linkHandler.CreateLink)
+		docs.GET("/:id/stats", links, linkHandler.DocumentStats)
+	}
+}
+
+func setupLinkRoutes(r *gin.RouterGroup, linkHandler *handlers.LinkHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	links := r.Group("/links")
+	links.Use(middleware.AuthMiddleware(linkHandler, tokenService, apiKeyService))
+	links.Use(middleware.RequireScope(linkHandler, auth.ScopeLinksManage))
+	{
+		links.PUT("/:linkId", linkHandler.UpdateLink)
+		links.DELETE("/:linkId", linkHandler.DeleteLink)
+		links.GET("/:linkId/stats", linkHandler.LinkStats)
+		links.GET("/:linkId/views", linkHandler.ListViews)
+	}
+}
+
+// setupSharedRoutes serves share links. They are public: the link token is
+// the credential.
+func setupSharedRoutes(r *gin.RouterGroup, sharedHandler *handlers.SharedHandler, transfer gin.HandlerFunc) {
+	shared := r.Group("/shared")
+	{
+		shared.GET("/:token", sharedHandler.Access)
+		shared.POST("/:token/request-code", sharedHandler.RequestCode)
+		shared.GET("/:token/agreement", sharedHandler.GetAgreement)
+		shared.GET("/:token/agreement/file", transfer, sharedHandler.GetAgreementFile)
+		shared.POST("/:token/verify", sharedHandler.Verify)
+		shared.GET("/:token/file", transfer, sharedHandler.GetFile)
+		shared.GET("/:token/download", transfer, sharedHandler.Download)
+		shared.POST("/:token/beacon", sharedHandler.Beacon)
+	}
+}
+
+func setupAgreementRoutes(r *gin.RouterGroup, agreementHandler *handlers.AgreementHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	agreements := r.Group("/agreements")
+	agreements.Use(middleware.AuthMiddleware(agreementHandler, tokenService, apiKeyService))
+	agreements.Use(middleware.RequireScope(agreementHandler, auth.ScopeLinksManage))
+	{
+		agreements.GET("/", agreementHandler.ListAgreements)
+		agreements.POST("/", agreementHandler.CreateAgreement)
+		agreements.GET("/:id", agreementHandler.GetAgreement)
+		agreements.DELETE("/:id", agreementHandler.DeleteAgreement)
+		agreements.GET("/:id/acceptances", agreementHandler.ListAcceptances)
+	}
+}
+
+func setupDataRoomRoutes(r *gin.RouterGroup, dataRoomHandler *handlers.DataRoomHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	rooms := r.Group("/datarooms")
+	rooms.Use(middleware.AuthMiddleware(dataRoomHandler, tokenService, apiKeyService))
+	rooms.Use(middleware.RequireScope(dataRoomHandler, auth.ScopeLinksManage))
+	{
+		rooms.GET("/", dataRoomHandler.ListDataRooms)
+		rooms.POST("/", dataRoomHandler.CreateDataRoom)
+		rooms.GET("/:id", dataRoomHandler.GetDataRoom)
+		rooms.PUT("/:id", dataRoomHandler.UpdateDataRoom)
+		rooms.DELETE("/:id", dataRoomHandler.DeleteDataRoom)
+		rooms.POST("/:id/link", dataRoomHandler.RotateLink)
+		rooms.GET("/:id/items", dataRoomHandler.ListItems)
+		rooms.POST("/:id/items", dataRoomHandler.AddItem)
+		rooms.DELETE("/:id/items/:itemId", dataRoomHandler.RemoveItem)
+		rooms.GET("/:id/groups", dataRoomHandler.ListGroups)
+		rooms.POST("/:id/groups", dataRoomHandler.CreateGroup)
+		rooms.PUT("/:id/groups/:groupId", dataRoomHandler.UpdateGroup)
+		rooms.DELETE("/:id/groups/:groupId", dataRoomHandler.DeleteGroup)
+		rooms.GET("/:id/viewers", dataRoomHandler.ListViewers)
+		rooms.POST("/:id/viewers", dataRoomHandler.AddViewers)
+		rooms.PUT("/:id/viewers/:viewerId", dataRoomHandler.UpdateViewer)
+		rooms.DELETE("/:id/viewers/:viewerId", dataRoomHandler.RemoveViewer)
+		rooms.GET("/:id/activity", dataRoomHandler.ListActivity)
+		rooms.GET("/:id/stats", dataRoomHandler.RoomStats)
+	}
+}
+
+// setupRoomRoutes serves data rooms to their viewers. Like share links they
+// are public, but viewers sign in with a code sent to an email on the list.
+func setupRoomRoutes(r *gin.RouterGroup, roomHandler *handlers.RoomHandler, transfer gin.HandlerFunc) {
+	rooms := r.Group("/rooms")
+	{
+		rooms.GET("/:token", roomHandler.GetRoom)
+		rooms.POST("/:token/request-code", roomHandler.RequestCode)
+		rooms.POST("/:token/verify", roomHandler.Verify)
+		rooms.GET("/:token/contents", roomHandler.GetContents)
+		rooms.GET("/:token/documents/:documentId/file", transfer, roomHandler.GetFile)
+		rooms.GET("/:token/documents/:documentId/download", transfer, roomHandler.Download)
+	}
+}
+
+func setupUploadRequestRoutes(r *gin.RouterGroup, uploadRequestHandler *handlers.UploadRequestHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	requests := r.Group("/upload-requests")
+	requests.Use(middleware.AuthMiddleware(uploadRequestHandler, tokenService, apiKeyService))
+	requests.Use(middleware.RequireScope(uploadRequestHandler, auth.ScopeLinksManage))
+	{
+		requests.GET("/", uploadRequestHandler.ListUploadRequests)
+		requests.POST("/", uploadRequestHandler.CreateUploadRequest)
+		requests.GET("/:id", uploadRequestHandler.GetUploadRequest)
+		requests.PUT("/:id", uploadRequestHandler.UpdateUploadRequest)
+		requests.DELETE("/:id", uploadRequestHandler.DeleteUploadRequest)
+		requests.GET("/:id/documents", uploadRequestHandler.ListReceivedDocuments)
+	}
+}
+
+func setupWebhookRoutes(r *gin.RouterGroup, webhookHandler *handlers.WebhookHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(middleware.AuthMiddleware(webhookHandler, tokenService, apiKeyService))
+	webhooks.Use(middleware.RequireScope(webhookHandler, auth.ScopeDocsRead))
+
+	manage := middleware.RequireScope(webhookHandler, auth.ScopeWebhooksManage)
+	{
+		webhooks.GET("/", webhookHandler.ListWebhooks)
+		webhooks.POST("/", manage, webhookHandler.CreateWebhook)
+		webhooks.GET("/dead-letters", webhookHandler.ListDeadLetters)
+		webhooks.GET("/:id", webhookHandler.GetWebhook)
+		webhooks.PUT("/:id", manage, webhookHandler.UpdateWebhook)
+		webhooks.DELETE("/:id", manage, webhookHandler.DeleteWebhook)
+		webhooks.POST("/:id/rotate-secret", manage, webhookHandler.RotateSecret)
+		webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+		webhooks.POST("/:id/deliveries/:deliveryId/redeliver", manage, webhookHandler.Redeliver)
+	}
+}
+
+func setupAdminRoutes(r *gin.RouterGroup, jobHandler *handlers.JobHandler, auditHandler *handlers.AuditHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface, transfer gin.HandlerFunc) {
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(jobHandler, tokenService, apiKeyService))
+	admin.Use(middleware.RequireScope(jobHandler, auth.ScopeAdmin))
 	{
-		docs.GET("/:id", documentHandler.GetDocument)
-		docs.GET("/:id/file", documentHandler.GetFile)
-		docs.POST("/", documentHandler.CreateDocument)
-		docs.PUT(":id", documentHandler.UpdateDocument)
+		admin.GET("/jobs", jobHandler.ListJobs)
+		admin.GET("/jobs/stats", jobHandler.QueueStats)
+		admin.GET("/jobs/:id", jobHandler.GetJob)
+		admin.POST("/jobs/:id/retry", jobHandler.RetryJob)
+		admin.POST("/jobs/:id/cancel", jobHandler.CancelJob)
+		admin.GET("/audit", transfer, auditHandler.ListEvents)
+		admin.GET("/audit/verify", auditHandler.VerifyChain)
 	}
 }
 
-// SetupRouter configures the Gin router with all routes
-func SetupRouter() *gin.Engine {
+// setupJobs starts a job worker in the API process unless it is configured
+// to run in `cmd worker` instead.
+func setupJobs(ctx context.Context, app *App, cfg config.Jobs, database *gorm.DB, log *logger.Logger, mailer mail.Mailer) {
+	if !cfg.InProcess {
+		return
+	}
+
+	queues, err := jobs.ParseQueues(cfg.Queues)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to configure job queues: %v", err))
+	}
+
+	worker, err := jobs.NewStandardWorker(database, log, mailer, queues)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to set up job worker: %v", err))
+	}
+
+	app.background(func() { worker.Run(ctx) })
+}
+
+// setupUploadRoutes receives files through upload requests. They are public:
+// the request token is the credential.
+func setupUploadRoutes(r *gin.RouterGroup, uploadHandler *handlers.UploadHandler, transfer gin.HandlerFunc) {
+	uploads := r.Group("/uploads")
+	{
+		uploads.GET("/:token", uploadHandler.GetUploadRequest)
+		uploads.POST("/:token", transfer, uploadHandler.Upload)
+	}
+}
+
+func setupFolderRoutes(r *gin.RouterGroup, folderHandler *handlers.FolderHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	folders := r.Group("/folders")
+	folders.Use(middleware.AuthMiddleware(folderHandler, tokenService, apiKeyService))
+
+	read := middleware.RequireScope(folderHandler, auth.ScopeDocsRead)
+	write := middleware.RequireScope(folderHandler, auth.ScopeDocsWrite)
+	{
+		folders.GET("/", read, folderHandler.ListFolders)
+		folders.POST("/", write, folderHandler.CreateFolder)
+		folders.DELETE("/:id", write, folderHandler.DeleteFolder)
+	}
+}
+
+func setupOrganizationRoutes(r *gin.RouterGroup, orgHandler *handlers.OrgHandler, tokenService services.TokenServiceInterface, apiKeyService services.APIKeyServiceInterface) {
+	orgs := r.Group("/orgs")
+	orgs.Use(middleware.AuthMiddleware(orgHandler, tokenService, apiKeyService))
+
+	read := middleware.RequireScope(orgHandler, auth.ScopeDocsRead)
+	write := middleware.RequireScope(orgHandler, auth.ScopeDocsWrite)
+	{
+		orgs.GET("/", read, orgHandler.ListOrganizations)
+		orgs.POST("/", write, orgHandler.CreateOrganization)
+		orgs.POST("/invitations/accept", write, orgHandler.AcceptInvitation)
+		orgs.GET("/:id", read, orgHandler.GetOrganization)
+		orgs.PUT("/:id", write, orgHandler.UpdateOrganization)
+		orgs.DELETE("/:id", write, orgHandler.DeleteOrganization)
+		orgs.GET("/:id/members", read, orgHandler.ListMembers)
+		orgs.PUT("/:id/members/:userId", write, orgHandler.UpdateMemberRole)
+		orgs.DELETE("/:id/members/:userId", write, orgHandler.RemoveMember)
+		orgs.GET("/:id/invitations", read, orgHandler.ListInvitations)
+		orgs.POST("/:id/invitations", write, orgHandler.InviteMember)
+		orgs.DELETE("/:id/invitations/:invitationId", write, orgHandler.RevokeInvitation)
+	}
+}
+
+// setupEvents starts the relay that dispatches outbox events to the
+// in-process bus and the broker. The returned bus takes in-process handlers.
+func setupEvents(ctx context.Context, app *App, database *gorm.DB, log *logger.Logger) *events.Bus {
+	bus := events.NewBus()
+	bus.Subscribe(events.AllEvents, func(ctx context.Context, event events.Event) error {
+		log.WithFields(map[string]interface{}{
+			"event_id":     event.ID,
+			"event_type":   event.Type,
+			"aggregate_id": event.AggregateID,
+		}).Debug("Domain event")
+		return nil
+	})
+
+	broker := events.NewBrokerSink("share-docs", events.NewMemoryBroker())
+
+	relay := events.NewRelay(database, log, bus, broker)
+	app.background(func() { relay.Run(ctx) })
+
+	return bus
+}
+
+// migrate applies pending migrations before the API starts serving. Replicas
+// starting together wait for each other on the migration lock.
+func migrate(ctx context.Context, database *gorm.DB, log *logger.Logger) {
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to set up migrations: %v", err))
+	}
+
+	results, err := migrator.Up(ctx)
+	for _, result := range results {
+		log.WithFields(map[string]interface{}{
+			"version":  result.Source.Version,
+			"duration": result.Duration.String(),
+		}).Info("Applied migration")
+	}
+
+	if err != nil {
+		panic(fmt.Sprintf("Failed to migrate database: %v", err))
+	}
+}
+
+// SetupRouter configures the Gin router with all routes. Background workers
+// run until ctx is cancelled; App.Close waits for them.
+func SetupRouter(ctx context.Context, cfg *config.Config) *App {
 	r := gin.Default()
 	// Health check endpoint
 	r.GET("/ping", func(c *gin.Context) {
@@ -50,9 +326,9 @@ func SetupRouter() *gin.Engine {
 	})
 
 	logConfig := logger.LogConfig{
-		Level:       util.GetEnv("LOG_LEVEL", "info"),
-		Environment: util.GetEnv("ENVIRONMENT", "development"),
-		OutputPath:  util.GetEnv("LOG_OUTPUT", "stdout"),
+		Level:       cfg.Log.Level,
+		Environment: cfg.Environment,
+		OutputPath:  cfg.Log.Output,
 		ServiceName: "share-docs",
 		Version:     "1.0.0",
 	}
@@ -62,24 +338,108 @@ func SetupRouter() *gin.Engine {
 		panic(fmt.Sprintf("Failed to initialise logger: %v", err))
 	}
 
+	log.WithFields(cfg.Redacted()).Info("Configuration loaded")
+
+	database := db.Connect(cfg.Database)
+	app := &App{Router: r, Log: log, DB: database}
+
+	sqlDB, err := database.DB()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to get database handle: %v", err))
+	}
+	metrics.RegisterDB(sqlDB)
+
+	if cfg.Database.AutoMigrate {
+		migrate(ctx, database, log)
+	}
+
+	storageService := services.NewStorageService(cfg.Storage, log)
+
+	// Probes are registered ahead of the middleware to keep them out of the
+	// request logs, traces and metrics
+	healthService := services.NewHealthService(database, storageService, migrations.FS)
+	healthHandler := handlers.NewHealthHandler(healthService, *handlers.NewBaseHandler(database, log))
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	r.Use(middleware.TracingMiddleware())
 	r.Use(middleware.LoggingMiddleware(log))
+	r.Use(middleware.MetricsMiddleware())
 
-	database := db.Connect()
+	bus := setupEvents(ctx, app, database, log)
+
+	auditService := services.NewAuditService(database, log)
+	userService := services.NewUserService(database, auditService)
+	jwt, err := auth.NewJWT(cfg.JWT)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load JWT signing keys: %v", err))
+	}
 
-	userService := services.NewUserService(database)
-	docService := services.NewDocumentService(database)
-	storageType := util.MustGetEnv("STORAGE_TYPE")
-	storageService := services.NewStorageService(storageType, log)
+	tokenService := services.NewTokenService(database, jwt, auditService)
+	mfaService := services.NewMFAService(database, auditService)
+	apiKeyService := services.NewAPIKeyService(database, auditService)
+
+	oidcProviders, err := auth.LoadOIDCProviders(cfg.OIDC.ProvidersFile)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load OIDC providers: %v", err))
+	}
+	oidcService := services.NewOIDCService(database, oidcProviders, userService)
+	docService := services.NewDocumentService(database, auditService)
+	folderService := services.NewFolderService(database)
+	mailer := mail.NewMailer(cfg.Mail, log)
+	setupJobs(ctx, app, cfg.Jobs, database, log, mailer)
+	orgService := services.NewOrganizationService(database, auditService, cfg.BaseURL)
+	collaboratorService := services.NewCollaboratorService(database, auditService, cfg.BaseURL)
+	linkService := services.NewShareLinkService(database, auditService, cfg.BaseURL)
+	linkAnalyticsService := services.NewLinkAnalyticsService(database)
+	agreementService := services.NewAgreementService(database)
+	dataRoomService := services.NewDataRoomService(database, cfg.BaseURL)
+	uploadRequestService := services.NewUploadRequestService(database, docService, cfg.BaseURL)
+	webhookService := services.NewWebhookService(database)
+	jobService := services.NewJobService(database)
+	bus.Subscribe(events.AllEvents, webhookService.HandleEvent)
+	app.background(func() { webhookService.RunDeliveries(ctx, log) })
 
 	baseHandler := handlers.NewBaseHandler(database, log)
 	userHandler := handlers.NewUserHandler(userService, *baseHandler)
-	authHandler := handlers.NewAuthHandler(userService, *baseHandler)
+	authHandler := handlers.NewAuthHandler(userService, tokenService, mfaService, oidcService, jwt, *baseHandler)
+	sessionHandler := handlers.NewSessionHandler(tokenService, *baseHandler)
+	mfaHandler := handlers.NewMFAHandler(mfaService, *baseHandler)
 	docHandler := handlers.NewDocHandler(*docService, *storageService, *baseHandler)
+	collaboratorHandler := handlers.NewCollaboratorHandler(collaboratorService, *baseHandler)
+	linkHandler := handlers.NewLinkHandler(linkService, linkAnalyticsService, *baseHandler)
+	sharedHandler := handlers.NewSharedHandler(linkService, linkAnalyticsService, *baseHandler)
+	agreementHandler := handlers.NewAgreementHandler(agreementService, *baseHandler)
+	dataRoomHandler := handlers.NewDataRoomHandler(dataRoomService, *baseHandler)
+	roomHandler := handlers.NewRoomHandler(dataRoomService, *baseHandler)
+	uploadRequestHandler := handlers.NewUploadRequestHandler(uploadRequestService, *baseHandler)
+	uploadHandler := handlers.NewUploadHandler(uploadRequestService, *storageService, *baseHandler)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, *baseHandler)
+	jobHandler := handlers.NewJobHandler(jobService, *baseHandler)
+	auditHandler := handlers.NewAuditHandler(auditService, *baseHandler)
+	folderHandler := handlers.NewFolderHandler(folderService, *baseHandler)
+	orgHandler := handlers.NewOrgHandler(orgService, *baseHandler)
+
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Routes moving files get longer deadlines than the server's defaults
+	transfer := middleware.Deadlines(cfg.HTTP.TransferTimeout, cfg.HTTP.TransferTimeout)
 
 	api := r.Group("/api/v1")
-	setupAuthRoutes(api, authHandler)
-	setupUserRoutes(api, userHandler)
-	setupDocumentRoutes(api, docHandler)
+	setupAuthRoutes(api, authHandler, tokenService, apiKeyService)
+	setupUserRoutes(api, userHandler, sessionHandler, mfaHandler, tokenService, apiKeyService)
+	setupDocumentRoutes(api, docHandler, collaboratorHandler, linkHandler, tokenService, apiKeyService, transfer)
+	setupLinkRoutes(api, linkHandler, tokenService, apiKeyService)
+	setupSharedRoutes(api, sharedHandler, transfer)
+	setupAgreementRoutes(api, agreementHandler, tokenService, apiKeyService)
+	setupDataRoomRoutes(api, dataRoomHandler, tokenService, apiKeyService)
+	setupRoomRoutes(api, roomHandler, transfer)
+	setupUploadRequestRoutes(api, uploadRequestHandler, tokenService, apiKeyService)
+	setupUploadRoutes(api, uploadHandler, transfer)
+	setupWebhookRoutes(api, webhookHandler, tokenService, apiKeyService)
+	setupAdminRoutes(api, jobHandler, auditHandler, tokenService, apiKeyService, transfer)
+	setupFolderRoutes(api, folderHandler, tokenService, apiKeyService)
+	setupOrganizationRoutes(api, orgHandler, tokenService, apiKeyService)
 
-	return r
+	return app
 }
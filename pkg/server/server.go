@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"share-docs/pkg/config"
+	"share-docs/pkg/logger"
+	"time"
+)
+
+func New(cfg config.HTTP, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    1 << 20,
+	}
+}
+
+// Run serves until ctx is cancelled, then stops accepting connections and
+// waits up to ShutdownTimeout for the requests in flight, uploads included,
+// to finish. It returns an error if the server could not start or the
+// requests could not be drained in time.
+func Run(ctx context.Context, cfg config.HTTP, srv *http.Server, log *logger.Logger) error {
+	serveErr := make(chan error, 1)
+
+	go func() {
+		log.WithFields(map[string]interface{}{
+			"addr": cfg.Addr,
+			"tls":  cfg.TLS(),
+		}).Info("HTTP server listening")
+
+		if cfg.TLS() {
+			serveErr <- srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			serveErr <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("HTTP server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.WithField("timeout", cfg.ShutdownTimeout.String()).Info("Shutting down, draining requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("failed to drain requests: %w", err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// RunInternal serves handler on addr until ctx is cancelled. It is for
+// endpoints such as metrics that must stay off the public listener, so it
+// uses plain HTTP and drops connections on shutdown instead of draining them.
+func RunInternal(ctx context.Context, addr string, handler http.Handler, log *logger.Logger) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	log.WithField("addr", addr).Info("Internal HTTP server listening")
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("internal HTTP server failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/db/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// accessibleBy is a condition group for organisation-ownable tables
+// (documents, folders) matching the rows userID may reach with at least the
+// min role: their personal rows and the rows of organisations they belong to.
+// Use it as db.Where(accessibleBy(...)).
+func accessibleBy(db *gorm.DB, table string, userID uuid.UUID, min orgapp.Role) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Where(
+		fmt.Sprintf("(%[1]s.organization_id IS NULL AND %[1]s.user_id = ?) OR %[1]s.organization_id IN (?)", table),
+		userID, membershipsOf(db, userID, min),
+	)
+}
+
+// membershipsOf is a subquery selecting the organisations where userID holds
+// at least the min role.
+func membershipsOf(db *gorm.DB, userID uuid.UUID, min orgapp.Role) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.OrganizationMembership{}).
+		Select("organization_id").
+		Where("user_id = ? AND role IN ?", userID, orgapp.RolesAtLeast(min))
+}
+
+// sharedWith is a subquery selecting the documents shared with userID as a
+// collaborator with at least the min role.
+func sharedWith(db *gorm.DB, userID uuid.UUID, min documentapp.PermissionRole) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.DocumentPermission{}).
+		Select("document_id").
+		Where("user_id = ? AND role IN ?", userID, documentapp.PermissionRolesAtLeast(min))
+}
+
+// readableDocuments matches the documents userID may read: their own, their
+// organisations', the ones shared with them and public ones.
+func readableDocuments(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return accessibleBy(db, "documents", userID, orgapp.RoleViewer).
+		Or("documents.id IN (?)", sharedWith(db, userID, documentapp.PermissionViewer)).
+		Or("documents.is_public = ?", true)
+}
+
+// editableDocuments matches the documents userID may edit: their own, their
+// organisations' as at least a member, and the ones shared with them as editor.
+func editableDocuments(db *gorm.DB, userID uuid.UUID) *gorm.DB {
+	return accessibleBy(db, "documents", userID, orgapp.RoleMember).
+		Or("documents.id IN (?)", sharedWith(db, userID, documentapp.PermissionEditor))
+}
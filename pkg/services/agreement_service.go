@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/db/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAgreementNotFound    = errors.New("agreement not found")
+	ErrAgreementEmpty       = errors.New("agreement needs content or a document")
+	ErrAgreementNotAccepted = errors.New("share link requires accepting its agreement with a name and email")
+)
+
+type AgreementServiceInterface interface {
+	CreateAgreement(ctx context.Context, userID uuid.UUID, req linkapp.CreateAgreement) (*linkapp.Agreement, error)
+	ListAgreements(ctx context.Context, userID uuid.UUID) ([]linkapp.Agreement, error)
+	GetAgreement(userID uuid.UUID, agreementID string) (*linkapp.Agreement, error)
+	DeleteAgreement(ctx context.Context, userID uuid.UUID, agreementID string) error
+	ListAcceptances(ctx context.Context, userID uuid.UUID, agreementID string, page, limit int) ([]linkapp.Acceptance, int64, error)
+	ExportAcceptances(ctx context.Context, userID uuid.UUID, agreementID string, w io.Writer) error
+}
+
+type AgreementService struct {
+	db *gorm.DB
+}
+
+func NewAgreementService(db *gorm.DB) *AgreementService {
+	return &AgreementService{
+		db: db,
+	}
+}
+
+// CreateAgreement creates an agreement from text, a document the user can
+// read, or both.
+func (s *AgreementService) CreateAgreement(ctx context.Context, userID uuid.UUID, req linkapp.CreateAgreement) (*linkapp.Agreement, error) {
+	if req.Content != nil && strings.TrimSpace(*req.Content) == "" {
+		req.Content = nil
+	}
+
+	if req.Content == nil && req.DocumentID == nil {
+		return nil, ErrAgreementEmpty
+	}
+
+	agreement := &models.Agreement{
+		UserID:  userID,
+		Name:    req.Name,
+		Content: req.Content,
+	}
+
+	if req.DocumentID != nil {
+		documentID, err := uuid.Parse(*req.DocumentID)
+		if err != nil {
+			return nil, ErrInvalidId
+		}
+
+		var count int64
+		s.db.WithContext(ctx).Model(&models.Document{}).Where("documents.id = ?", documentID).Where(readableDocuments(s.db, userID)).Count(&count)
+		if count == 0 {
+			return nil, ErrDocumentNotFound
+		}
+
+		agreement.DocumentID = &documentID
+	}
+
+	if result := s.db.WithContext(ctx).Create(agreement); result.Error != nil {
+		return nil, fmt.Errorf("failed to create agreement: %w", result.Error)
+	}
+
+	a := linkapp.ToAppAgreement(*agreement)
+	return &a, nil
+}
+
+func (s *AgreementService) ListAgreements(ctx context.Context, userID uuid.UUID) ([]linkapp.Agreement, error) {
+	var modelAgreements []models.Agreement
+
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&modelAgreements)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	agreements := make([]linkapp.Agreement, 0, len(modelAgreements))
+	for _, ma := range modelAgreements {
+		agreements = append(agreements, linkapp.ToAppAgreement(ma))
+	}
+
+	return agreements, nil
+}
+
+func (s *AgreementService) GetAgreement(userID uuid.UUID, agreementID string) (*linkapp.Agreement, error) {
+	agreement, err := findOwnAgreement(s.db, userID, agreementID)
+	if err != nil {
+		return nil, err
+	}
+
+	a := linkapp.ToAppAgreement(*agreement)
+	return &a, nil
+}
+
+// DeleteAgreement detaches the agreement from its links. Acceptances are kept
+// as the legal record.
+func (s *AgreementService) DeleteAgreement(ctx context.Context, userID uuid.UUID, agreementID string) error {
+	agreement, err := findOwnAgreement(s.db, userID, agreementID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ShareLink{}).Where("agreement_id = ?", agreement.ID).Update("agreement_id", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Delete(agreement).Error
+	})
+}
+
+// ListAcceptances lists who accepted an agreement, newest first.
+func (s *AgreementService) ListAcceptances(ctx context.Context, userID uuid.UUID, agreementID string, page, limit int) ([]linkapp.Acceptance, int64, error) {
+	agreement, err := findOwnAgreement(s.db, userID, agreementID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.AgreementAcceptance{}).Where("agreement_id = ?", agreement.ID)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelAcceptances []models.AgreementAcceptance
+
+	result := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&modelAcceptances)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	acceptances := make([]linkapp.Acceptance, 0, len(modelAcceptances))
+	for _, ma := range modelAcceptances {
+		acceptances = append(acceptances, linkapp.ToAppAcceptance(ma))
+	}
+
+	return acceptances, total, nil
+}
+
+// ExportAcceptances writes every acceptance of an agreement as CSV, oldest
+// first.
+func (s *AgreementService) ExportAcceptances(ctx context.Context, userID uuid.UUID, agreementID string, w io.Writer) error {
+	agreement, err := findOwnAgreement(s.db, userID, agreementID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.WithContext(ctx).Model(&models.AgreementAcceptance{}).
+		Where("agreement_id = ?", agreement.ID).
+		Order("created_at").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	out := csv.NewWriter(w)
+
+	if err := out.Write([]string{"name", "email", "accepted_at", "ip_address", "user_agent", "share_link_id", "agreement"}); err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var acceptance models.AgreementAcceptance
+		if err := s.db.WithContext(ctx).ScanRows(rows, &acceptance); err != nil {
+			return err
+		}
+
+		record := []string{
+			csvSafe(acceptance.Name),
+			csvSafe(acceptance.Email),
+			acceptance.CreatedAt.UTC().Format(time.RFC3339),
+			acceptance.IPAddress,
+			csvSafe(acceptance.UserAgent),
+			acceptance.ShareLinkID.String(),
+			csvSafe(agreement.Name),
+		}
+
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// findOwnAgreement loads an agreement created by the user.
+func findOwnAgreement(db *gorm.DB, userID uuid.UUID, stringID string) (*models.Agreement, error) {
+	agreementID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var agreement models.Agreement
+
+	result := db.Where("user_id = ?", userID).First(&agreement, agreementID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrAgreementNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &agreement, nil
+}
+
+// csvSafe keeps viewer-supplied values from being read as formulas when the
+// export is opened in a spreadsheet.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/apikeyapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/auth"
+	"share-docs/pkg/db/models"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyRevoked  = errors.New("API key has been revoked")
+	ErrAPIKeyExpired  = errors.New("API key has expired")
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrInvalidScopes  = errors.New("invalid scopes")
+	ErrAdminScope     = errors.New("only admins can be granted the admin scope")
+)
+
+type APIKeyServiceInterface interface {
+	CreateAPIKey(ownerEmail, name string, scopes []string, expiresAt *time.Time) (*apikeyapp.CreatedAPIKey, error)
+	ListAPIKeys(ownerEmail string) ([]apikeyapp.APIKey, error)
+	RevokeAPIKey(id string) error
+	RotateAPIKey(id string) (*apikeyapp.CreatedAPIKey, error)
+	Authenticate(ctx context.Context, key string) (*apikeyapp.APIKey, error)
+}
+
+type APIKeyService struct {
+	db      *gorm.DB
+	auditor audit.Recorder
+}
+
+func NewAPIKeyService(db *gorm.DB, auditor audit.Recorder) *APIKeyService {
+	return &APIKeyService{
+		db:      db,
+		auditor: auditor,
+	}
+}
+
+func (s *APIKeyService) CreateAPIKey(ownerEmail, name string, scopes []string, expiresAt *time.Time) (*apikeyapp.CreatedAPIKey, error) {
+	if err := auth.ValidateScopes(scopes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidScopes, err)
+	}
+
+	var owner models.User
+
+	result := s.db.Where("email = ?", strings.TrimSpace(ownerEmail)).First(&owner)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, result.Error
+	}
+
+	// admin covers every other scope, /admin included
+	if slices.Contains(scopes, string(auth.ScopeAdmin)) && !owner.IsAdmin {
+		return nil, ErrAdminScope
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	modelKey := &models.APIKey{
+		UserID:     owner.ID,
+		User:       owner,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hash,
+		Scopes:     apikeyapp.JoinScopes(scopes),
+		ExpiresAt:  expiresAt,
+	}
+
+	if result := s.db.Omit("User").Create(modelKey); result.Error != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", result.Error)
+	}
+
+	return &apikeyapp.CreatedAPIKey{
+		APIKey: apikeyapp.ToAppAPIKey(*modelKey),
+		Key:    key,
+	}, nil
+}
+
+// ListAPIKeys lists the keys of one owner, or every key when ownerEmail is
+// empty.
+func (s *APIKeyService) ListAPIKeys(ownerEmail string) ([]apikeyapp.APIKey, error) {
+	var modelKeys []models.APIKey
+
+	query := s.db.Preload("User").Order("created_at DESC")
+
+	if ownerEmail != "" {
+		owners := s.db.Model(&models.User{}).Select("id").Where("email = ?", ownerEmail)
+		query = query.Where("user_id IN (?)", owners)
+	}
+
+	if result := query.Find(&modelKeys); result.Error != nil {
+		return nil, result.Error
+	}
+
+	keys := make([]apikeyapp.APIKey, 0, len(modelKeys))
+	for _, mk := range modelKeys {
+		keys = append(keys, apikeyapp.ToAppAPIKey(mk))
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(stringID string) error {
+	id, err := uuid.Parse(stringID)
+	if err != nil {
+		return ErrInvalidId
+	}
+
+	result := s.db.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// RotateAPIKey replaces the secret of a key, keeping its name, owner, scopes
+// and expiry. The previous secret stops working immediately.
+func (s *APIKeyService) RotateAPIKey(stringID string) (*apikeyapp.CreatedAPIKey, error) {
+	id, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var created *apikeyapp.CreatedAPIKey
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var modelKey models.APIKey
+
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("User").
+			Where("id = ?", id).
+			First(&modelKey)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrAPIKeyNotFound
+			}
+
+			return result.Error
+		}
+
+		if modelKey.RevokedAt != nil {
+			return ErrAPIKeyRevoked
+		}
+
+		key, prefix, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate API key: %w", err)
+		}
+
+		result = tx.Model(&modelKey).Updates(map[string]any{
+			"prefix":       prefix,
+			"secret_hash":  hash,
+			"last_used_at": nil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		modelKey.Prefix = prefix
+		modelKey.LastUsedAt = nil
+
+		created = &apikeyapp.CreatedAPIKey{
+			APIKey: apikeyapp.ToAppAPIKey(modelKey),
+			Key:    key,
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Authenticate resolves an API key and records its use, successful or not.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*apikeyapp.APIKey, error) {
+	modelKey, err := s.authenticate(ctx, key)
+
+	entry := audit.Entry{
+		Action:     audit.ActionAPIKeyUse,
+		TargetType: audit.TargetAPIKey,
+	}
+
+	if modelKey != nil {
+		entry.TargetID = modelKey.ID.String()
+		entry.Actor = &audit.Actor{Type: audit.ActorAPIKey, UserID: &modelKey.UserID, APIKeyID: &modelKey.ID}
+	}
+
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Metadata = map[string]any{"reason": err.Error()}
+		s.auditor.Record(ctx, entry)
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, entry)
+
+	// A key can't do more than its owner may now, so an admin's keys lose the
+	// admin scope when they are demoted, as their refresh tokens do
+	allowed := auth.ScopesForUser(modelKey.User.IsAdmin)
+
+	apiKey := apikeyapp.ToAppAPIKey(*modelKey)
+	apiKey.Scopes = slices.DeleteFunc(apiKey.Scopes, func(scope string) bool {
+		return !slices.Contains(allowed, scope)
+	})
+
+	return &apiKey, nil
+}
+
+// authenticate checks a key. The key is returned along with the error when it
+// exists but can't be used.
+func (s *APIKeyService) authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	prefix, err := auth.ParseAPIKeyPrefix(key)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var modelKey models.APIKey
+
+	result := s.db.WithContext(ctx).Preload("User").Where("prefix = ?", prefix).First(&modelKey)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+
+		return nil, result.Error
+	}
+
+	if !auth.CompareAPIKey(key, modelKey.SecretHash) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+
+	if modelKey.RevokedAt != nil {
+		return &modelKey, ErrAPIKeyRevoked
+	}
+
+	if modelKey.ExpiresAt != nil && now.After(*modelKey.ExpiresAt) {
+		return &modelKey, ErrAPIKeyExpired
+	}
+
+	if result := s.db.WithContext(ctx).Model(&modelKey).UpdateColumn("last_used_at", now); result.Error != nil {
+		return nil, result.Error
+	}
+
+	modelKey.LastUsedAt = &now
+
+	return &modelKey, nil
+}
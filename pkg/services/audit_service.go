@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"share-docs/pkg/app/domain/auditapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/logger"
+	"share-docs/pkg/util"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditChainLock is the advisory lock key serialising appends to the chain.
+const auditChainLock = 7_404_417_001
+
+const auditBatchSize = 500
+
+type AuditServiceInterface interface {
+	audit.Recorder
+	ListEvents(filter auditapp.Filter, page, limit int) ([]auditapp.Event, int64, error)
+	ExportEvents(ctx context.Context, filter auditapp.Filter, format string, w io.Writer) error
+	VerifyChain(ctx context.Context) (*auditapp.Verification, error)
+}
+
+type AuditService struct {
+	db  *gorm.DB
+	log *logger.Logger
+
+	// Events waiting to be appended, and whether a Record call is appending
+	// a batch right now
+	mu        sync.Mutex
+	appended  *sync.Cond
+	pending   []*pendingAuditEvent
+	appending bool
+}
+
+type pendingAuditEvent struct {
+	event *models.AuditEvent
+	done  bool
+}
+
+func NewAuditService(db *gorm.DB, log *logger.Logger) *AuditService {
+	s := &AuditService{db: db, log: log}
+	s.appended = sync.NewCond(&s.mu)
+	return s
+}
+
+// Record appends an entry to the audit log, filling in the actor and request
+// from ctx. It runs in its own transaction so failed actions are recorded
+// too, and survives the request being cancelled. Every event is chained;
+// concurrent calls share one transaction and one take of the chain lock, and
+// each returns once its own event is stored.
+func (s *AuditService) Record(ctx context.Context, entry audit.Entry) {
+	actor := audit.ActorFrom(ctx)
+	request := audit.RequestFrom(ctx)
+
+	event := &models.AuditEvent{
+		ID:         uuid.New(),
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+		Action:     entry.Action,
+		Outcome:    entry.Outcome,
+		ActorType:  actor.Type,
+		ActorID:    actor.UserID,
+		APIKeyID:   actor.APIKeyID,
+		TargetType: emptyToNil(entry.TargetType),
+		TargetID:   emptyToNil(entry.TargetID),
+		IPAddress:  emptyToNil(util.Truncate(request.IP, 64)),
+		UserAgent:  emptyToNil(util.Truncate(request.UserAgent, 512)),
+		RequestID:  emptyToNil(request.ID),
+	}
+
+	if event.Outcome == "" {
+		event.Outcome = audit.OutcomeSuccess
+	}
+
+	if entry.Actor != nil && actor.Type == audit.ActorAnonymous {
+		event.ActorType = entry.Actor.Type
+		event.ActorID = entry.Actor.UserID
+		event.APIKeyID = entry.Actor.APIKeyID
+	}
+
+	if len(entry.Changes) > 0 {
+		event.Changes = s.encode(entry.Changes)
+	}
+
+	if len(entry.Metadata) > 0 {
+		event.Metadata = s.encode(entry.Metadata)
+	}
+
+	s.append(s.db.WithContext(context.WithoutCancel(ctx)), event)
+}
+
+// append queues the event and waits until it is stored. Whichever waiting
+// call finds no batch in progress appends everything queued so far.
+func (s *AuditService) append(db *gorm.DB, event *models.AuditEvent) {
+	p := &pendingAuditEvent{event: event}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, p)
+
+	for !p.done {
+		if s.appending {
+			s.appended.Wait()
+			continue
+		}
+
+		batch := s.pending
+		s.pending = nil
+		s.appending = true
+		s.mu.Unlock()
+
+		s.appendBatch(db, batch)
+
+		s.mu.Lock()
+		s.appending = false
+		for _, b := range batch {
+			b.done = true
+		}
+		s.appended.Broadcast()
+	}
+}
+
+// appendBatch chains and stores a batch of events under the chain lock.
+func (s *AuditService) appendBatch(db *gorm.DB, batch []*pendingAuditEvent) {
+	events := make([]*models.AuditEvent, 0, len(batch))
+	for _, p := range batch {
+		events = append(events, p.event)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock); result.Error != nil {
+			return result.Error
+		}
+
+		var last models.AuditEvent
+		if result := tx.Order("seq DESC").Limit(1).Find(&last); result.Error != nil {
+			return result.Error
+		}
+
+		seq, prevHash := last.Seq, last.Hash
+		for _, event := range events {
+			seq++
+			event.Seq = seq
+			event.PrevHash = prevHash
+			event.Hash = event.ComputeHash()
+			prevHash = event.Hash
+		}
+
+		return tx.CreateInBatches(events, auditBatchSize).Error
+	})
+
+	if err != nil {
+		for _, event := range events {
+			s.log.WithError(err).WithFields(map[string]interface{}{
+				"action":     event.Action,
+				"request_id": stringValue(event.RequestID),
+			}).Error("Failed to record audit event")
+		}
+	}
+}
+
+func (s *AuditService) encode(v any) *string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	encoded := string(data)
+	return models.CanonicalJSON(&encoded)
+}
+
+func (s *AuditService) ListEvents(filter auditapp.Filter, page, limit int) ([]auditapp.Event, int64, error) {
+	query := s.filtered(s.db, filter)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelEvents []models.AuditEvent
+
+	result := query.Order("seq DESC").Offset((page - 1) * limit).Limit(limit).Find(&modelEvents)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	events := make([]auditapp.Event, 0, len(modelEvents))
+	for _, me := range modelEvents {
+		events = append(events, auditapp.ToAppEvent(me))
+	}
+
+	return events, total, nil
+}
+
+// ExportEvents writes every event matching the filter, oldest first, as CSV
+// or as a JSON array. Events are read in batches so large exports don't sit
+// in memory.
+func (s *AuditService) ExportEvents(ctx context.Context, filter auditapp.Filter, format string, w io.Writer) error {
+	var csvWriter *csv.Writer
+
+	switch format {
+	case auditapp.FormatCSV:
+		csvWriter = csv.NewWriter(w)
+		csvWriter.Write([]string{
+			"seq", "id", "created_at", "action", "outcome", "actor_type", "actor_id", "api_key_id",
+			"target_type", "target_id", "ip_address", "user_agent", "request_id", "changes", "metadata", "hash",
+		})
+	case auditapp.FormatJSON:
+		io.WriteString(w, "[")
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	first := true
+	var afterSeq int64
+
+	for {
+		var batch []models.AuditEvent
+
+		result := s.filtered(s.db.WithContext(ctx), filter).
+			Where("seq > ?", afterSeq).
+			Order("seq").
+			Limit(auditBatchSize).
+			Find(&batch)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, me := range batch {
+			if csvWriter != nil {
+				csvWriter.Write([]string{
+					strconv.FormatInt(me.Seq, 10), me.ID.String(), me.CreatedAt.UTC().Format(time.RFC3339Nano),
+					me.Action, me.Outcome, me.ActorType, uuidString(me.ActorID), uuidString(me.APIKeyID),
+					csvSafe(stringValue(me.TargetType)), csvSafe(stringValue(me.TargetID)), stringValue(me.IPAddress),
+					csvSafe(stringValue(me.UserAgent)), stringValue(me.RequestID),
+					csvSafe(stringValue(me.Changes)), csvSafe(stringValue(me.Metadata)), me.Hash,
+				})
+				continue
+			}
+
+			data, err := json.Marshal(auditapp.ToAppEvent(me))
+			if err != nil {
+				return err
+			}
+
+			if !first {
+				io.WriteString(w, ",")
+			}
+			first = false
+			w.Write(data)
+		}
+
+		if len(batch) < auditBatchSize {
+			break
+		}
+		afterSeq = batch[len(batch)-1].Seq
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// VerifyChain walks the whole log and checks that sequence numbers have no
+// gaps, that every row links to the previous hash and that every hash
+// matches the row's content.
+func (s *AuditService) VerifyChain(ctx context.Context) (*auditapp.Verification, error) {
+	verification := &auditapp.Verification{Valid: true}
+
+	prevHash := ""
+	var prevSeq int64
+
+	for {
+		var batch []models.AuditEvent
+
+		result := s.db.WithContext(ctx).Where("seq > ?", prevSeq).Order("seq").Limit(auditBatchSize).Find(&batch)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		for _, me := range batch {
+			var problem string
+
+			switch {
+			case me.Seq != prevSeq+1:
+				problem = fmt.Sprintf("events %d to %d are missing", prevSeq+1, me.Seq-1)
+			case me.PrevHash != prevHash:
+				problem = "previous hash does not match the previous event"
+			case me.Hash != me.ComputeHash():
+				problem = "hash does not match the event's content"
+			}
+
+			if problem != "" {
+				seq := me.Seq
+				verification.Valid = false
+				verification.BrokenAtSeq = &seq
+				verification.Problem = problem
+				return verification, nil
+			}
+
+			verification.Checked++
+			prevSeq, prevHash = me.Seq, me.Hash
+		}
+
+		if len(batch) < auditBatchSize {
+			break
+		}
+	}
+
+	verification.LastHash = prevHash
+	return verification, nil
+}
+
+func (s *AuditService) filtered(db *gorm.DB, filter auditapp.Filter) *gorm.DB {
+	query := db.Model(&models.AuditEvent{})
+
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	return query
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
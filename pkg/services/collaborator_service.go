@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCollaboratorNotFound  = errors.New("collaborator not found")
+	ErrCollaboratorExists    = errors.New("document is already shared with this email")
+	ErrInvalidPermissionRole = errors.New("invalid collaborator role")
+	ErrCannotShareWithOwner  = errors.New("document cannot be shared with its owner")
+	ErrDocumentNotManageable = errors.New("only the document owner can manage collaborators")
+)
+
+type CollaboratorServiceInterface interface {
+	ListCollaborators(ctx context.Context, userID uuid.UUID, documentID string) ([]documentapp.Collaborator, error)
+	AddCollaborator(ctx context.Context, userID uuid.UUID, documentID string, email string, role documentapp.PermissionRole) (*documentapp.Collaborator, error)
+	UpdateCollaborator(ctx context.Context, userID uuid.UUID, documentID string, email string, role documentapp.PermissionRole) (*documentapp.Collaborator, error)
+	RemoveCollaborator(ctx context.Context, userID uuid.UUID, documentID string, email string) error
+	AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*documentapp.Document, error)
+}
+
+type CollaboratorService struct {
+	db      *gorm.DB
+	auditor audit.Recorder
+	baseURL string
+}
+
+func NewCollaboratorService(db *gorm.DB, auditor audit.Recorder, baseURL string) *CollaboratorService {
+	return &CollaboratorService{
+		db:      db,
+		auditor: auditor,
+		baseURL: baseURL,
+	}
+}
+
+func (s *CollaboratorService) ListCollaborators(ctx context.Context, userID uuid.UUID, documentID string) ([]documentapp.Collaborator, error) {
+	document, err := s.manageableDocument(ctx, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions []models.DocumentPermission
+
+	result := s.db.WithContext(ctx).Preload("User").Where("document_id = ?", document.ID).Order("created_at").Find(&permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	collaborators := make([]documentapp.Collaborator, 0, len(permissions))
+	for _, mp := range permissions {
+		collaborators = append(collaborators, documentapp.ToAppCollaborator(mp))
+	}
+
+	return collaborators, nil
+}
+
+// AddCollaborator shares a document with an email address. People with an
+// account get access straight away; everyone else gets an invitation to
+// accept once they have signed up.
+func (s *CollaboratorService) AddCollaborator(ctx context.Context, userID uuid.UUID, documentID string, email string, role documentapp.PermissionRole) (*documentapp.Collaborator, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidPermissionRole
+	}
+
+	document, err := s.manageableDocument(ctx, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if strings.EqualFold(document.User.Email, email) {
+		return nil, ErrCannotShareWithOwner
+	}
+
+	var existing int64
+	s.db.WithContext(ctx).Model(&models.DocumentPermission{}).Where("document_id = ? AND email = ?", document.ID, email).Count(&existing)
+	if existing > 0 {
+		return nil, ErrCollaboratorExists
+	}
+
+	permission := &models.DocumentPermission{
+		DocumentID:  document.ID,
+		InvitedByID: userID,
+		Email:       email,
+		Role:        string(role),
+	}
+
+	var invitee models.User
+	result := s.db.WithContext(ctx).Where("LOWER(email) = ?", email).First(&invitee)
+
+	var token string
+	switch {
+	case result.Error == nil:
+		permission.UserID = &invitee.ID
+		permission.User = &invitee
+	case result.Error == gorm.ErrRecordNotFound:
+		token, err = randomToken()
+		if err != nil {
+			return nil, err
+		}
+
+		tokenHash := hashToken(token)
+		expiresAt := time.Now().Add(invitationExpiration)
+		permission.TokenHash = &tokenHash
+		permission.ExpiresAt = &expiresAt
+	default:
+		return nil, result.Error
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Omit("User").Create(permission); result.Error != nil {
+			return fmt.Errorf("failed to share document: %w", result.Error)
+		}
+
+		return jobs.EnqueueEmail(tx, s.shareMessage(document, permission, token))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionPermissionGrant,
+		TargetType: audit.TargetDocument,
+		TargetID:   document.ID.String(),
+		Metadata:   map[string]any{"email": email, "role": role},
+	})
+
+	c := documentapp.ToAppCollaborator(*permission)
+	return &c, nil
+}
+
+func (s *CollaboratorService) UpdateCollaborator(ctx context.Context, userID uuid.UUID, documentID string, email string, role documentapp.PermissionRole) (*documentapp.Collaborator, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidPermissionRole
+	}
+
+	document, err := s.manageableDocument(ctx, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var permission models.DocumentPermission
+
+	result := s.db.WithContext(ctx).Preload("User").
+		Where("document_id = ? AND email = ?", document.ID, strings.ToLower(strings.TrimSpace(email))).
+		First(&permission)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrCollaboratorNotFound
+		}
+		return nil, result.Error
+	}
+
+	before := permission.Role
+
+	if result := s.db.WithContext(ctx).Model(&permission).Update("role", string(role)); result.Error != nil {
+		return nil, result.Error
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionPermissionUpdate,
+		TargetType: audit.TargetDocument,
+		TargetID:   document.ID.String(),
+		Changes:    map[string]audit.Change{"role": {Before: before, After: string(role)}},
+		Metadata:   map[string]any{"email": permission.Email},
+	})
+
+	c := documentapp.ToAppCollaborator(permission)
+	return &c, nil
+}
+
+// RemoveCollaborator revokes access, or a pending invitation, for an email.
+func (s *CollaboratorService) RemoveCollaborator(ctx context.Context, userID uuid.UUID, documentID string, email string) error {
+	document, err := s.manageableDocument(ctx, userID, documentID)
+	if err != nil {
+		return err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	result := s.db.WithContext(ctx).Where("document_id = ? AND email = ?", document.ID, email).
+		Delete(&models.DocumentPermission{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrCollaboratorNotFound
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionPermissionRevoke,
+		TargetType: audit.TargetDocument,
+		TargetID:   document.ID.String(),
+		Metadata:   map[string]any{"email": email},
+	})
+
+	return nil
+}
+
+// AcceptInvitation binds a pending invitation to the user. The user's email
+// has to match the invited one.
+func (s *CollaboratorService) AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*documentapp.Document, error) {
+	var permission models.DocumentPermission
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND user_id IS NULL", hashToken(token)).
+			First(&permission)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrInvitationNotFound
+			}
+			return result.Error
+		}
+
+		if permission.ExpiresAt != nil && time.Now().After(*permission.ExpiresAt) {
+			return ErrInvitationExpired
+		}
+
+		var user models.User
+		if result := tx.First(&user, userID); result.Error != nil {
+			return result.Error
+		}
+
+		if !strings.EqualFold(user.Email, permission.Email) {
+			return ErrInvitationEmailMismatch
+		}
+
+		return tx.Model(&permission).Updates(map[string]any{
+			"user_id":    userID,
+			"token_hash": nil,
+			"expires_at": nil,
+		}).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var document models.Document
+	if result := s.db.WithContext(ctx).Preload("User").First(&document, permission.DocumentID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, result.Error
+	}
+
+	d := documentapp.ToAppDocument(document)
+	return &d, nil
+}
+
+// manageableDocument loads a document whose collaborators userID may manage:
+// their own personal documents, or an organisation's as at least an admin.
+// Readers who may not manage get ErrDocumentNotManageable, everyone else
+// ErrDocumentNotFound.
+func (s *CollaboratorService) manageableDocument(ctx context.Context, userID uuid.UUID, stringID string) (*models.Document, error) {
+	documentID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var document models.Document
+
+	result := s.db.WithContext(ctx).Preload("User").Where(readableDocuments(s.db, userID)).First(&document, documentID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, result.Error
+	}
+
+	var count int64
+	s.db.WithContext(ctx).Model(&models.Document{}).
+		Where("documents.id = ?", documentID).
+		Where(accessibleBy(s.db, "documents", userID, orgapp.RoleAdmin)).
+		Count(&count)
+
+	if count == 0 {
+		return nil, ErrDocumentNotManageable
+	}
+
+	return &document, nil
+}
+
+func (s *CollaboratorService) shareMessage(document *models.Document, permission *models.DocumentPermission, token string) mail.Message {
+	name := document.OriginalFilename
+	if document.Title != nil {
+		name = *document.Title
+	}
+
+	if token == "" {
+		return mail.Message{
+			To:      []string{permission.Email},
+			Subject: fmt.Sprintf("%s was shared with you on share-docs", name),
+			Body: fmt.Sprintf(
+				"You can now access %s as %s.\n\nOpen it: %s/docs/%s\n",
+				name, permission.Role, s.baseURL, document.ID,
+			),
+		}
+	}
+
+	return mail.Message{
+		To:      []string{permission.Email},
+		Subject: fmt.Sprintf("%s was shared with you on share-docs", name),
+		Body: fmt.Sprintf(
+			"You have been invited to access %s as %s.\n\nCreate an account with this email address, then accept the invitation: %s/docs/invitations/accept?token=%s\n\nThe invitation expires on %s.\n",
+			name, permission.Role, s.baseURL, token, permission.ExpiresAt.Format(time.RFC1123),
+		),
+	}
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/app/domain/roomapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/mail"
+	"share-docs/pkg/util"
+	"share-docs/pkg/watermark"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoomBranding returns what anyone with the room's link sees before signing
+// in.
+func (s *DataRoomService) RoomBranding(ctx context.Context, token string) (*roomapp.Branding, error) {
+	room, err := s.openRoom(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	b := roomapp.ToAppBranding(*room)
+	return &b, nil
+}
+
+// RequestRoomCode emails a one-time code to a viewer on the room's list.
+// Emails off the list never get a code.
+func (s *DataRoomService) RequestRoomCode(ctx context.Context, token string, email string) error {
+	room, err := s.openRoom(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	var viewer models.DataRoomViewer
+	if result := s.db.WithContext(ctx).Where("data_room_id = ? AND email = ?", room.ID, email).First(&viewer); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return ErrEmailNotAllowed
+		}
+		return result.Error
+	}
+
+	code, err := generateEmailCode()
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the latest code is valid
+		result := tx.Model(&viewer).Updates(map[string]any{
+			"code_hash":       hashRoomCode(room, email, code),
+			"code_expires_at": time.Now().Add(linkEmailCodeExpiration),
+			"code_attempts":   0,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to store room code: %w", result.Error)
+		}
+
+		return jobs.EnqueueEmail(tx, mail.Message{
+			To:      []string{email},
+			Subject: fmt.Sprintf("Your code to open %s", room.Name),
+			Body: fmt.Sprintf(
+				"Your verification code is %s\n\nIt expires in %d minutes.\n",
+				code, int(linkEmailCodeExpiration.Minutes()),
+			),
+		})
+	})
+}
+
+// VerifyRoomViewer signs a viewer in with their one-time code and records the
+// visit.
+func (s *DataRoomService) VerifyRoomViewer(ctx context.Context, token string, req roomapp.VerifyRequest, visitor linkapp.Visitor) (*roomapp.RoomAccess, error) {
+	room, err := s.openRoom(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	var viewer models.DataRoomViewer
+
+	result := s.db.WithContext(ctx).Where("data_room_id = ? AND email = ? AND code_expires_at > ? AND code_attempts < ?",
+		room.ID, email, time.Now(), linkEmailCodeMaxAttempts).
+		First(&viewer)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrLinkEmailCodeInvalid
+		}
+		return nil, result.Error
+	}
+
+	codeHash := hashRoomCode(room, email, req.Code)
+
+	if viewer.CodeHash == nil || *viewer.CodeHash != codeHash {
+		s.db.WithContext(ctx).Model(&viewer).UpdateColumn("code_attempts", gorm.Expr("code_attempts + 1"))
+		return nil, ErrLinkEmailCodeInvalid
+	}
+
+	viewToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	visit := &models.DataRoomVisit{
+		DataRoomID: room.ID,
+		ViewerID:   viewer.ID,
+		TokenHash:  hashToken(viewToken),
+		IPAddress:  anonymizeIP(visitor.IPAddress),
+		UserAgent:  util.Truncate(visitor.UserAgent, 255),
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Clearing the code makes it single use; a concurrent use loses the race
+		result := tx.Model(&models.DataRoomViewer{}).
+			Where("id = ? AND code_hash = ?", viewer.ID, codeHash).
+			Updates(map[string]any{"code_hash": nil, "code_expires_at": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrLinkEmailCodeInvalid
+		}
+
+		if result := tx.Create(visit); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Create(&models.DataRoomEvent{
+			DataRoomID: room.ID,
+			ViewerID:   viewer.ID,
+			VisitID:    visit.ID,
+			Type:       models.DataRoomEventOpened,
+		}).Error
+	})
+
+	if err == ErrLinkEmailCodeInvalid {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to record room visit: %w", err)
+	}
+
+	return &roomapp.RoomAccess{
+		Branding:      roomapp.ToAppBranding(*room),
+		Email:         email,
+		AllowDownload: room.AllowDownload,
+		ViewToken:     viewToken,
+	}, nil
+}
+
+// RoomContents lists the folders and documents the viewer of a visit may
+// see.
+func (s *DataRoomService) RoomContents(ctx context.Context, token string, viewToken string) (*roomapp.Contents, error) {
+	_, visit, err := s.findVisit(ctx, token, viewToken)
+	if err != nil {
+		return nil, err
+	}
+
+	folders, documents, err := s.visibleContent(ctx, visit)
+	if err != nil {
+		return nil, err
+	}
+
+	visibleFolders := make(map[uuid.UUID]bool, len(folders))
+	for _, mf := range folders {
+		visibleFolders[mf.ID] = true
+	}
+
+	contents := &roomapp.Contents{
+		Folders:   make([]roomapp.Folder, 0, len(folders)),
+		Documents: make([]roomapp.RoomDocument, 0, len(documents)),
+	}
+
+	for _, mf := range folders {
+		f := roomapp.Folder{ID: mf.ID.String(), Name: mf.Name}
+		if mf.ParentID != nil && visibleFolders[*mf.ParentID] {
+			parentID := mf.ParentID.String()
+			f.ParentID = &parentID
+		}
+		contents.Folders = append(contents.Folders, f)
+	}
+
+	for _, md := range documents {
+		d := roomapp.ToAppRoomDocument(md)
+		if md.FolderID == nil || !visibleFolders[*md.FolderID] {
+			d.FolderID = nil
+		}
+		contents.Documents = append(contents.Documents, d)
+	}
+
+	return contents, nil
+}
+
+// OpenRoomDocument returns a document the viewer of a visit may see and
+// records that it was viewed or downloaded. Like view-only links, view-only
+// rooms never serve the original: the preview is stamped with the viewer's
+// email, and documents that cannot be stamped are refused.
+func (s *DataRoomService) OpenRoomDocument(ctx context.Context, token string, viewToken string, documentID string, visitor linkapp.Visitor, download bool) (*roomapp.RoomFile, error) {
+	id, err := uuid.Parse(documentID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	room, visit, err := s.findVisit(ctx, token, viewToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if download && !room.AllowDownload {
+		return nil, ErrDownloadNotAllowed
+	}
+
+	_, documents, err := s.visibleContent(ctx, visit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, md := range documents {
+		if md.ID != id {
+			continue
+		}
+
+		file := &roomapp.RoomFile{
+			Path:     md.OriginalFilename,
+			MimeType: md.MimeType,
+		}
+
+		if !room.AllowDownload {
+			if !watermark.Supported(md.MimeType) {
+				return nil, ErrPreviewNotAvailable
+			}
+
+			file.Watermark = fmt.Sprintf("%s  %s  %s", visit.Viewer.Email, visitor.IPAddress, time.Now().UTC().Format(time.RFC3339))
+		}
+
+		event := models.DataRoomEventDocumentViewed
+		if download {
+			event = models.DataRoomEventDocumentDownloaded
+		}
+
+		result := s.db.WithContext(ctx).Create(&models.DataRoomEvent{
+			DataRoomID: room.ID,
+			ViewerID:   visit.ViewerID,
+			VisitID:    visit.ID,
+			Type:       event,
+			DocumentID: &md.ID,
+		})
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to record room activity: %w", result.Error)
+		}
+
+		return file, nil
+	}
+
+	return nil, ErrDocumentNotFound
+}
+
+// visibleContent resolves what the viewer of a visit may see: the room's
+// items, or only their group's, with folders expanded to everything below
+// them.
+func (s *DataRoomService) visibleContent(ctx context.Context, visit *models.DataRoomVisit) ([]models.Folder, []models.Document, error) {
+	query := s.db.WithContext(ctx).Model(&models.DataRoomItem{}).Where("data_room_items.data_room_id = ?", visit.DataRoomID)
+
+	if visit.Viewer.GroupID != nil {
+		query = query.Where("data_room_items.id IN (?)",
+			s.db.Table("data_room_group_items").Select("item_id").Where("group_id = ?", *visit.Viewer.GroupID))
+	}
+
+	var items []models.DataRoomItem
+	if result := query.Find(&items); result.Error != nil {
+		return nil, nil, result.Error
+	}
+
+	var folderIDs, documentIDs []uuid.UUID
+	for _, mi := range items {
+		if mi.FolderID != nil {
+			folderIDs = append(folderIDs, *mi.FolderID)
+		}
+		if mi.DocumentID != nil {
+			documentIDs = append(documentIDs, *mi.DocumentID)
+		}
+	}
+
+	// Expand folders to their whole sub-tree
+	for frontier := folderIDs; len(frontier) > 0; {
+		var children []uuid.UUID
+		if result := s.db.WithContext(ctx).Model(&models.Folder{}).Where("parent_id IN ? AND id NOT IN ?", frontier, folderIDs).Pluck("id", &children); result.Error != nil {
+			return nil, nil, result.Error
+		}
+		folderIDs = append(folderIDs, children...)
+		frontier = children
+	}
+
+	folders := []models.Folder{}
+	if len(folderIDs) > 0 {
+		if result := s.db.WithContext(ctx).Where("id IN ?", folderIDs).Order("name").Find(&folders); result.Error != nil {
+			return nil, nil, result.Error
+		}
+	}
+
+	documents := []models.Document{}
+	if len(folderIDs) > 0 || len(documentIDs) > 0 {
+		result := s.db.WithContext(ctx).Where("id IN ? OR folder_id IN ?", documentIDs, folderIDs).
+			Order("created_at").
+			Find(&documents)
+		if result.Error != nil {
+			return nil, nil, result.Error
+		}
+	}
+
+	return folders, documents, nil
+}
+
+// openRoom resolves a link token to a room whose link is enabled and has not
+// expired.
+func (s *DataRoomService) openRoom(ctx context.Context, token string) (*models.DataRoom, error) {
+	var room models.DataRoom
+
+	result := s.db.WithContext(ctx).Where("token_hash = ?", hashToken(token)).First(&room)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDataRoomNotFound
+		}
+		return nil, result.Error
+	}
+
+	if !room.IsOpen(time.Now()) {
+		return nil, ErrDataRoomClosed
+	}
+
+	return &room, nil
+}
+
+// findVisit resolves a view token handed out by VerifyRoomViewer. Visits of
+// viewers taken off the list stop working.
+func (s *DataRoomService) findVisit(ctx context.Context, token string, viewToken string) (*models.DataRoom, *models.DataRoomVisit, error) {
+	room, err := s.openRoom(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var visit models.DataRoomVisit
+
+	result := s.db.WithContext(ctx).Preload("Viewer").
+		Where("data_room_id = ? AND token_hash = ? AND created_at > ?", room.ID, hashToken(viewToken), time.Now().Add(-viewTokenExpiration)).
+		First(&visit)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil, ErrInvalidViewToken
+		}
+		return nil, nil, result.Error
+	}
+
+	if visit.Viewer.ID == uuid.Nil {
+		return nil, nil, ErrInvalidViewToken
+	}
+
+	return room, &visit, nil
+}
+
+// hashRoomCode binds a code to its room and email, like hashEmailCode does
+// for links.
+func hashRoomCode(room *models.DataRoom, email string, code string) string {
+	return hashToken(room.ID.String() + "|" + email + "|" + strings.TrimSpace(code))
+}
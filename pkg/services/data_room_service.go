@@ -0,0 +1,739 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/app/domain/roomapp"
+	"share-docs/pkg/db/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDataRoomNotFound       = errors.New("data room not found")
+	ErrDataRoomClosed         = errors.New("data room link is disabled or has expired")
+	ErrDataRoomItemNotFound   = errors.New("data room item not found")
+	ErrDataRoomItemExists     = errors.New("item is already in the data room")
+	ErrInvalidDataRoomItem    = errors.New("an item is either a folder or a document")
+	ErrDataRoomGroupNotFound  = errors.New("data room group not found")
+	ErrDataRoomViewerNotFound = errors.New("data room viewer not found")
+)
+
+type DataRoomServiceInterface interface {
+	CreateDataRoom(ctx context.Context, userID uuid.UUID, req roomapp.CreateDataRoom) (*roomapp.LinkedDataRoom, error)
+	ListDataRooms(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) ([]roomapp.DataRoom, error)
+	GetDataRoom(ctx context.Context, userID uuid.UUID, roomID string) (*roomapp.DataRoom, error)
+	UpdateDataRoom(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.UpdateDataRoom) (*roomapp.DataRoom, error)
+	DeleteDataRoom(ctx context.Context, userID uuid.UUID, roomID string) error
+	RotateLink(ctx context.Context, userID uuid.UUID, roomID string) (*roomapp.LinkedDataRoom, error)
+
+	ListItems(ctx context.Context, userID uuid.UUID, roomID string) ([]roomapp.Item, error)
+	AddItem(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.AddItem) (*roomapp.Item, error)
+	RemoveItem(ctx context.Context, userID uuid.UUID, roomID string, itemID string) error
+
+	ListGroups(ctx context.Context, userID uuid.UUID, roomID string) ([]roomapp.Group, error)
+	CreateGroup(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.GroupRequest) (*roomapp.Group, error)
+	UpdateGroup(ctx context.Context, userID uuid.UUID, roomID string, groupID string, req roomapp.GroupRequest) (*roomapp.Group, error)
+	DeleteGroup(ctx context.Context, userID uuid.UUID, roomID string, groupID string) error
+
+	ListViewers(ctx context.Context, userID uuid.UUID, roomID string) ([]roomapp.Viewer, error)
+	AddViewers(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.AddViewers) ([]roomapp.Viewer, error)
+	UpdateViewer(ctx context.Context, userID uuid.UUID, roomID string, viewerID string, req roomapp.UpdateViewer) (*roomapp.Viewer, error)
+	RemoveViewer(ctx context.Context, userID uuid.UUID, roomID string, viewerID string) error
+
+	ListActivity(ctx context.Context, userID uuid.UUID, roomID string, page, limit int) ([]roomapp.Event, int64, error)
+	RoomStats(ctx context.Context, userID uuid.UUID, roomID string) (*roomapp.Stats, error)
+
+	RoomBranding(ctx context.Context, token string) (*roomapp.Branding, error)
+	RequestRoomCode(ctx context.Context, token string, email string) error
+	VerifyRoomViewer(ctx context.Context, token string, req roomapp.VerifyRequest, visitor linkapp.Visitor) (*roomapp.RoomAccess, error)
+	RoomContents(ctx context.Context, token string, viewToken string) (*roomapp.Contents, error)
+	OpenRoomDocument(ctx context.Context, token string, viewToken string, documentID string, visitor linkapp.Visitor, download bool) (*roomapp.RoomFile, error)
+}
+
+type DataRoomService struct {
+	db      *gorm.DB
+	baseURL string
+}
+
+func NewDataRoomService(db *gorm.DB, baseURL string) *DataRoomService {
+	return &DataRoomService{
+		db:      db,
+		baseURL: baseURL,
+	}
+}
+
+// CreateDataRoom creates an empty room in the user's personal space or in an
+// organisation. The link token is only returned here and by RotateLink.
+func (s *DataRoomService) CreateDataRoom(ctx context.Context, userID uuid.UUID, req roomapp.CreateDataRoom) (*roomapp.LinkedDataRoom, error) {
+	room := &models.DataRoom{
+		UserID:         userID,
+		Name:           strings.TrimSpace(req.Name),
+		Description:    req.Description,
+		LogoURL:        req.LogoURL,
+		BrandColor:     req.BrandColor,
+		WelcomeMessage: req.WelcomeMessage,
+		LinkEnabled:    true,
+		AllowDownload:  req.AllowDownload == nil || *req.AllowDownload,
+		ExpiresAt:      req.ExpiresAt,
+	}
+
+	if req.OrganizationID != nil {
+		orgID, err := uuid.Parse(*req.OrganizationID)
+		if err != nil {
+			return nil, ErrInvalidId
+		}
+
+		var count int64
+		s.db.WithContext(ctx).Model(&models.OrganizationMembership{}).
+			Where("organization_id = ? AND user_id = ? AND role IN ?", orgID, userID, orgapp.RolesAtLeast(orgapp.RoleMember)).
+			Count(&count)
+
+		if count == 0 {
+			return nil, ErrOrganizationNotFound
+		}
+
+		room.OrganizationID = &orgID
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	room.TokenHash = hashToken(token)
+
+	if result := s.db.WithContext(ctx).Create(room); result.Error != nil {
+		return nil, fmt.Errorf("failed to create data room: %w", result.Error)
+	}
+
+	return s.linked(room, token), nil
+}
+
+// ListDataRooms lists the rooms the user can manage, or only those of an
+// organisation.
+func (s *DataRoomService) ListDataRooms(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) ([]roomapp.DataRoom, error) {
+	query := s.db.WithContext(ctx).Where(accessibleBy(s.db, "data_rooms", userID, orgapp.RoleMember))
+
+	if orgID != nil {
+		query = query.Where("data_rooms.organization_id = ?", *orgID)
+	}
+
+	var modelRooms []models.DataRoom
+	if result := query.Order("data_rooms.created_at DESC").Find(&modelRooms); result.Error != nil {
+		return nil, result.Error
+	}
+
+	rooms := make([]roomapp.DataRoom, 0, len(modelRooms))
+	for _, mr := range modelRooms {
+		rooms = append(rooms, roomapp.ToAppDataRoom(mr))
+	}
+
+	return rooms, nil
+}
+
+func (s *DataRoomService) GetDataRoom(ctx context.Context, userID uuid.UUID, roomID string) (*roomapp.DataRoom, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := roomapp.ToAppDataRoom(*room)
+	return &r, nil
+}
+
+func (s *DataRoomService) UpdateDataRoom(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.UpdateDataRoom) (*roomapp.DataRoom, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+
+	if req.Name != nil {
+		room.Name = strings.TrimSpace(*req.Name)
+		updates["name"] = room.Name
+	}
+
+	if req.Description != nil {
+		room.Description = emptyToNil(*req.Description)
+		updates["description"] = room.Description
+	}
+
+	if req.LogoURL != nil {
+		room.LogoURL = emptyToNil(*req.LogoURL)
+		updates["logo_url"] = room.LogoURL
+	}
+
+	if req.BrandColor != nil {
+		room.BrandColor = emptyToNil(*req.BrandColor)
+		updates["brand_color"] = room.BrandColor
+	}
+
+	if req.WelcomeMessage != nil {
+		room.WelcomeMessage = emptyToNil(*req.WelcomeMessage)
+		updates["welcome_message"] = room.WelcomeMessage
+	}
+
+	if req.LinkEnabled != nil {
+		room.LinkEnabled = *req.LinkEnabled
+		updates["link_enabled"] = room.LinkEnabled
+	}
+
+	if req.AllowDownload != nil {
+		room.AllowDownload = *req.AllowDownload
+		updates["allow_download"] = room.AllowDownload
+	}
+
+	if req.RemoveExpiry {
+		room.ExpiresAt = nil
+		updates["expires_at"] = nil
+	} else if req.ExpiresAt != nil {
+		room.ExpiresAt = req.ExpiresAt
+		updates["expires_at"] = *req.ExpiresAt
+	}
+
+	if result := s.db.WithContext(ctx).Model(room).Updates(updates); result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	r := roomapp.ToAppDataRoom(*room)
+	return &r, nil
+}
+
+func (s *DataRoomService) DeleteDataRoom(ctx context.Context, userID uuid.UUID, roomID string) error {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Delete(room).Error
+}
+
+// RotateLink replaces the room's link. The old link stops working, and so do
+// the visits opened through it.
+func (s *DataRoomService) RotateLink(ctx context.Context, userID uuid.UUID, roomID string) (*roomapp.LinkedDataRoom, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	room.TokenHash = hashToken(token)
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(room).Update("token_hash", room.TokenHash); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Where("data_room_id = ?", room.ID).Delete(&models.DataRoomVisit{}).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.linked(room, token), nil
+}
+
+func (s *DataRoomService) ListItems(ctx context.Context, userID uuid.UUID, roomID string) ([]roomapp.Item, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelItems []models.DataRoomItem
+
+	result := s.db.WithContext(ctx).Preload("Folder").Preload("Document").
+		Where("data_room_id = ?", room.ID).
+		Order("created_at").
+		Find(&modelItems)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	items := make([]roomapp.Item, 0, len(modelItems))
+	for _, mi := range modelItems {
+		items = append(items, roomapp.ToAppItem(mi))
+	}
+
+	return items, nil
+}
+
+// AddItem puts a folder, with everything below it, or a document in a room.
+// Documents have to be editable by the user, as with share links.
+func (s *DataRoomService) AddItem(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.AddItem) (*roomapp.Item, error) {
+	if (req.FolderID == nil) == (req.DocumentID == nil) {
+		return nil, ErrInvalidDataRoomItem
+	}
+
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &models.DataRoomItem{DataRoomID: room.ID}
+
+	if req.FolderID != nil {
+		folderID, err := uuid.Parse(*req.FolderID)
+		if err != nil {
+			return nil, ErrInvalidId
+		}
+
+		var folder models.Folder
+
+		result := s.db.WithContext(ctx).Where(accessibleBy(s.db, "folders", userID, orgapp.RoleMember)).First(&folder, folderID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, ErrFolderNotFound
+			}
+			return nil, result.Error
+		}
+
+		item.FolderID = &folder.ID
+		item.Folder = &folder
+	} else {
+		document, err := findEditableDocument(s.db, userID, *req.DocumentID)
+		if err != nil {
+			return nil, err
+		}
+
+		item.DocumentID = &document.ID
+		item.Document = document
+	}
+
+	var existing int64
+	s.db.WithContext(ctx).Model(&models.DataRoomItem{}).
+		Where("data_room_id = ? AND (folder_id = ? OR document_id = ?)", room.ID, item.FolderID, item.DocumentID).
+		Count(&existing)
+	if existing > 0 {
+		return nil, ErrDataRoomItemExists
+	}
+
+	if result := s.db.WithContext(ctx).Omit("Folder", "Document").Create(item); result.Error != nil {
+		return nil, fmt.Errorf("failed to add data room item: %w", result.Error)
+	}
+
+	i := roomapp.ToAppItem(*item)
+	return &i, nil
+}
+
+// RemoveItem takes an item out of the room and out of every group.
+func (s *DataRoomService) RemoveItem(ctx context.Context, userID uuid.UUID, roomID string, itemID string) error {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.Parse(itemID)
+	if err != nil {
+		return ErrInvalidId
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("data_room_id = ?", room.ID).Delete(&models.DataRoomItem{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrDataRoomItemNotFound
+		}
+
+		return tx.Table("data_room_group_items").Where("item_id = ?", id).Delete(nil).Error
+	})
+}
+
+func (s *DataRoomService) ListGroups(ctx context.Context, userID uuid.UUID, roomID string) ([]roomapp.Group, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelGroups []models.DataRoomGroup
+
+	result := s.db.WithContext(ctx).Preload("Items").Where("data_room_id = ?", room.ID).Order("name").Find(&modelGroups)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	groups := make([]roomapp.Group, 0, len(modelGroups))
+	for _, mg := range modelGroups {
+		groups = append(groups, roomapp.ToAppGroup(mg))
+	}
+
+	return groups, nil
+}
+
+// CreateGroup creates a viewer group that sees the given items only.
+func (s *DataRoomService) CreateGroup(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.GroupRequest) (*roomapp.Group, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.roomItems(ctx, room.ID, req.ItemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &models.DataRoomGroup{
+		DataRoomID: room.ID,
+		Name:       strings.TrimSpace(req.Name),
+		Items:      items,
+	}
+
+	if result := s.db.WithContext(ctx).Omit("Items.*").Create(group); result.Error != nil {
+		return nil, fmt.Errorf("failed to create data room group: %w", result.Error)
+	}
+
+	g := roomapp.ToAppGroup(*group)
+	return &g, nil
+}
+
+// UpdateGroup renames a group and replaces the items it sees.
+func (s *DataRoomService) UpdateGroup(ctx context.Context, userID uuid.UUID, roomID string, groupID string, req roomapp.GroupRequest) (*roomapp.Group, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.roomGroup(ctx, room.ID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.roomItems(ctx, room.ID, req.ItemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	group.Name = strings.TrimSpace(req.Name)
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(group).Update("name", group.Name); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Model(group).Omit("Items.*").Association("Items").Replace(items)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	group.Items = items
+
+	g := roomapp.ToAppGroup(*group)
+	return &g, nil
+}
+
+// DeleteGroup deletes a group. Its viewers are left without a group and see
+// the whole room.
+func (s *DataRoomService) DeleteGroup(ctx context.Context, userID uuid.UUID, roomID string, groupID string) error {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return err
+	}
+
+	group, err := s.roomGroup(ctx, room.ID, groupID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(&models.DataRoomViewer{}).Where("group_id = ?", group.ID).Update("group_id", nil); result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Model(group).Association("Items").Clear(); err != nil {
+			return err
+		}
+
+		return tx.Delete(group).Error
+	})
+}
+
+func (s *DataRoomService) ListViewers(ctx context.Context, userID uuid.UUID, roomID string) ([]roomapp.Viewer, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelViewers []models.DataRoomViewer
+
+	result := s.db.WithContext(ctx).Where("data_room_id = ?", room.ID).Order("email").Find(&modelViewers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	viewers := make([]roomapp.Viewer, 0, len(modelViewers))
+	for _, mv := range modelViewers {
+		viewers = append(viewers, roomapp.ToAppViewer(mv))
+	}
+
+	return viewers, nil
+}
+
+// AddViewers puts emails on the room's viewer list. Emails already on it are
+// moved to the requested group.
+func (s *DataRoomService) AddViewers(ctx context.Context, userID uuid.UUID, roomID string, req roomapp.AddViewers) ([]roomapp.Viewer, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupID *uuid.UUID
+	if req.GroupID != nil {
+		group, err := s.roomGroup(ctx, room.ID, *req.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		groupID = &group.ID
+	}
+
+	viewers := make([]roomapp.Viewer, 0, len(req.Emails))
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, email := range req.Emails {
+			email = strings.ToLower(strings.TrimSpace(email))
+
+			viewer := models.DataRoomViewer{DataRoomID: room.ID, Email: email}
+
+			result := tx.Where("data_room_id = ? AND email = ?", room.ID, email).FirstOrCreate(&viewer)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			viewer.GroupID = groupID
+			if result := tx.Model(&viewer).Update("group_id", groupID); result.Error != nil {
+				return result.Error
+			}
+
+			viewers = append(viewers, roomapp.ToAppViewer(viewer))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to add data room viewers: %w", err)
+	}
+
+	return viewers, nil
+}
+
+func (s *DataRoomService) UpdateViewer(ctx context.Context, userID uuid.UUID, roomID string, viewerID string, req roomapp.UpdateViewer) (*roomapp.Viewer, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer, err := s.roomViewer(ctx, room.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewer.GroupID = nil
+	if *req.GroupID != "" {
+		group, err := s.roomGroup(ctx, room.ID, *req.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		viewer.GroupID = &group.ID
+	}
+
+	if result := s.db.WithContext(ctx).Model(viewer).Update("group_id", viewer.GroupID); result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	v := roomapp.ToAppViewer(*viewer)
+	return &v, nil
+}
+
+// RemoveViewer takes a viewer off the list, which also ends their visits.
+func (s *DataRoomService) RemoveViewer(ctx context.Context, userID uuid.UUID, roomID string, viewerID string) error {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return err
+	}
+
+	viewer, err := s.roomViewer(ctx, room.ID, viewerID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Delete(viewer).Error
+}
+
+// ListActivity lists what viewers did in the room, newest first.
+func (s *DataRoomService) ListActivity(ctx context.Context, userID uuid.UUID, roomID string, page, limit int) ([]roomapp.Event, int64, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.DataRoomEvent{}).Where("data_room_id = ?", room.ID)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelEvents []models.DataRoomEvent
+
+	// Activity outlives removed viewers and deleted documents
+	result := query.
+		Preload("Viewer", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Document", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&modelEvents)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	events := make([]roomapp.Event, 0, len(modelEvents))
+	for _, me := range modelEvents {
+		events = append(events, roomapp.ToAppEvent(me))
+	}
+
+	return events, total, nil
+}
+
+func (s *DataRoomService) RoomStats(ctx context.Context, userID uuid.UUID, roomID string) (*roomapp.Stats, error) {
+	room, err := s.manageableRoom(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats roomapp.Stats
+
+	result := s.db.WithContext(ctx).Model(&models.DataRoomEvent{}).
+		Select(`COUNT(*) FILTER (WHERE type = ?) AS visits,
+			COUNT(DISTINCT viewer_id) AS unique_viewers,
+			COUNT(*) FILTER (WHERE type = ?) AS document_views,
+			COUNT(*) FILTER (WHERE type = ?) AS downloads,
+			MAX(created_at) AS last_activity_at`,
+			models.DataRoomEventOpened, models.DataRoomEventDocumentViewed, models.DataRoomEventDocumentDownloaded).
+		Where("data_room_id = ?", room.ID).
+		Scan(&stats)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &stats, nil
+}
+
+// manageableRoom loads a room the user can manage: their own, or their
+// organisations' as at least a member.
+func (s *DataRoomService) manageableRoom(ctx context.Context, userID uuid.UUID, stringID string) (*models.DataRoom, error) {
+	roomID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var room models.DataRoom
+
+	result := s.db.WithContext(ctx).Where(accessibleBy(s.db, "data_rooms", userID, orgapp.RoleMember)).First(&room, roomID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDataRoomNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &room, nil
+}
+
+// roomItems loads items of a room, failing if any of the IDs is not one.
+func (s *DataRoomService) roomItems(ctx context.Context, roomID uuid.UUID, stringIDs []string) ([]models.DataRoomItem, error) {
+	ids := make([]uuid.UUID, 0, len(stringIDs))
+	for _, stringID := range stringIDs {
+		id, err := uuid.Parse(stringID)
+		if err != nil {
+			return nil, ErrInvalidId
+		}
+		ids = append(ids, id)
+	}
+
+	items := []models.DataRoomItem{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	if result := s.db.WithContext(ctx).Where("data_room_id = ? AND id IN ?", roomID, ids).Find(&items); result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(items) != len(ids) {
+		return nil, ErrDataRoomItemNotFound
+	}
+
+	return items, nil
+}
+
+func (s *DataRoomService) roomGroup(ctx context.Context, roomID uuid.UUID, stringID string) (*models.DataRoomGroup, error) {
+	groupID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var group models.DataRoomGroup
+
+	result := s.db.WithContext(ctx).Where("data_room_id = ?", roomID).First(&group, groupID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDataRoomGroupNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &group, nil
+}
+
+func (s *DataRoomService) roomViewer(ctx context.Context, roomID uuid.UUID, stringID string) (*models.DataRoomViewer, error) {
+	viewerID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var viewer models.DataRoomViewer
+
+	result := s.db.WithContext(ctx).Where("data_room_id = ?", roomID).First(&viewer, viewerID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDataRoomViewerNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &viewer, nil
+}
+
+func (s *DataRoomService) linked(room *models.DataRoom, token string) *roomapp.LinkedDataRoom {
+	return &roomapp.LinkedDataRoom{
+		DataRoom: roomapp.ToAppDataRoom(*room),
+		Token:    token,
+		URL:      fmt.Sprintf("%s/rooms/%s", s.baseURL, token),
+	}
+}
+
+func emptyToNil(value string) *string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
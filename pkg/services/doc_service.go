@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/audit"
 	"share-docs/pkg/db/models"
+	"share-docs/pkg/metrics"
 	"share-docs/pkg/storage"
 
 	"github.com/google/uuid"
@@ -14,27 +18,44 @@ import (
 // TODO: do not json serialise documents on the model level, but rather here
 
 type DocumentServiceInterface interface {
-	// TODO: add parameters
-	CreateDocument(userID uuid.UUID, o storage.StorageObject) (*documentapp.Document, error)
-	GetDocument(documentID uuid.UUID) (*documentapp.Document, error)
-	UpdateDocument(documentUpdate documentapp.UpdateDocument) (*documentapp.Document, error)
+	CreateDocument(ctx context.Context, userID uuid.UUID, o storage.StorageObject, placement documentapp.Placement) (*documentapp.Document, error)
+	GetDocument(ctx context.Context, userID uuid.UUID, documentID string) (*documentapp.Document, error)
+	ListDocuments(ctx context.Context, userID uuid.UUID, filter documentapp.ListFilter, page, limit int) ([]documentapp.Document, int64, error)
+	ListSharedDocuments(ctx context.Context, userID uuid.UUID, page, limit int) ([]documentapp.SharedDocument, int64, error)
+	UpdateDocument(ctx context.Context, userID uuid.UUID, documentID string, documentUpdate documentapp.UpdateDocument) (*documentapp.Document, error)
+	DeleteDocument(ctx context.Context, userID uuid.UUID, documentID string) error
 }
 
 var (
-	ErrDocumentNotFound = errors.New("document not found")
+	ErrDocumentNotFound  = errors.New("document not found")
+	ErrFolderNotFound    = errors.New("folder not found")
+	ErrDocumentForbidden = errors.New("not allowed to do this with the document")
 )
 
 type DocumentService struct {
-	db *gorm.DB
+	db      *gorm.DB
+	auditor audit.Recorder
 }
 
-func NewDocumentService(db *gorm.DB) *DocumentService {
+func NewDocumentService(db *gorm.DB, auditor audit.Recorder) *DocumentService {
 	return &DocumentService{
-		db: db,
+		db:      db,
+		auditor: auditor,
 	}
 }
 
-func (s *DocumentService) CreateDocument(userID uuid.UUID, o storage.StorageObject) (*documentapp.Document, error) {
+func (s *DocumentService) CreateDocument(ctx context.Context, userID uuid.UUID, o storage.StorageObject, placement documentapp.Placement) (*documentapp.Document, error) {
+	return s.createDocument(ctx, userID, o, placement, nil, nil)
+}
+
+// createDocument files a stored object as a document of userID. extra, when
+// set, fills in fields other pipelines record, such as who uploaded it.
+// created, when set, runs in the transaction that creates the document.
+func (s *DocumentService) createDocument(ctx context.Context, userID uuid.UUID, o storage.StorageObject, placement documentapp.Placement, extra func(*models.Document), created func(tx *gorm.DB, document *models.Document) error) (*documentapp.Document, error) {
+	if err := s.checkPlacement(ctx, userID, placement); err != nil {
+		return nil, err
+	}
+
 	document := &models.Document{
 		OriginalFilename: o.Name,
 		FilePath:         o.Path,
@@ -43,19 +64,72 @@ func (s *DocumentService) CreateDocument(userID uuid.UUID, o storage.StorageObje
 		FileHash:         o.FileHash,
 		IsPublic:         o.IsPublic,
 
-		UserID: userID,
+		UserID:         userID,
+		OrganizationID: placement.OrganizationID,
+		FolderID:       placement.FolderID,
 	}
 
-	if result := s.db.Preload("User").Create(document); result.Error != nil {
-		// TODO: use logger
-		return nil, fmt.Errorf("failed to create a document")
+	if extra != nil {
+		extra(document)
 	}
 
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Preload("User").Create(document); result.Error != nil {
+			// TODO: use logger
+			return fmt.Errorf("failed to create a document")
+		}
+
+		if created != nil {
+			return created(tx, document)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.DocumentsCreated.Inc()
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionDocumentCreate,
+		TargetType: audit.TargetDocument,
+		TargetID:   document.ID.String(),
+		Metadata: map[string]any{
+			"owner_id":          userID,
+			"original_filename": document.OriginalFilename,
+		},
+	})
+
 	doc := documentapp.ToAppDocument(*document)
 	return &doc, nil
 }
 
-func (s *DocumentService) GetDocument(documentStringID string) (*documentapp.Document, error) {
+// GetDocument returns a document the user can read: their own, one of an
+// organisation they belong to, one shared with them, or a public one. Reads
+// are audited, and so are attempts to read a document the user can't see.
+func (s *DocumentService) GetDocument(ctx context.Context, userID uuid.UUID, documentStringID string) (*documentapp.Document, error) {
+	doc, err := s.getDocument(ctx, userID, documentStringID)
+	if err != nil && err != ErrDocumentNotFound {
+		return nil, err
+	}
+
+	entry := audit.Entry{
+		Action:     audit.ActionDocumentRead,
+		TargetType: audit.TargetDocument,
+		TargetID:   documentStringID,
+	}
+
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Metadata = map[string]any{"reason": err.Error()}
+	}
+
+	s.auditor.Record(ctx, entry)
+	return doc, err
+}
+
+func (s *DocumentService) getDocument(ctx context.Context, userID uuid.UUID, documentStringID string) (*documentapp.Document, error) {
 	documentID, err := uuid.Parse(documentStringID)
 
 	if err != nil {
@@ -64,33 +138,252 @@ func (s *DocumentService) GetDocument(documentStringID string) (*documentapp.Doc
 
 	var document *models.Document
 
-	result := s.db.Preload("User").First(&document, documentID)
+	result := s.db.WithContext(ctx).Preload("User").
+		Where(readableDocuments(s.db, userID)).
+		First(&document, documentID)
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, ErrDocumentNotFound
 		}
 
-		return nil, err
+		return nil, result.Error
 	}
 
 	doc := documentapp.ToAppDocument(*document)
 	return &doc, nil
 }
 
-func (s *DocumentService) UpdateDocument(stringId string, documentUpdate documentapp.UpdateDocument) (*documentapp.Document, error) {
+// ListDocuments lists the user's personal documents, or an organisation's
+// documents when filter.OrganizationID is set.
+func (s *DocumentService) ListDocuments(ctx context.Context, userID uuid.UUID, filter documentapp.ListFilter, page, limit int) ([]documentapp.Document, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Document{})
+
+	if filter.OrganizationID != nil {
+		query = query.Where("documents.organization_id = ? AND documents.organization_id IN (?)",
+			*filter.OrganizationID, membershipsOf(s.db, userID, orgapp.RoleViewer))
+	} else {
+		query = query.Where("documents.organization_id IS NULL AND documents.user_id = ?", userID)
+	}
+
+	if filter.FolderID != nil {
+		query = query.Where("documents.folder_id = ?", *filter.FolderID)
+	}
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelDocuments []models.Document
+
+	result := query.Preload("User").
+		Order("documents.created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&modelDocuments)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	documents := make([]documentapp.Document, 0, len(modelDocuments))
+	for _, md := range modelDocuments {
+		documents = append(documents, documentapp.ToAppDocument(md))
+	}
+
+	return documents, total, nil
+}
+
+// ListSharedDocuments lists the documents other people shared with the user.
+func (s *DocumentService) ListSharedDocuments(ctx context.Context, userID uuid.UUID, page, limit int) ([]documentapp.SharedDocument, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.DocumentPermission{}).
+		Joins("JOIN documents ON documents.id = document_permissions.document_id AND documents.deleted_at IS NULL").
+		Where("document_permissions.user_id = ?", userID)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var permissions []models.DocumentPermission
+
+	result := query.Preload("Document.User").
+		Order("document_permissions.created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&permissions)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	documents := make([]documentapp.SharedDocument, 0, len(permissions))
+	for _, mp := range permissions {
+		documents = append(documents, documentapp.SharedDocument{
+			Document: documentapp.ToAppDocument(mp.Document),
+			Role:     documentapp.PermissionRole(mp.Role),
+		})
+	}
+
+	return documents, total, nil
+}
+
+// UpdateDocument updates a document the user can edit: their own, one of an
+// organisation where they are at least a member, or one shared with them as
+// editor. Only the owning side may move it between folders.
+func (s *DocumentService) UpdateDocument(ctx context.Context, userID uuid.UUID, stringId string, documentUpdate documentapp.UpdateDocument) (*documentapp.Document, error) {
 	id, err := uuid.Parse(stringId)
 	if err != nil {
 		return nil, ErrInvalidId
 	}
 
+	var existing models.Document
+
+	result := s.db.WithContext(ctx).Where(editableDocuments(s.db, userID)).First(&existing, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDocumentNotFound
+		}
+
+		return nil, result.Error
+	}
+
+	before := documentapp.ToAppDocument(existing)
 	md := documentUpdate.ToModelDocument()
 
-	result := s.db.Where("id = ?", id).Updates(md)
+	// Editors may change the content, but only whoever manages the document
+	// decides who else sees it and where it lives
+	if documentUpdate.IsPublic != nil || md.FolderID != nil {
+		manageable, err := s.manageable(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
 
-	if result.Error != nil {
+		if !manageable {
+			return nil, ErrDocumentForbidden
+		}
+	}
+
+	if md.FolderID != nil {
+		placement := documentapp.Placement{OrganizationID: existing.OrganizationID, FolderID: md.FolderID}
+		if err := s.checkPlacement(ctx, userID, placement); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Where("id = ?", id).Updates(md); result.Error != nil {
+			return result.Error
+		}
+
+		if result := tx.First(&existing, id); result.Error != nil {
+			return result.Error
+		}
+
+		return models.RecordEvent(tx, models.EventDocumentUpdated, id, existing.Event())
+	})
+
+	if err != nil {
 		return nil, ErrFailedToUpdate
 	}
 
-	return s.GetDocument(stringId)
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionDocumentUpdate,
+		TargetType: audit.TargetDocument,
+		TargetID:   id.String(),
+		Changes:    audit.Diff(before, documentapp.ToAppDocument(existing)),
+	})
+
+	return s.getDocument(ctx, userID, stringId)
+}
+
+// manageable reports whether userID manages the document: its owner for a
+// personal document, an admin of the organisation for an organisation one.
+func (s *DocumentService) manageable(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error) {
+	var count int64
+
+	result := s.db.WithContext(ctx).Model(&models.Document{}).
+		Where(accessibleBy(s.db, "documents", userID, orgapp.RoleAdmin)).
+		Where("id = ?", id).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// DeleteDocument soft-deletes a document. Personal documents can only be
+// deleted by their owner, organisation documents by its admins.
+func (s *DocumentService) DeleteDocument(ctx context.Context, userID uuid.UUID, stringId string) error {
+	id, err := uuid.Parse(stringId)
+	if err != nil {
+		return ErrInvalidId
+	}
+
+	var document models.Document
+
+	result := s.db.WithContext(ctx).Where(accessibleBy(s.db, "documents", userID, orgapp.RoleAdmin)).First(&document, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return ErrDocumentNotFound
+		}
+
+		return result.Error
+	}
+
+	// Deleting the loaded document lets its hooks record the event
+	if result := s.db.WithContext(ctx).Delete(&document); result.Error != nil {
+		return result.Error
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionDocumentDelete,
+		TargetType: audit.TargetDocument,
+		TargetID:   document.ID.String(),
+		Metadata:   map[string]any{"original_filename": document.OriginalFilename},
+	})
+
+	return nil
+}
+
+// checkPlacement verifies the user may add documents to the organisation and
+// folder, and that the folder lives in the same organisation.
+func (s *DocumentService) checkPlacement(ctx context.Context, userID uuid.UUID, placement documentapp.Placement) error {
+	if placement.OrganizationID != nil {
+		var count int64
+		s.db.WithContext(ctx).Model(&models.OrganizationMembership{}).
+			Where("organization_id = ? AND user_id = ? AND role IN ?", *placement.OrganizationID, userID, orgapp.RolesAtLeast(orgapp.RoleMember)).
+			Count(&count)
+
+		if count == 0 {
+			return ErrOrganizationNotFound
+		}
+	}
+
+	if placement.FolderID != nil {
+		var folder models.Folder
+
+		result := s.db.WithContext(ctx).Where(accessibleBy(s.db, "folders", userID, orgapp.RoleMember)).First(&folder, *placement.FolderID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrFolderNotFound
+			}
+			return result.Error
+		}
+
+		if !sameOrganization(folder.OrganizationID, placement.OrganizationID) {
+			return ErrFolderNotFound
+		}
+	}
+
+	return nil
+}
+
+func sameOrganization(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/db/models"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FolderServiceInterface interface {
+	CreateFolder(ctx context.Context, userID uuid.UUID, name string, parentID *uuid.UUID, orgID *uuid.UUID) (*documentapp.Folder, error)
+	ListFolders(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID, parentID *uuid.UUID) ([]documentapp.Folder, error)
+	DeleteFolder(ctx context.Context, userID uuid.UUID, folderID string) error
+}
+
+type FolderService struct {
+	db *gorm.DB
+}
+
+func NewFolderService(db *gorm.DB) *FolderService {
+	return &FolderService{
+		db: db,
+	}
+}
+
+// CreateFolder creates a folder. A sub-folder always belongs to the same
+// organisation as its parent.
+func (s *FolderService) CreateFolder(ctx context.Context, userID uuid.UUID, name string, parentID *uuid.UUID, orgID *uuid.UUID) (*documentapp.Folder, error) {
+	if parentID != nil {
+		var parent models.Folder
+
+		result := s.db.WithContext(ctx).Where(accessibleBy(s.db, "folders", userID, orgapp.RoleMember)).First(&parent, *parentID)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return nil, ErrFolderNotFound
+			}
+			return nil, result.Error
+		}
+
+		orgID = parent.OrganizationID
+	} else if orgID != nil {
+		var count int64
+		s.db.WithContext(ctx).Model(&models.OrganizationMembership{}).
+			Where("organization_id = ? AND user_id = ? AND role IN ?", *orgID, userID, orgapp.RolesAtLeast(orgapp.RoleMember)).
+			Count(&count)
+
+		if count == 0 {
+			return nil, ErrOrganizationNotFound
+		}
+	}
+
+	folder := &models.Folder{
+		UserID:         userID,
+		OrganizationID: orgID,
+		ParentID:       parentID,
+		Name:           strings.TrimSpace(name),
+	}
+
+	if result := s.db.WithContext(ctx).Create(folder); result.Error != nil {
+		return nil, result.Error
+	}
+
+	f := documentapp.ToAppFolder(*folder)
+	return &f, nil
+}
+
+// ListFolders lists the direct children of parentID, or the top-level folders
+// when it is nil, in the user's personal space or in an organisation.
+func (s *FolderService) ListFolders(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID, parentID *uuid.UUID) ([]documentapp.Folder, error) {
+	query := s.db.WithContext(ctx).Model(&models.Folder{})
+
+	if orgID != nil {
+		query = query.Where("folders.organization_id = ? AND folders.organization_id IN (?)",
+			*orgID, membershipsOf(s.db, userID, orgapp.RoleViewer))
+	} else {
+		query = query.Where("folders.organization_id IS NULL AND folders.user_id = ?", userID)
+	}
+
+	if parentID != nil {
+		query = query.Where("folders.parent_id = ?", *parentID)
+	} else {
+		query = query.Where("folders.parent_id IS NULL")
+	}
+
+	var modelFolders []models.Folder
+	if result := query.Order("folders.name").Find(&modelFolders); result.Error != nil {
+		return nil, result.Error
+	}
+
+	folders := make([]documentapp.Folder, 0, len(modelFolders))
+	for _, mf := range modelFolders {
+		folders = append(folders, documentapp.ToAppFolder(mf))
+	}
+
+	return folders, nil
+}
+
+// DeleteFolder deletes a folder; its documents move to the top level.
+func (s *FolderService) DeleteFolder(ctx context.Context, userID uuid.UUID, stringID string) error {
+	folderID, err := uuid.Parse(stringID)
+	if err != nil {
+		return ErrInvalidId
+	}
+
+	var folder models.Folder
+
+	result := s.db.WithContext(ctx).Where(accessibleBy(s.db, "folders", userID, orgapp.RoleMember)).First(&folder, folderID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return ErrFolderNotFound
+		}
+		return result.Error
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uuid.UUID{folderID}
+
+		// Collect the whole sub-tree before deleting it
+		for frontier := ids; len(frontier) > 0; {
+			var children []uuid.UUID
+			if result := tx.Model(&models.Folder{}).Where("parent_id IN ?", frontier).Pluck("id", &children); result.Error != nil {
+				return result.Error
+			}
+			ids = append(ids, children...)
+			frontier = children
+		}
+
+		if result := tx.Model(&models.Document{}).Where("folder_id IN ?", ids).Update("folder_id", nil); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Where("id IN ?", ids).Delete(&models.Folder{}).Error
+	})
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"share-docs/pkg/app/domain/healthapp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const readinessTimeout = 2 * time.Second
+
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+type HealthServiceInterface interface {
+	Readiness(ctx context.Context) healthapp.Report
+}
+
+// HealthService probes the dependencies an instance needs to serve traffic.
+type HealthService struct {
+	db         *gorm.DB
+	storage    *StorageService
+	migrations fs.FS
+}
+
+func NewHealthService(db *gorm.DB, storage *StorageService, migrations fs.FS) *HealthService {
+	return &HealthService{
+		db:         db,
+		storage:    storage,
+		migrations: migrations,
+	}
+}
+
+type healthCheck func(ctx context.Context) (any, error)
+
+// Readiness runs every check at once, each bounded by readinessTimeout, so a
+// hanging dependency is reported as down instead of stalling the probe.
+func (s *HealthService) Readiness(ctx context.Context) healthapp.Report {
+	checks := map[string]healthCheck{
+		"database":   s.checkDatabase,
+		"storage":    s.checkStorage,
+		"migrations": s.checkMigrations,
+	}
+
+	report := healthapp.Report{
+		Status:     healthapp.StatusUp,
+		Components: make(map[string]healthapp.Component, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			component := runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[name] = component
+			if component.Status != healthapp.StatusUp {
+				report.Status = healthapp.StatusDown
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func runCheck(ctx context.Context, check healthCheck) healthapp.Component {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	type result struct {
+		details any
+		err     error
+	}
+
+	start := time.Now()
+	done := make(chan result, 1)
+
+	go func() {
+		details, err := check(ctx)
+		done <- result{details, err}
+	}()
+
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		r.err = fmt.Errorf("timed out after %s", readinessTimeout)
+	}
+
+	component := healthapp.Component{
+		Status:    healthapp.StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   r.details,
+	}
+
+	if r.err != nil {
+		component.Status = healthapp.StatusDown
+		component.Error = r.err.Error()
+	}
+
+	return component
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) (any, error) {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, sqlDB.PingContext(ctx)
+}
+
+func (s *HealthService) checkStorage(ctx context.Context) (any, error) {
+	return nil, s.storage.Probe()
+}
+
+// checkMigrations compares the migrations goose applied with the ones
+// embedded in the binary. Pending ones make the instance unready: its code
+// may expect tables or columns that don't exist yet. The versions table is
+// read directly, as goose's own status waits for the migration lock while
+// another instance migrates.
+func (s *HealthService) checkMigrations(ctx context.Context) (any, error) {
+	latest, available, err := s.availableMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	// A version counts as applied when its latest goose row says so
+	var rows []struct {
+		VersionID int64
+		IsApplied bool
+	}
+
+	result := s.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (version_id) version_id, is_applied
+		FROM goose_db_version
+		ORDER BY version_id, id DESC`,
+	).Scan(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to read migration versions: %w", result.Error)
+	}
+
+	status := healthapp.MigrationStatus{Latest: latest}
+
+	applied := make(map[int64]bool, len(rows))
+	for _, row := range rows {
+		if row.IsApplied && row.VersionID > 0 {
+			applied[row.VersionID] = true
+			status.Current = max(status.Current, row.VersionID)
+		}
+	}
+
+	for _, version := range available {
+		if !applied[version] {
+			status.Pending = append(status.Pending, version)
+		}
+	}
+
+	if len(status.Pending) > 0 {
+		return status, ErrPendingMigrations
+	}
+
+	return status, nil
+}
+
+func (s *HealthService) availableMigrations() (int64, []int64, error) {
+	entries, err := fs.ReadDir(s.migrations, ".")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var versions []int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	slices.Sort(versions)
+
+	if len(versions) == 0 {
+		return 0, nil, nil
+	}
+
+	return versions[len(versions)-1], versions, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"share-docs/pkg/app/domain/jobapp"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobNotFound   = errors.New("job not found")
+	ErrJobNotRetried = errors.New("only dead or cancelled jobs can be retried")
+	ErrJobNotQueued  = errors.New("only queued jobs can be cancelled")
+)
+
+// JobServiceInterface gives administrators a view of the background job
+// queue and lets them retry or cancel jobs.
+type JobServiceInterface interface {
+	ListJobs(ctx context.Context, filter jobapp.ListFilter, page, limit int) ([]jobapp.Job, int64, error)
+	GetJob(ctx context.Context, jobID string) (*jobapp.Job, error)
+	QueueStats(ctx context.Context) ([]jobapp.QueueStats, error)
+	RetryJob(ctx context.Context, jobID string) (*jobapp.Job, error)
+	CancelJob(ctx context.Context, jobID string) (*jobapp.Job, error)
+}
+
+type JobService struct {
+	db *gorm.DB
+}
+
+func NewJobService(db *gorm.DB) *JobService {
+	return &JobService{db: db}
+}
+
+func (s *JobService) ListJobs(ctx context.Context, filter jobapp.ListFilter, page, limit int) ([]jobapp.Job, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Job{})
+
+	if filter.Queue != "" {
+		query = query.Where("queue = ?", filter.Queue)
+	}
+
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelJobs []models.Job
+
+	result := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&modelJobs)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	jobs := make([]jobapp.Job, 0, len(modelJobs))
+	for _, mj := range modelJobs {
+		jobs = append(jobs, jobapp.ToAppJob(mj))
+	}
+
+	return jobs, total, nil
+}
+
+func (s *JobService) GetJob(ctx context.Context, jobID string) (*jobapp.Job, error) {
+	job, err := s.findJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	j := jobapp.ToAppJob(*job)
+	return &j, nil
+}
+
+func (s *JobService) QueueStats(ctx context.Context) ([]jobapp.QueueStats, error) {
+	var rows []struct {
+		Queue  string
+		Status string
+		Count  int64
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.Job{}).
+		Select("queue, status, COUNT(*) AS count").
+		Group("queue, status").
+		Order("queue").
+		Scan(&rows)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	stats := []jobapp.QueueStats{}
+	for _, row := range rows {
+		if len(stats) == 0 || stats[len(stats)-1].Queue != row.Queue {
+			stats = append(stats, jobapp.QueueStats{Queue: row.Queue, Counts: map[string]int64{}})
+		}
+		stats[len(stats)-1].Counts[row.Status] = row.Count
+	}
+
+	return stats, nil
+}
+
+// RetryJob queues a dead or cancelled job again with a fresh set of attempts.
+func (s *JobService) RetryJob(ctx context.Context, jobID string) (*jobapp.Job, error) {
+	return s.transition(ctx, jobID, []string{models.JobDead, models.JobCancelled}, ErrJobNotRetried, map[string]any{
+		"status":      models.JobQueued,
+		"attempts":    0,
+		"run_at":      time.Now(),
+		"finished_at": nil,
+	})
+}
+
+// CancelJob stops a queued job from running. Running jobs can't be cancelled.
+func (s *JobService) CancelJob(ctx context.Context, jobID string) (*jobapp.Job, error) {
+	return s.transition(ctx, jobID, []string{models.JobQueued}, ErrJobNotQueued, map[string]any{
+		"status":      models.JobCancelled,
+		"finished_at": time.Now(),
+	})
+}
+
+// transition applies updates when the job is in one of the from states, in
+// the same statement so a worker claiming it in between can't be overridden.
+func (s *JobService) transition(ctx context.Context, jobID string, from []string, errWrongState error, updates map[string]any) (*jobapp.Job, error) {
+	job, err := s.findJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.Job{}).Where("id = ? AND status IN ?", job.ID, from).Updates(updates)
+	if result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, errWrongState
+	}
+
+	return s.GetJob(ctx, jobID)
+}
+
+func (s *JobService) findJob(ctx context.Context, jobID string) (*models.Job, error) {
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var job models.Job
+
+	result := s.db.WithContext(ctx).First(&job, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrJobNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &job, nil
+}
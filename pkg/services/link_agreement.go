@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/util"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkAgreement returns the agreement a viewer has to accept before opening
+// a link.
+func (s *ShareLinkService) LinkAgreement(ctx context.Context, token string) (*linkapp.Agreement, error) {
+	link, err := s.activeLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Agreement == nil {
+		return nil, ErrAgreementNotFound
+	}
+
+	a := linkapp.ToAppAgreement(*link.Agreement)
+	return &a, nil
+}
+
+// OpenAgreementFile returns the document of a link's agreement, so viewers
+// can read it before accepting.
+func (s *ShareLinkService) OpenAgreementFile(ctx context.Context, token string) (*documentapp.Document, error) {
+	link, err := s.activeLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Agreement == nil || link.Agreement.DocumentID == nil {
+		return nil, ErrAgreementNotFound
+	}
+
+	var document models.Document
+	if result := s.db.WithContext(ctx).First(&document, link.Agreement.DocumentID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrAgreementNotFound
+		}
+		return nil, result.Error
+	}
+
+	d := documentapp.ToAppDocument(document)
+	return &d, nil
+}
+
+// checkAgreement returns the acceptance to record for links with an
+// agreement, or nil when there is none. On email-gated links the email is
+// the one already checked by the gate.
+func checkAgreement(link *models.ShareLink, req linkapp.AccessRequest, viewerEmail *string, visitor linkapp.Visitor) (*models.AgreementAcceptance, error) {
+	if link.Agreement == nil {
+		return nil, nil
+	}
+
+	name := strings.TrimSpace(req.Name)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if viewerEmail != nil {
+		email = *viewerEmail
+	}
+
+	if !req.AcceptAgreement || name == "" || email == "" {
+		return nil, ErrAgreementNotAccepted
+	}
+
+	return &models.AgreementAcceptance{
+		AgreementID: link.Agreement.ID,
+		ShareLinkID: link.ID,
+		Name:        name,
+		Email:       email,
+		IPAddress:   visitor.IPAddress,
+		UserAgent:   util.Truncate(visitor.UserAgent, 255),
+	}, nil
+}
+
+// findOwnAgreementID resolves the agreement a link owner attaches to a link.
+func findOwnAgreementID(db *gorm.DB, userID uuid.UUID, stringID string) (*uuid.UUID, error) {
+	agreement, err := findOwnAgreement(db, userID, stringID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agreement.ID, nil
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"net"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxBeaconDurationMs caps a single dwell time report, so a tab left open
+// overnight doesn't skew the averages.
+const maxBeaconDurationMs = 10 * 60 * 1000
+
+type LinkAnalyticsServiceInterface interface {
+	RecordPageView(ctx context.Context, token string, beacon linkapp.PageBeacon) error
+	LinkStats(ctx context.Context, userID uuid.UUID, linkID string) (*linkapp.Stats, error)
+	DocumentStats(ctx context.Context, userID uuid.UUID, documentID string) (*linkapp.Stats, error)
+	ListViews(ctx context.Context, userID uuid.UUID, linkID string, page, limit int) ([]linkapp.View, int64, error)
+}
+
+type LinkAnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewLinkAnalyticsService(db *gorm.DB) *LinkAnalyticsService {
+	return &LinkAnalyticsService{
+		db: db,
+	}
+}
+
+// RecordPageView adds the dwell time reported by the viewer beacon to a page
+// of a view.
+func (s *LinkAnalyticsService) RecordPageView(ctx context.Context, token string, beacon linkapp.PageBeacon) error {
+	var link models.ShareLink
+
+	result := s.db.WithContext(ctx).Where("token_hash = ?", hashToken(token)).First(&link)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return ErrShareLinkNotFound
+		}
+		return result.Error
+	}
+
+	view, err := findView(s.db, link.ID, beacon.ViewToken)
+	if err != nil {
+		return err
+	}
+
+	duration := min(max(beacon.DurationMs, 0), maxBeaconDurationMs)
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if beacon.PageCount != nil {
+			if result := tx.Model(view).UpdateColumn("page_count", *beacon.PageCount); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "link_view_id"}, {Name: "page"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"duration_ms": gorm.Expr("link_view_pages.duration_ms + EXCLUDED.duration_ms"),
+				"updated_at":  time.Now(),
+			}),
+		}).Create(&models.LinkViewPage{
+			LinkViewID: view.ID,
+			Page:       beacon.Page,
+			DurationMs: duration,
+		}).Error
+	})
+}
+
+func (s *LinkAnalyticsService) LinkStats(ctx context.Context, userID uuid.UUID, linkID string) (*linkapp.Stats, error) {
+	link, err := findEditableLink(s.db, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.stats(ctx, "link_views.share_link_id = ?", link.ID)
+}
+
+// DocumentStats aggregates the views of every link of a document.
+func (s *LinkAnalyticsService) DocumentStats(ctx context.Context, userID uuid.UUID, documentID string) (*linkapp.Stats, error) {
+	document, err := findEditableDocument(s.db, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.stats(ctx, "link_views.document_id = ?", document.ID)
+}
+
+// ListViews lists the individual views of a link, newest first, with the time
+// spent and the number of pages seen.
+func (s *LinkAnalyticsService) ListViews(ctx context.Context, userID uuid.UUID, linkID string, page, limit int) ([]linkapp.View, int64, error) {
+	link, err := findEditableLink(s.db, userID, linkID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.LinkView{}).Where("share_link_id = ?", link.ID)
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelViews []models.LinkView
+
+	result := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&modelViews)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	viewIDs := make([]uuid.UUID, 0, len(modelViews))
+	for _, mv := range modelViews {
+		viewIDs = append(viewIDs, mv.ID)
+	}
+
+	var totals []struct {
+		LinkViewID  uuid.UUID
+		DurationMs  int64
+		PagesViewed int
+	}
+
+	result = s.db.WithContext(ctx).Model(&models.LinkViewPage{}).
+		Select("link_view_id, SUM(duration_ms) AS duration_ms, COUNT(*) AS pages_viewed").
+		Where("link_view_id IN ?", viewIDs).
+		Group("link_view_id").
+		Scan(&totals)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	views := make([]linkapp.View, 0, len(modelViews))
+	for _, mv := range modelViews {
+		v := linkapp.ToAppView(mv)
+		for _, t := range totals {
+			if t.LinkViewID == mv.ID {
+				v.DurationMs = t.DurationMs
+				v.PagesViewed = t.PagesViewed
+			}
+		}
+		views = append(views, v)
+	}
+
+	return views, total, nil
+}
+
+// stats aggregates the views matching the condition. Viewers are told apart
+// by their email when a link is gated and by anonymised IP and user agent
+// otherwise.
+func (s *LinkAnalyticsService) stats(ctx context.Context, condition string, id uuid.UUID) (*linkapp.Stats, error) {
+	perView := s.db.Model(&models.LinkViewPage{}).
+		Select("link_view_id, SUM(duration_ms) AS duration_ms, COUNT(*) AS pages_viewed").
+		Group("link_view_id")
+
+	var totals struct {
+		Views             int64
+		UniqueViewers     int64
+		AverageDurationMs float64
+		PagedViews        int64
+		CompletedViews    int64
+		LastViewedAt      *time.Time
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.LinkView{}).
+		Select(`COUNT(*) AS views,
+			COUNT(DISTINCT COALESCE(link_views.viewer_email, link_views.visitor_id)) AS unique_viewers,
+			COALESCE(AVG(COALESCE(t.duration_ms, 0)), 0) AS average_duration_ms,
+			COUNT(*) FILTER (WHERE link_views.page_count IS NOT NULL) AS paged_views,
+			COUNT(*) FILTER (WHERE link_views.page_count IS NOT NULL AND t.pages_viewed >= link_views.page_count) AS completed_views,
+			MAX(link_views.created_at) AS last_viewed_at`).
+		Joins("LEFT JOIN (?) AS t ON t.link_view_id = link_views.id", perView).
+		Where(condition, id).
+		Scan(&totals)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	stats := &linkapp.Stats{
+		Views:             totals.Views,
+		UniqueViewers:     totals.UniqueViewers,
+		AverageDurationMs: totals.AverageDurationMs,
+		LastViewedAt:      totals.LastViewedAt,
+		Pages:             []linkapp.PageStats{},
+	}
+
+	if totals.PagedViews > 0 {
+		rate := float64(totals.CompletedViews) / float64(totals.PagedViews)
+		stats.CompletionRate = &rate
+	}
+
+	result = s.db.WithContext(ctx).Model(&models.LinkViewPage{}).
+		Select("link_view_pages.page, COUNT(*) AS views, AVG(link_view_pages.duration_ms) AS average_duration_ms").
+		Joins("JOIN link_views ON link_views.id = link_view_pages.link_view_id AND link_views.deleted_at IS NULL").
+		Where(condition, id).
+		Group("link_view_pages.page").
+		Order("link_view_pages.page").
+		Scan(&stats.Pages)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return stats, nil
+}
+
+// anonymizeIP drops the host part of an address: the last octet of IPv4 and
+// everything past the /48 of IPv6.
+func anonymizeIP(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	netmail "net/mail"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/mail"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLinkEmailRequired    = errors.New("share link requires an email")
+	ErrLinkEmailCodeInvalid = errors.New("invalid or expired email code")
+	ErrEmailNotAllowed      = errors.New("email is not allowed to open this link")
+	ErrInvalidAllowlist     = errors.New("invalid allowlist entry")
+)
+
+const (
+	linkEmailCodeExpiration  = 10 * time.Minute
+	linkEmailCodeMaxAttempts = 5
+)
+
+// RequestEmailCode emails a one-time code to a viewer of an email-verified
+// link. Emails outside the allowlist never get a code.
+func (s *ShareLinkService) RequestEmailCode(ctx context.Context, token string, email string) error {
+	link, err := s.activeLink(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if !allowlisted(link.Allowlist, email) {
+		return ErrEmailNotAllowed
+	}
+
+	code, err := generateEmailCode()
+	if err != nil {
+		return err
+	}
+
+	emailCode := &models.LinkEmailCode{
+		ShareLinkID: link.ID,
+		Email:       email,
+		CodeHash:    hashEmailCode(link, email, code),
+		ExpiresAt:   time.Now().Add(linkEmailCodeExpiration),
+	}
+
+	name := link.Document.OriginalFilename
+	if link.Document.Title != nil {
+		name = *link.Document.Title
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the latest code is valid
+		if result := tx.Where("share_link_id = ? AND email = ?", link.ID, email).Delete(&models.LinkEmailCode{}); result.Error != nil {
+			return fmt.Errorf("failed to store email code: %w", result.Error)
+		}
+
+		if result := tx.Create(emailCode); result.Error != nil {
+			return fmt.Errorf("failed to store email code: %w", result.Error)
+		}
+
+		return jobs.EnqueueEmail(tx, mail.Message{
+			To:      []string{email},
+			Subject: fmt.Sprintf("Your code to view %s", name),
+			Body: fmt.Sprintf(
+				"Your verification code is %s\n\nIt expires in %d minutes.\n",
+				code, int(linkEmailCodeExpiration.Minutes()),
+			),
+		})
+	})
+}
+
+// checkEmailGate enforces the email requirement, allowlist and verification
+// of a link, returning the email to record on the view.
+func (s *ShareLinkService) checkEmailGate(ctx context.Context, link *models.ShareLink, email string, code string) (*string, bool, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if !link.EmailGated() {
+		if email == "" {
+			return nil, false, nil
+		}
+		return &email, false, nil
+	}
+
+	if email == "" {
+		return nil, false, ErrLinkEmailRequired
+	}
+
+	if !allowlisted(link.Allowlist, email) {
+		return nil, false, ErrEmailNotAllowed
+	}
+
+	if !link.EmailVerificationRequired() {
+		return &email, false, nil
+	}
+
+	if code == "" {
+		return nil, false, ErrLinkEmailCodeInvalid
+	}
+
+	var emailCode models.LinkEmailCode
+
+	result := s.db.WithContext(ctx).Where("share_link_id = ? AND email = ? AND expires_at > ? AND attempts < ?",
+		link.ID, email, time.Now(), linkEmailCodeMaxAttempts).
+		Order("created_at DESC").
+		First(&emailCode)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, false, ErrLinkEmailCodeInvalid
+		}
+		return nil, false, result.Error
+	}
+
+	if emailCode.CodeHash != hashEmailCode(link, email, code) {
+		s.db.WithContext(ctx).Model(&emailCode).UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+		return nil, false, ErrLinkEmailCodeInvalid
+	}
+
+	// Deleting makes the code single use; a concurrent use loses the race
+	result = s.db.WithContext(ctx).Delete(&emailCode)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, false, ErrLinkEmailCodeInvalid
+	}
+
+	return &email, true, nil
+}
+
+// normalizeAllowlist validates allowlist entries and stores domains as
+// "@domain".
+func normalizeAllowlist(entries []string) (string, error) {
+	normalized := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "@") {
+			entry = "@" + entry
+		}
+
+		if strings.HasPrefix(entry, "@") {
+			if !strings.Contains(entry, ".") || strings.ContainsAny(entry[1:], "@ ") {
+				return "", fmt.Errorf("%w: %s", ErrInvalidAllowlist, entry)
+			}
+		} else if _, err := netmail.ParseAddress(entry); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidAllowlist, entry)
+		}
+
+		normalized = append(normalized, entry)
+	}
+
+	return linkapp.JoinAllowlist(normalized), nil
+}
+
+// allowlisted reports whether an email matches the allowlist. An empty
+// allowlist allows everyone.
+func allowlisted(allowlist string, email string) bool {
+	entries := linkapp.SplitAllowlist(allowlist)
+	if len(entries) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	for _, entry := range entries {
+		if entry == email || entry == email[at:] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func generateEmailCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashEmailCode binds a code to its link and email, so a leaked hash table
+// can't be matched against another link.
+func hashEmailCode(link *models.ShareLink, email string, code string) string {
+	return hashToken(link.ID.String() + "|" + email + "|" + strings.TrimSpace(code))
+}
@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/userapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/auth"
+	"share-docs/pkg/db/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled     = errors.New("two-factor enrollment has not been started")
+	ErrInvalidMFACode     = errors.New("invalid two-factor code")
+	ErrInvalidMFAPassword = errors.New("invalid password")
+	ErrMFAChallengeClosed = errors.New("two-factor challenge expired or ran out of attempts")
+	ErrMFALocked          = errors.New("too many invalid two-factor codes, try again later")
+)
+
+const recoveryCodeCount = 10
+
+const (
+	// mfaChallengeExpiration matches the lifetime of the challenge token
+	mfaChallengeExpiration  = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+
+	// A user's codes are counted across challenges, so logging in again does
+	// not buy more guesses
+	mfaMaxFailures = 10
+	mfaLockout     = 15 * time.Minute
+)
+
+type MFAServiceInterface interface {
+	BeginEnrollment(ctx context.Context, userID uuid.UUID) (*userapp.TOTPEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	Disable(ctx context.Context, userID uuid.UUID, password, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	BeginChallenge(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	Verify(ctx context.Context, challengeID uuid.UUID, userID uuid.UUID, code string) error
+}
+
+type MFAService struct {
+	db         *gorm.DB
+	auditor    audit.Recorder
+	bcryptCost int
+}
+
+func NewMFAService(db *gorm.DB, auditor audit.Recorder) *MFAService {
+	return &MFAService{
+		db:         db,
+		auditor:    auditor,
+		bcryptCost: 5,
+	}
+}
+
+// BeginEnrollment stores a fresh TOTP secret for the user. Two-factor is only
+// enforced once the secret is confirmed with ConfirmEnrollment.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID uuid.UUID) (*userapp.TOTPEnrollment, error) {
+	user, err := s.getUser(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	result := s.db.WithContext(ctx).Model(user).Updates(map[string]any{
+		"totp_secret":         secret,
+		"totp_last_used_step": 0,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &userapp.TOTPEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(secret, user.Email),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor once the user proves their app is set
+// up, and returns the plaintext recovery codes. They are not retrievable later.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var codes []string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := s.getUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+
+		if user.TOTPEnabled {
+			return ErrMFAAlreadyEnabled
+		}
+
+		if user.TOTPSecret == nil {
+			return ErrMFANotEnrolled
+		}
+
+		if err := s.verifyTOTP(tx, user, code); err != nil {
+			return err
+		}
+
+		if result := tx.Model(user).Update("totp_enabled", true); result.Error != nil {
+			return result.Error
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor off. Both the password and a current code are
+// required so a hijacked session alone cannot remove the second factor.
+// Accounts that only sign in through SSO have no password and confirm with
+// the code alone.
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, password, code string) error {
+	if err := s.countAttempt(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := s.getUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.TOTPEnabled {
+			return ErrMFANotEnabled
+		}
+
+		if user.Password != "" {
+			if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+				return ErrInvalidMFAPassword
+			}
+		}
+
+		if err := s.verify(tx, user, code); err != nil {
+			return err
+		}
+
+		result := tx.Model(user).Updates(map[string]any{
+			"totp_enabled":        false,
+			"totp_secret":         nil,
+			"totp_last_used_step": 0,
+			"mfa_failures":        0,
+			"mfa_locked_until":    nil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+// RegenerateRecoveryCodes invalidates all existing recovery codes and returns
+// a new set.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.countAttempt(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := s.getUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.TOTPEnabled {
+			return ErrMFANotEnabled
+		}
+
+		if err := s.verifyTOTP(tx, user, code); err != nil {
+			return err
+		}
+
+		if err := s.resetFailures(tx, user); err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// BeginChallenge opens the two-factor step of a user's login.
+func (s *MFAService) BeginChallenge(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	challenge := &models.MFAChallenge{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(mfaChallengeExpiration),
+	}
+
+	// Expired challenges are never consumed, clean them up as we go
+	s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.MFAChallenge{})
+
+	if result := s.db.WithContext(ctx).Create(challenge); result.Error != nil {
+		return uuid.Nil, fmt.Errorf("failed to store MFA challenge: %w", result.Error)
+	}
+
+	return challenge.ID, nil
+}
+
+// Verify accepts either a TOTP code or an unused recovery code for a login's
+// challenge. Recovery codes are consumed on use. A challenge takes
+// mfaChallengeMaxAttempts codes, after which the login starts over from the
+// password. Codes also count against the user, see countAttempt. Wrong codes
+// and lockouts are audited as failed logins.
+func (s *MFAService) Verify(ctx context.Context, challengeID uuid.UUID, userID uuid.UUID, code string) error {
+	// Counting the attempt before checking the code keeps concurrent guesses
+	// within the limit
+	result := s.db.WithContext(ctx).Model(&models.MFAChallenge{}).
+		Where("id = ? AND user_id = ? AND expires_at > ? AND attempts < ?", challengeID, userID, time.Now(), mfaChallengeMaxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrMFAChallengeClosed
+	}
+
+	err := s.countAttempt(ctx, userID)
+	if err == nil {
+		err = s.checkChallenge(ctx, challengeID, userID, code)
+	}
+
+	if err == ErrInvalidMFACode || err == ErrMFALocked {
+		s.auditor.Record(ctx, audit.Entry{
+			Action:     audit.ActionLogin,
+			Outcome:    audit.OutcomeFailure,
+			Actor:      &audit.Actor{Type: audit.ActorUser, UserID: &userID},
+			TargetType: audit.TargetUser,
+			TargetID:   userID.String(),
+			Metadata:   map[string]any{"reason": err.Error()},
+		})
+	}
+
+	return err
+}
+
+// checkChallenge checks the code and closes the challenge when it is right.
+func (s *MFAService) checkChallenge(ctx context.Context, challengeID uuid.UUID, userID uuid.UUID, code string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := s.getUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.TOTPEnabled {
+			return ErrMFANotEnabled
+		}
+
+		if err := s.verify(tx, user, code); err != nil {
+			return err
+		}
+
+		if err := s.resetFailures(tx, user); err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.MFAChallenge{}, "id = ?", challengeID).Error
+	})
+}
+
+// countAttempt counts a code against the user before it is checked, so
+// concurrent guesses stay within the limit. The mfaMaxFailures-th code in a
+// row locks the user out for mfaLockout, and each code after that until one
+// is right locks them out again.
+func (s *MFAService) countAttempt(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+
+	result := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (mfa_locked_until IS NULL OR mfa_locked_until <= ?)", userID, now).
+		Updates(map[string]any{
+			"mfa_failures":     gorm.Expr("mfa_failures + 1"),
+			"mfa_locked_until": gorm.Expr("CASE WHEN mfa_failures + 1 >= ? THEN ?::timestamptz END", mfaMaxFailures, now.Add(mfaLockout)),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrMFALocked
+	}
+
+	return nil
+}
+
+// resetFailures clears the count of a user who got a code right.
+func (s *MFAService) resetFailures(tx *gorm.DB, user *models.User) error {
+	return tx.Model(user).Updates(map[string]any{
+		"mfa_failures":     0,
+		"mfa_locked_until": nil,
+	}).Error
+}
+
+func (s *MFAService) verify(tx *gorm.DB, user *models.User, code string) error {
+	if err := s.verifyTOTP(tx, user, code); err == nil {
+		return nil
+	} else if err != ErrInvalidMFACode {
+		return err
+	}
+
+	return s.useRecoveryCode(tx, user.ID, code)
+}
+
+func (s *MFAService) verifyTOTP(tx *gorm.DB, user *models.User, code string) error {
+	if user.TOTPSecret == nil {
+		return ErrMFANotEnrolled
+	}
+
+	step, ok := auth.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+
+	// A code may only be used once, even inside its validity window
+	if !ok || step <= user.TOTPLastUsedStep {
+		return ErrInvalidMFACode
+	}
+
+	return tx.Model(user).Update("totp_last_used_step", step).Error
+}
+
+func (s *MFAService) useRecoveryCode(tx *gorm.DB, userID uuid.UUID, code string) error {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	var recoveryCodes []models.RecoveryCode
+	result := tx.Where("user_id = ? AND used_at IS NULL", userID).Find(&recoveryCodes)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, rc := range recoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(rc.CodeHash), []byte(code)) == nil {
+			return tx.Model(&rc).Update("used_at", time.Now()).Error
+		}
+	}
+
+	return ErrInvalidMFACode
+}
+
+func (s *MFAService) replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if result := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}); result.Error != nil {
+		return nil, result.Error
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	recoveryCodes := make([]models.RecoveryCode, 0, len(codes))
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), s.bcryptCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+
+		recoveryCodes = append(recoveryCodes, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: string(hash),
+		})
+	}
+
+	if result := tx.Create(&recoveryCodes); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return codes, nil
+}
+
+func (s *MFAService) getUser(tx *gorm.DB, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+
+	result := tx.First(&user, userID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/userapp"
+	"share-docs/pkg/auth"
+	"share-docs/pkg/db/models"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidOIDCState = errors.New("invalid or expired OIDC login state")
+)
+
+const oidcLoginStateExpiration = 10 * time.Minute
+
+type OIDCServiceInterface interface {
+	Providers() []string
+	BeginLogin(ctx context.Context, provider string) (string, string, error)
+	CompleteLogin(ctx context.Context, provider, state, code string) (*userapp.User, error)
+}
+
+type OIDCService struct {
+	db          *gorm.DB
+	providers   *auth.OIDCProviders
+	userService UserServiceInterface
+}
+
+func NewOIDCService(db *gorm.DB, providers *auth.OIDCProviders, userService UserServiceInterface) *OIDCService {
+	return &OIDCService{
+		db:          db,
+		providers:   providers,
+		userService: userService,
+	}
+}
+
+func (s *OIDCService) Providers() []string {
+	return s.providers.Names()
+}
+
+// BeginLogin stores a fresh state, nonce and PKCE verifier and returns the URL
+// to redirect the user to, with the state the browser must bring back.
+func (s *OIDCService) BeginLogin(ctx context.Context, providerName string) (string, string, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	loginState := &models.OIDCLoginState{
+		State:        state,
+		Provider:     provider.Name(),
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ExpiresAt:    time.Now().Add(oidcLoginStateExpiration),
+	}
+
+	// Expired states are never consumed, clean them up as we go
+	s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+
+	if result := s.db.WithContext(ctx).Create(loginState); result.Error != nil {
+		return "", "", fmt.Errorf("failed to store OIDC login state: %w", result.Error)
+	}
+
+	redirectURL, err := provider.AuthCodeURL(ctx, state, nonce, loginState.CodeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	return redirectURL, state, nil
+}
+
+// CompleteLogin consumes the login state, verifies the provider's response and
+// provisions the user.
+func (s *OIDCService) CompleteLogin(ctx context.Context, providerName, state, code string) (*userapp.User, error) {
+	provider, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var loginState models.OIDCLoginState
+
+	// Deleting with RETURNING makes each state single use
+	result := s.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ?", state, provider.Name()).
+		Delete(&loginState)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	identity, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userService.ProvisionOIDCUser(ctx, *identity)
+}
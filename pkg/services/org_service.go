@@ -0,0 +1,565 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOrganizationNotFound    = errors.New("organization not found")
+	ErrInsufficientRole        = errors.New("insufficient organization role")
+	ErrInvalidRole             = errors.New("invalid organization role")
+	ErrLastOwner               = errors.New("an organization needs at least one owner")
+	ErrMemberNotFound          = errors.New("member not found")
+	ErrAlreadyMember           = errors.New("user is already a member")
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvitationExpired       = errors.New("invitation has expired")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email")
+)
+
+const invitationExpiration = 7 * 24 * time.Hour
+
+type OrganizationServiceInterface interface {
+	CreateOrganization(ctx context.Context, userID uuid.UUID, name string) (*orgapp.Organization, error)
+	ListOrganizations(ctx context.Context, userID uuid.UUID) ([]orgapp.Organization, error)
+	GetOrganization(ctx context.Context, userID uuid.UUID, orgID string) (*orgapp.Organization, error)
+	UpdateOrganization(ctx context.Context, userID uuid.UUID, orgID string, name string) (*orgapp.Organization, error)
+	DeleteOrganization(ctx context.Context, userID uuid.UUID, orgID string) error
+
+	ListMembers(ctx context.Context, userID uuid.UUID, orgID string) ([]orgapp.Member, error)
+	UpdateMemberRole(ctx context.Context, userID uuid.UUID, orgID string, memberID string, role orgapp.Role) error
+	RemoveMember(ctx context.Context, userID uuid.UUID, orgID string, memberID string) error
+
+	InviteMember(ctx context.Context, userID uuid.UUID, orgID string, email string, role orgapp.Role) (*orgapp.Invitation, error)
+	ListInvitations(ctx context.Context, userID uuid.UUID, orgID string) ([]orgapp.Invitation, error)
+	RevokeInvitation(ctx context.Context, userID uuid.UUID, orgID string, invitationID string) error
+	AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*orgapp.Organization, error)
+
+	RequireRole(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, min orgapp.Role) (orgapp.Role, error)
+}
+
+type OrganizationService struct {
+	db      *gorm.DB
+	auditor audit.Recorder
+	baseURL string
+}
+
+func NewOrganizationService(db *gorm.DB, auditor audit.Recorder, baseURL string) *OrganizationService {
+	return &OrganizationService{
+		db:      db,
+		auditor: auditor,
+		baseURL: baseURL,
+	}
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, userID uuid.UUID, name string) (*orgapp.Organization, error) {
+	org := &models.Organization{Name: strings.TrimSpace(name)}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(org); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Create(&models.OrganizationMembership{
+			OrganizationID: org.ID,
+			UserID:         userID,
+			Role:           string(orgapp.RoleOwner),
+		}).Error
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	o := orgapp.ToAppOrganization(*org)
+	o.Role = orgapp.RoleOwner
+	return &o, nil
+}
+
+func (s *OrganizationService) ListOrganizations(ctx context.Context, userID uuid.UUID) ([]orgapp.Organization, error) {
+	var memberships []models.OrganizationMembership
+
+	result := s.db.WithContext(ctx).Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&memberships)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	orgs := make([]orgapp.Organization, 0, len(memberships))
+	for _, m := range memberships {
+		// Soft deleted organisations are not preloaded
+		if m.Organization.ID == uuid.Nil {
+			continue
+		}
+
+		o := orgapp.ToAppOrganization(m.Organization)
+		o.Role = orgapp.Role(m.Role)
+		orgs = append(orgs, o)
+	}
+
+	return orgs, nil
+}
+
+func (s *OrganizationService) GetOrganization(ctx context.Context, userID uuid.UUID, stringID string) (*orgapp.Organization, error) {
+	orgID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	role, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	var org models.Organization
+	if result := s.db.WithContext(ctx).First(&org, orgID); result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, result.Error
+	}
+
+	o := orgapp.ToAppOrganization(org)
+	o.Role = role
+	return &o, nil
+}
+
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, userID uuid.UUID, stringID string, name string) (*orgapp.Organization, error) {
+	orgID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	if _, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).Model(&models.Organization{}).Where("id = ?", orgID).Update("name", strings.TrimSpace(name))
+	if result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	return s.GetOrganization(ctx, userID, stringID)
+}
+
+// DeleteOrganization deletes the organisation together with its documents,
+// folders, memberships and invitations.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, userID uuid.UUID, stringID string) error {
+	orgID, err := uuid.Parse(stringID)
+	if err != nil {
+		return ErrInvalidId
+	}
+
+	if _, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleOwner); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{
+			&models.Document{},
+			&models.Folder{},
+			&models.OrganizationInvitation{},
+			&models.OrganizationMembership{},
+		} {
+			if result := tx.Where("organization_id = ?", orgID).Delete(model); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return tx.Delete(&models.Organization{}, orgID).Error
+	})
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, userID uuid.UUID, stringID string) ([]orgapp.Member, error) {
+	orgID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	if _, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var memberships []models.OrganizationMembership
+
+	result := s.db.WithContext(ctx).Preload("User").Where("organization_id = ?", orgID).Order("created_at").Find(&memberships)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	members := make([]orgapp.Member, 0, len(memberships))
+	for _, m := range memberships {
+		members = append(members, orgapp.ToAppMember(m))
+	}
+
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of a member. Admins manage members below
+// owner; only owners can grant, change or revoke the owner role.
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, userID uuid.UUID, stringID string, memberStringID string, role orgapp.Role) error {
+	orgID, memberID, err := parseOrgAndMember(stringID, memberStringID)
+	if err != nil {
+		return err
+	}
+
+	if !role.IsValid() {
+		return ErrInvalidRole
+	}
+
+	actorRole, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	var before string
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		membership, err := s.lockMembership(tx, orgID, memberID)
+		if err != nil {
+			return err
+		}
+
+		before = membership.Role
+		touchesOwner := role == orgapp.RoleOwner || orgapp.Role(membership.Role) == orgapp.RoleOwner
+		if touchesOwner && actorRole != orgapp.RoleOwner {
+			return ErrInsufficientRole
+		}
+
+		if orgapp.Role(membership.Role) == orgapp.RoleOwner && role != orgapp.RoleOwner {
+			if err := s.ensureAnotherOwner(tx, orgID, memberID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(membership).Update("role", string(role)).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionPermissionUpdate,
+		TargetType: audit.TargetOrganization,
+		TargetID:   orgID.String(),
+		Changes:    map[string]audit.Change{"role": {Before: before, After: string(role)}},
+		Metadata:   map[string]any{"member_id": memberID},
+	})
+
+	return nil
+}
+
+// RemoveMember removes a member. Members may always remove themselves, i.e.
+// leave, as long as they are not the last owner.
+func (s *OrganizationService) RemoveMember(ctx context.Context, userID uuid.UUID, stringID string, memberStringID string) error {
+	orgID, memberID, err := parseOrgAndMember(stringID, memberStringID)
+	if err != nil {
+		return err
+	}
+
+	minRole := orgapp.RoleAdmin
+	if memberID == userID {
+		minRole = orgapp.RoleViewer
+	}
+
+	actorRole, err := s.RequireRole(ctx, userID, orgID, minRole)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		membership, err := s.lockMembership(tx, orgID, memberID)
+		if err != nil {
+			return err
+		}
+
+		if orgapp.Role(membership.Role) == orgapp.RoleOwner {
+			if memberID != userID && actorRole != orgapp.RoleOwner {
+				return ErrInsufficientRole
+			}
+
+			if err := s.ensureAnotherOwner(tx, orgID, memberID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(membership).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionPermissionRevoke,
+		TargetType: audit.TargetOrganization,
+		TargetID:   orgID.String(),
+		Metadata:   map[string]any{"member_id": memberID},
+	})
+
+	return nil
+}
+
+// InviteMember emails an invitation to join the organisation. The invitee
+// does not need an account yet.
+func (s *OrganizationService) InviteMember(ctx context.Context, userID uuid.UUID, stringID string, email string, role orgapp.Role) (*orgapp.Invitation, error) {
+	orgID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+
+	actorRole, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == orgapp.RoleOwner && actorRole != orgapp.RoleOwner {
+		return nil, ErrInsufficientRole
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	var existing int64
+	s.db.WithContext(ctx).Model(&models.OrganizationMembership{}).
+		Joins("JOIN users ON users.id = organization_memberships.user_id").
+		Where("organization_memberships.organization_id = ? AND LOWER(users.email) = ?", orgID, email).
+		Count(&existing)
+
+	if existing > 0 {
+		return nil, ErrAlreadyMember
+	}
+
+	var org models.Organization
+	if result := s.db.WithContext(ctx).First(&org, orgID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.OrganizationInvitation{
+		OrganizationID: orgID,
+		InvitedByID:    userID,
+		Email:          email,
+		Role:           string(role),
+		TokenHash:      hashToken(token),
+		ExpiresAt:      time.Now().Add(invitationExpiration),
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(invitation); result.Error != nil {
+			return fmt.Errorf("failed to create invitation: %w", result.Error)
+		}
+
+		return jobs.EnqueueEmail(tx, mail.Message{
+			To:      []string{email},
+			Subject: fmt.Sprintf("You have been invited to %s on share-docs", org.Name),
+			Body: fmt.Sprintf(
+				"You have been invited to join %s as %s.\n\nAccept the invitation: %s/invitations/accept?token=%s\n\nThe invitation expires on %s.\n",
+				org.Name, role, s.baseURL, token, invitation.ExpiresAt.Format(time.RFC1123),
+			),
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	i := orgapp.ToAppInvitation(*invitation)
+	return &i, nil
+}
+
+func (s *OrganizationService) ListInvitations(ctx context.Context, userID uuid.UUID, stringID string) ([]orgapp.Invitation, error) {
+	orgID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	if _, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	var modelInvitations []models.OrganizationInvitation
+
+	result := s.db.WithContext(ctx).Where("organization_id = ? AND accepted_at IS NULL", orgID).Order("created_at DESC").Find(&modelInvitations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	invitations := make([]orgapp.Invitation, 0, len(modelInvitations))
+	for _, mi := range modelInvitations {
+		invitations = append(invitations, orgapp.ToAppInvitation(mi))
+	}
+
+	return invitations, nil
+}
+
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, userID uuid.UUID, stringID string, invitationStringID string) error {
+	orgID, invitationID, err := parseOrgAndMember(stringID, invitationStringID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.RequireRole(ctx, userID, orgID, orgapp.RoleAdmin); err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Where("id = ? AND organization_id = ? AND accepted_at IS NULL", invitationID, orgID).
+		Delete(&models.OrganizationInvitation{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation adds the user to the organisation the invitation is for.
+// The user's email has to match the invited one.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, userID uuid.UUID, token string) (*orgapp.Organization, error) {
+	var invitation models.OrganizationInvitation
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND accepted_at IS NULL", hashToken(token)).
+			First(&invitation)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				return ErrInvitationNotFound
+			}
+			return result.Error
+		}
+
+		if time.Now().After(invitation.ExpiresAt) {
+			return ErrInvitationExpired
+		}
+
+		var user models.User
+		if result := tx.First(&user, userID); result.Error != nil {
+			return result.Error
+		}
+
+		if !strings.EqualFold(user.Email, invitation.Email) {
+			return ErrInvitationEmailMismatch
+		}
+
+		var existing int64
+		tx.Model(&models.OrganizationMembership{}).
+			Where("organization_id = ? AND user_id = ?", invitation.OrganizationID, userID).
+			Count(&existing)
+		if existing > 0 {
+			return ErrAlreadyMember
+		}
+
+		if result := tx.Model(&invitation).Update("accepted_at", time.Now()); result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Create(&models.OrganizationMembership{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+			Role:           invitation.Role,
+		}).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOrganization(ctx, userID, invitation.OrganizationID.String())
+}
+
+// RequireRole returns the user's role in the organisation if it grants at
+// least min. Non-members get ErrOrganizationNotFound so organisation IDs
+// can't be probed.
+func (s *OrganizationService) RequireRole(ctx context.Context, userID uuid.UUID, orgID uuid.UUID, min orgapp.Role) (orgapp.Role, error) {
+	var membership models.OrganizationMembership
+
+	result := s.db.WithContext(ctx).Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return "", ErrOrganizationNotFound
+		}
+		return "", result.Error
+	}
+
+	role := orgapp.Role(membership.Role)
+	if !role.AtLeast(min) {
+		return role, ErrInsufficientRole
+	}
+
+	return role, nil
+}
+
+func (s *OrganizationService) lockMembership(tx *gorm.DB, orgID, memberID uuid.UUID) (*models.OrganizationMembership, error) {
+	var membership models.OrganizationMembership
+
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = ? AND user_id = ?", orgID, memberID).
+		First(&membership)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrMemberNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &membership, nil
+}
+
+func (s *OrganizationService) ensureAnotherOwner(tx *gorm.DB, orgID, exceptUserID uuid.UUID) error {
+	var owners int64
+
+	result := tx.Model(&models.OrganizationMembership{}).
+		Where("organization_id = ? AND role = ? AND user_id <> ?", orgID, string(orgapp.RoleOwner), exceptUserID).
+		Count(&owners)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if owners == 0 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+func parseOrgAndMember(orgStringID, memberStringID string) (uuid.UUID, uuid.UUID, error) {
+	orgID, err := uuid.Parse(orgStringID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidId
+	}
+
+	memberID, err := uuid.Parse(memberStringID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidId
+	}
+
+	return orgID, memberID, nil
+}
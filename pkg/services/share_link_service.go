@@ -0,0 +1,570 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/linkapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/metrics"
+	"share-docs/pkg/util"
+	"share-docs/pkg/watermark"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrShareLinkNotFound    = errors.New("share link not found")
+	ErrShareLinkExpired     = errors.New("share link has expired")
+	ErrLinkPasswordRequired = errors.New("share link requires a password")
+	ErrInvalidLinkPassword  = errors.New("invalid share link password")
+	ErrInvalidViewToken     = errors.New("invalid or expired view token")
+	ErrShareLinkExhausted   = errors.New("share link has reached its view limit")
+	ErrDownloadNotAllowed   = errors.New("share link is view-only")
+	ErrPreviewNotAvailable  = errors.New("document type cannot be previewed while downloads are off")
+)
+
+// viewTokenExpiration bounds how long a viewer can keep fetching the file and
+// reporting dwell times after opening a link.
+const viewTokenExpiration = 12 * time.Hour
+
+type ShareLinkServiceInterface interface {
+	CreateLink(ctx context.Context, userID uuid.UUID, documentID string, req linkapp.CreateShareLink) (*linkapp.CreatedShareLink, error)
+	ListLinks(ctx context.Context, userID uuid.UUID, documentID string) ([]linkapp.ShareLink, error)
+	UpdateLink(ctx context.Context, userID uuid.UUID, linkID string, req linkapp.UpdateShareLink) (*linkapp.ShareLink, error)
+	DeleteLink(ctx context.Context, userID uuid.UUID, linkID string) error
+
+	RequestEmailCode(ctx context.Context, token string, email string) error
+	LinkAgreement(ctx context.Context, token string) (*linkapp.Agreement, error)
+	OpenAgreementFile(ctx context.Context, token string) (*documentapp.Document, error)
+	Access(ctx context.Context, token string, req linkapp.AccessRequest, visitor linkapp.Visitor) (*linkapp.LinkAccess, error)
+	OpenFile(ctx context.Context, token string, viewToken string, visitor linkapp.Visitor, download bool) (*linkapp.SharedFile, error)
+}
+
+type ShareLinkService struct {
+	db         *gorm.DB
+	auditor    audit.Recorder
+	bcryptCost int
+	baseURL    string
+}
+
+func NewShareLinkService(db *gorm.DB, auditor audit.Recorder, baseURL string) *ShareLinkService {
+	return &ShareLinkService{
+		db:         db,
+		auditor:    auditor,
+		bcryptCost: 5,
+		baseURL:    baseURL,
+	}
+}
+
+// CreateLink creates a link to a document the user can edit. The token is
+// only returned here.
+func (s *ShareLinkService) CreateLink(ctx context.Context, userID uuid.UUID, documentID string, req linkapp.CreateShareLink) (*linkapp.CreatedShareLink, error) {
+	document, err := findEditableDocument(s.db, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	allowlist, err := normalizeAllowlist(req.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.ShareLink{
+		DocumentID:   document.ID,
+		CreatedByID:  userID,
+		Name:         req.Name,
+		TokenHash:    hashToken(token),
+		ExpiresAt:    req.ExpiresAt,
+		RequireEmail: req.RequireEmail || req.VerifyEmail,
+		VerifyEmail:  req.VerifyEmail,
+		Allowlist:    allowlist,
+
+		AllowDownload: req.AllowDownload == nil || *req.AllowDownload,
+		MaxViews:      req.MaxViews,
+		Watermark:     req.Watermark,
+	}
+
+	if req.AgreementID != nil {
+		link.AgreementID, err = findOwnAgreementID(s.db, userID, *req.AgreementID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Password != nil && *req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), s.bcryptCost)
+		if err != nil {
+			return nil, err
+		}
+
+		passwordHash := string(hash)
+		link.PasswordHash = &passwordHash
+	}
+
+	if result := s.db.WithContext(ctx).Create(link); result.Error != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", result.Error)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionLinkCreate,
+		TargetType: audit.TargetShareLink,
+		TargetID:   link.ID.String(),
+		Metadata:   map[string]any{"document_id": link.DocumentID},
+	})
+
+	return &linkapp.CreatedShareLink{
+		ShareLink: linkapp.ToAppShareLink(*link),
+		Token:     token,
+		URL:       fmt.Sprintf("%s/shared/%s", s.baseURL, token),
+	}, nil
+}
+
+func (s *ShareLinkService) ListLinks(ctx context.Context, userID uuid.UUID, documentID string) ([]linkapp.ShareLink, error) {
+	document, err := findEditableDocument(s.db, userID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var modelLinks []models.ShareLink
+
+	result := s.db.WithContext(ctx).Where("document_id = ?", document.ID).Order("created_at DESC").Find(&modelLinks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var counts []struct {
+		ShareLinkID uuid.UUID
+		Views       int64
+	}
+
+	result = s.db.WithContext(ctx).Model(&models.LinkView{}).
+		Select("share_link_id, COUNT(*) AS views").
+		Where("document_id = ?", document.ID).
+		Group("share_link_id").
+		Scan(&counts)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	links := make([]linkapp.ShareLink, 0, len(modelLinks))
+	for _, ml := range modelLinks {
+		l := linkapp.ToAppShareLink(ml)
+		for _, c := range counts {
+			if c.ShareLinkID == ml.ID {
+				l.Views = c.Views
+			}
+		}
+		links = append(links, l)
+	}
+
+	return links, nil
+}
+
+func (s *ShareLinkService) UpdateLink(ctx context.Context, userID uuid.UUID, linkID string, req linkapp.UpdateShareLink) (*linkapp.ShareLink, error) {
+	link, err := findEditableLink(s.db, userID, linkID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := linkapp.ToAppShareLink(*link)
+
+	updates := map[string]any{}
+
+	if req.Name != nil {
+		updates["name"] = *req.Name
+		link.Name = req.Name
+	}
+
+	if req.Password != nil {
+		if *req.Password == "" {
+			updates["password_hash"] = nil
+			link.PasswordHash = nil
+		} else {
+			hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), s.bcryptCost)
+			if err != nil {
+				return nil, err
+			}
+
+			passwordHash := string(hash)
+			updates["password_hash"] = passwordHash
+			link.PasswordHash = &passwordHash
+		}
+	}
+
+	if req.RemoveExpiry {
+		updates["expires_at"] = nil
+		link.ExpiresAt = nil
+	} else if req.ExpiresAt != nil {
+		updates["expires_at"] = *req.ExpiresAt
+		link.ExpiresAt = req.ExpiresAt
+	}
+
+	if req.RequireEmail != nil {
+		updates["require_email"] = *req.RequireEmail
+		link.RequireEmail = *req.RequireEmail
+	}
+
+	if req.VerifyEmail != nil {
+		updates["verify_email"] = *req.VerifyEmail
+		link.VerifyEmail = *req.VerifyEmail
+	}
+
+	if req.Allowlist != nil {
+		allowlist, err := normalizeAllowlist(*req.Allowlist)
+		if err != nil {
+			return nil, err
+		}
+
+		updates["allowlist"] = allowlist
+		link.Allowlist = allowlist
+	}
+
+	if req.AllowDownload != nil {
+		updates["allow_download"] = *req.AllowDownload
+		link.AllowDownload = *req.AllowDownload
+	}
+
+	if req.MaxViews != nil {
+		if *req.MaxViews == 0 {
+			updates["max_views"] = nil
+			link.MaxViews = nil
+		} else {
+			updates["max_views"] = *req.MaxViews
+			link.MaxViews = req.MaxViews
+		}
+	}
+
+	if req.Watermark != nil {
+		updates["watermark"] = *req.Watermark
+		link.Watermark = *req.Watermark
+	}
+
+	if req.AgreementID != nil {
+		if *req.AgreementID == "" {
+			updates["agreement_id"] = nil
+			link.AgreementID = nil
+		} else {
+			agreementID, err := findOwnAgreementID(s.db, userID, *req.AgreementID)
+			if err != nil {
+				return nil, err
+			}
+
+			updates["agreement_id"] = *agreementID
+			link.AgreementID = agreementID
+		}
+	}
+
+	if result := s.db.WithContext(ctx).Model(link).Updates(updates); result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	l := linkapp.ToAppShareLink(*link)
+
+	entry := audit.Entry{
+		Action:     audit.ActionLinkUpdate,
+		TargetType: audit.TargetShareLink,
+		TargetID:   link.ID.String(),
+		Changes:    audit.Diff(before, l),
+	}
+
+	// The hash never goes in the log, only that the password changed
+	if req.Password != nil {
+		entry.Metadata = map[string]any{"password_changed": true}
+	}
+
+	s.auditor.Record(ctx, entry)
+	return &l, nil
+}
+
+func (s *ShareLinkService) DeleteLink(ctx context.Context, userID uuid.UUID, linkID string) error {
+	link, err := findEditableLink(s.db, userID, linkID)
+	if err != nil {
+		return err
+	}
+
+	if result := s.db.WithContext(ctx).Delete(link); result.Error != nil {
+		return result.Error
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionLinkDelete,
+		TargetType: audit.TargetShareLink,
+		TargetID:   link.ID.String(),
+		Metadata:   map[string]any{"document_id": link.DocumentID},
+	})
+
+	return nil
+}
+
+// Access opens a link for a viewer, checking its gates, and records the view
+// along with the viewer's acceptance of the link's agreement. Every attempt
+// is audited, turned away or not.
+func (s *ShareLinkService) Access(ctx context.Context, token string, req linkapp.AccessRequest, visitor linkapp.Visitor) (*linkapp.LinkAccess, error) {
+	access, link, err := s.access(ctx, token, req, visitor)
+
+	entry := audit.Entry{
+		Action:     audit.ActionLinkAccess,
+		TargetType: audit.TargetShareLink,
+	}
+
+	metadata := map[string]any{}
+
+	if link != nil {
+		entry.TargetID = link.ID.String()
+		metadata["document_id"] = link.DocumentID
+	}
+
+	if req.Email != "" {
+		metadata["email"] = req.Email
+	}
+
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		metadata["reason"] = err.Error()
+	}
+
+	entry.Metadata = metadata
+	s.auditor.Record(ctx, entry)
+
+	return access, err
+}
+
+func (s *ShareLinkService) access(ctx context.Context, token string, req linkapp.AccessRequest, visitor linkapp.Visitor) (*linkapp.LinkAccess, *models.ShareLink, error) {
+	link, err := s.activeLink(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	viewerEmail, verified, err := s.checkEmailGate(ctx, link, req.Email, req.Code)
+	if err != nil {
+		return nil, link, err
+	}
+
+	if link.PasswordHash != nil {
+		if req.Password == "" {
+			return nil, link, ErrLinkPasswordRequired
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(*link.PasswordHash), []byte(req.Password)) != nil {
+			return nil, link, ErrInvalidLinkPassword
+		}
+	}
+
+	acceptance, err := checkAgreement(link, req, viewerEmail, visitor)
+	if err != nil {
+		return nil, link, err
+	}
+
+	viewToken, err := randomToken()
+	if err != nil {
+		return nil, link, err
+	}
+
+	ip := anonymizeIP(visitor.IPAddress)
+
+	view := &models.LinkView{
+		ShareLinkID:   link.ID,
+		DocumentID:    link.DocumentID,
+		TokenHash:     hashToken(viewToken),
+		VisitorID:     hashToken(ip + "|" + visitor.UserAgent),
+		ViewerEmail:   viewerEmail,
+		EmailVerified: verified,
+		IPAddress:     ip,
+		UserAgent:     util.Truncate(visitor.UserAgent, 255),
+		Referrer:      util.Truncate(visitor.Referrer, 1000),
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if link.MaxViews != nil {
+			// Lock the link so concurrent viewers can't overshoot the limit
+			if result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.ShareLink{}, link.ID); result.Error != nil {
+				return result.Error
+			}
+
+			var views int64
+			if result := tx.Model(&models.LinkView{}).Where("share_link_id = ?", link.ID).Count(&views); result.Error != nil {
+				return result.Error
+			}
+
+			if views >= int64(*link.MaxViews) {
+				return ErrShareLinkExhausted
+			}
+		}
+
+		if result := tx.Create(view); result.Error != nil {
+			return result.Error
+		}
+
+		err := models.RecordEvent(tx, models.EventLinkViewed, link.ID, models.LinkViewedEvent{
+			ShareLinkID: link.ID,
+			DocumentID:  link.DocumentID,
+			ViewID:      view.ID,
+			ViewerEmail: viewerEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		if acceptance == nil {
+			return nil
+		}
+
+		acceptance.LinkViewID = &view.ID
+		return tx.Create(acceptance).Error
+	})
+
+	if err == ErrShareLinkExhausted {
+		return nil, link, err
+	}
+
+	if err != nil {
+		return nil, link, fmt.Errorf("failed to record link view: %w", err)
+	}
+
+	metrics.LinksViewed.Inc()
+
+	return &linkapp.LinkAccess{
+		Document:      documentapp.ToAppDocument(link.Document),
+		ViewID:        view.ID.String(),
+		ViewToken:     viewToken,
+		AllowDownload: link.AllowDownload,
+	}, link, nil
+}
+
+// OpenFile returns the document behind a link for a viewer who opened it,
+// with the watermark to stamp on it. Downloads are refused on view-only
+// links, which never serve the original: their preview is always stamped,
+// and documents that cannot be are refused.
+func (s *ShareLinkService) OpenFile(ctx context.Context, token string, viewToken string, visitor linkapp.Visitor, download bool) (*linkapp.SharedFile, error) {
+	link, err := s.activeLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if download && !link.AllowDownload {
+		return nil, ErrDownloadNotAllowed
+	}
+
+	view, err := findView(s.db, link.ID, viewToken)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &linkapp.SharedFile{
+		Document:      documentapp.ToAppDocument(link.Document),
+		AllowDownload: link.AllowDownload,
+	}
+
+	if !link.AllowDownload && !watermark.Supported(file.Document.MimeType) {
+		return nil, ErrPreviewNotAvailable
+	}
+
+	if link.Watermark || !link.AllowDownload {
+		viewer := "anonymous viewer"
+		if view.ViewerEmail != nil {
+			viewer = *view.ViewerEmail
+		}
+
+		file.Watermark = fmt.Sprintf("%s  %s  %s", viewer, visitor.IPAddress, time.Now().UTC().Format(time.RFC3339))
+	}
+
+	return file, nil
+}
+
+// activeLink resolves a token to a link that has not expired.
+func (s *ShareLinkService) activeLink(ctx context.Context, token string) (*models.ShareLink, error) {
+	var link models.ShareLink
+
+	result := s.db.WithContext(ctx).Preload("Document.User").Preload("Agreement").Where("token_hash = ?", hashToken(token)).First(&link)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, result.Error
+	}
+
+	// Links of deleted documents are not preloaded
+	if link.Document.ID == uuid.Nil {
+		return nil, ErrShareLinkNotFound
+	}
+
+	if link.IsExpired(time.Now()) {
+		return nil, ErrShareLinkExpired
+	}
+
+	return &link, nil
+}
+
+// findEditableDocument loads a document the user can edit.
+func findEditableDocument(db *gorm.DB, userID uuid.UUID, stringID string) (*models.Document, error) {
+	documentID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var document models.Document
+
+	result := db.Where(editableDocuments(db, userID)).First(&document, documentID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &document, nil
+}
+
+// findEditableLink loads a link of a document the user can edit.
+func findEditableLink(db *gorm.DB, userID uuid.UUID, stringID string) (*models.ShareLink, error) {
+	linkID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var link models.ShareLink
+
+	result := db.Where("share_links.document_id IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).Model(&models.Document{}).Select("documents.id").Where(editableDocuments(db, userID)),
+	).First(&link, linkID)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &link, nil
+}
+
+// findView resolves a view token handed out by Access for the given link.
+func findView(db *gorm.DB, linkID uuid.UUID, viewToken string) (*models.LinkView, error) {
+	var view models.LinkView
+
+	result := db.Where("share_link_id = ? AND token_hash = ? AND created_at > ?",
+		linkID, hashToken(viewToken), time.Now().Add(-viewTokenExpiration)).
+		First(&view)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidViewToken
+		}
+		return nil, result.Error
+	}
+
+	return &view, nil
+}
@@ -1,56 +1,72 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"mime/multipart"
-	"os"
+	"share-docs/pkg/config"
 	"share-docs/pkg/logger"
+	"share-docs/pkg/metrics"
 	"share-docs/pkg/storage"
-	"share-docs/pkg/util"
+	"share-docs/pkg/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 type StorageService struct {
 	// TODO: expand
-	sb storage.StorageBackendInterface
+	sb      storage.StorageBackendInterface
+	backend string
 }
 
 type StorageServiceInterface interface {
-	UploadDocument(file multipart.File, object string) error
+	UploadDocument(ctx context.Context, file multipart.File, path string, filename string) (*storage.StorageObject, error)
 }
 
-func NewStorageService(storageType string, logger *logger.Logger) *StorageService {
-	supportedBackends := map[string]bool{
-		"local": true,
-		"gcp":   false,
-	}
-
+func NewStorageService(cfg config.Storage, logger *logger.Logger) *StorageService {
 	var sb storage.StorageBackendInterface
 
-	if !supportedBackends[storageType] {
-		panic(fmt.Sprintf("storage backend %s not supported", storageType))
-	} else if storageType == "local" {
-		path := util.MustGetEnv("STORAGE_LOCAL_PATH")
-
-		_, err := os.Stat(path)
-
-		if os.IsNotExist(err) {
-			panic(err)
-		}
-
-		sb = storage.NewLocalStorage(path, *logger)
+	switch cfg.Type {
+	case "local":
+		sb = storage.NewLocalStorage(cfg.LocalPath, *logger)
+	default:
+		panic(fmt.Sprintf("storage backend %s not supported", cfg.Type))
 	}
 
 	return &StorageService{
-		sb: sb,
+		sb:      sb,
+		backend: cfg.Type,
 	}
 }
 
-func (s *StorageService) UploadDocument(file multipart.File, path string, filename string) (*storage.StorageObject, error) {
+func (s *StorageService) UploadDocument(ctx context.Context, file multipart.File, path string, filename string) (*storage.StorageObject, error) {
+	_, span := tracing.Tracer().Start(ctx, "storage.upload")
+	span.SetAttributes(
+		attribute.String("storage.backend", s.backend),
+		attribute.String("storage.path", path),
+	)
+	defer span.End()
+
+	start := time.Now()
 	so, err := s.sb.Upload(file, path, filename)
 
+	metrics.StorageOperationDuration.WithLabelValues(s.backend, "upload").Observe(time.Since(start).Seconds())
+
 	if err != nil {
+		metrics.StorageOperationErrors.WithLabelValues(s.backend, "upload").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
+	span.SetAttributes(attribute.Int64("storage.size_bytes", so.FileSizeBytes))
+
 	return so, nil
 }
+
+// Probe checks that the storage backend accepts writes.
+func (s *StorageService) Probe() error {
+	return s.sb.Probe()
+}
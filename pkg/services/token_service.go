@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"share-docs/pkg/app/domain/sessionapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/auth"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/util"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidAccessToken  = errors.New("invalid access token")
+	ErrSessionRevoked      = errors.New("session has been signed out")
+)
+
+type TokenServiceInterface interface {
+	AuthenticateAccessToken(ctx context.Context, accessToken string) (*auth.Claims, error)
+	IssueTokenPair(ctx context.Context, userID uuid.UUID, email string, client sessionapp.ClientInfo) (*auth.TokenPair, error)
+	RotateRefreshToken(ctx context.Context, refreshToken string, client sessionapp.ClientInfo) (*auth.TokenPair, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]sessionapp.Session, error)
+	RevokeSession(ctx context.Context, userID uuid.UUID, sessionID string) error
+}
+
+type TokenService struct {
+	db      *gorm.DB
+	jwt     *auth.JWT
+	auditor audit.Recorder
+}
+
+func NewTokenService(db *gorm.DB, jwt *auth.JWT, auditor audit.Recorder) *TokenService {
+	return &TokenService{
+		db:      db,
+		jwt:     jwt,
+		auditor: auditor,
+	}
+}
+
+// IssueTokenPair starts a new session and refresh token family, e.g. on login.
+// The login is audited here, as every way of signing in ends with it.
+func (s *TokenService) IssueTokenPair(ctx context.Context, userID uuid.UUID, email string, client sessionapp.ClientInfo) (*auth.TokenPair, error) {
+	now := time.Now()
+
+	scopes, err := s.scopesForUser(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:     userID,
+		UserAgent:  util.Truncate(client.UserAgent, 512),
+		IPAddress:  util.Truncate(client.IPAddress, 64),
+		LastUsedAt: now,
+	}
+
+	rt := &models.RefreshToken{
+		UserID:    userID,
+		ExpiresAt: now.Add(auth.RefreshTokenExpiration),
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(session); result.Error != nil {
+			return result.Error
+		}
+
+		rt.FamilyID = session.ID
+		return tx.Create(rt).Error
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to persist refresh token: %w", err)
+	}
+
+	s.auditor.Record(ctx, audit.Entry{
+		Action:     audit.ActionLogin,
+		Actor:      &audit.Actor{Type: audit.ActorUser, UserID: &userID},
+		TargetType: audit.TargetSession,
+		TargetID:   session.ID.String(),
+	})
+
+	return s.jwt.GenerateTokenPair(userID, email, scopes, rt.FamilyID, rt.ID)
+}
+
+// AuthenticateAccessToken validates an access token and checks that its
+// session is still signed in, so logging out or revoking a session takes
+// effect before the token expires.
+func (s *TokenService) AuthenticateAccessToken(ctx context.Context, accessToken string) (*auth.Claims, error) {
+	claims, err := s.jwt.ValidateToken(accessToken, auth.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccessToken, err)
+	}
+
+	var session models.Session
+	result := s.db.WithContext(ctx).
+		Select("id", "revoked_at").
+		Where("id = ? AND user_id = ?", claims.SessionID, claims.UserID).
+		First(&session)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrSessionRevoked
+		}
+
+		return nil, fmt.Errorf("failed to look up session: %w", result.Error)
+	}
+
+	if session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	return claims, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new pair and records
+// the refresh, successful or not.
+func (s *TokenService) RotateRefreshToken(ctx context.Context, refreshToken string, client sessionapp.ClientInfo) (*auth.TokenPair, error) {
+	pair, rt, err := s.rotateRefreshToken(ctx, refreshToken, client)
+
+	entry := audit.Entry{
+		Action:     audit.ActionTokenRefresh,
+		TargetType: audit.TargetSession,
+	}
+
+	if rt != nil {
+		entry.TargetID = rt.FamilyID.String()
+		entry.Actor = &audit.Actor{Type: audit.ActorUser, UserID: &rt.UserID}
+	}
+
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Metadata = map[string]any{"reason": err.Error()}
+	}
+
+	s.auditor.Record(ctx, entry)
+	return pair, err
+}
+
+// rotateRefreshToken exchanges a refresh token for a new pair in the same
+// family. Presenting a token that was already rotated or revoked is treated
+// as theft and revokes the whole family.
+func (s *TokenService) rotateRefreshToken(ctx context.Context, refreshToken string, client sessionapp.ClientInfo) (*auth.TokenPair, *models.RefreshToken, error) {
+	claims, rt, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pair *auth.TokenPair
+	reused := false
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, rt.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		now := time.Now()
+
+		if current.RevokedAt != nil || current.ReplacedByID != nil {
+			reused = true
+			return s.revokeFamily(tx, current.FamilyID, now)
+		}
+
+		if !current.IsUsable(now) {
+			return ErrInvalidRefreshToken
+		}
+
+		next := &models.RefreshToken{
+			UserID:    current.UserID,
+			FamilyID:  current.FamilyID,
+			ExpiresAt: now.Add(auth.RefreshTokenExpiration),
+		}
+
+		if result := tx.Create(next); result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Model(&current).Updates(map[string]any{
+			"replaced_by_id": next.ID,
+			"revoked_at":     now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Model(&models.Session{}).Where("id = ?", current.FamilyID).Updates(map[string]any{
+			"user_agent":   util.Truncate(client.UserAgent, 512),
+			"ip_address":   util.Truncate(client.IPAddress, 64),
+			"last_used_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		scopes, err := s.scopesForUser(tx, current.UserID)
+		if err != nil {
+			return err
+		}
+
+		pair, err = s.jwt.GenerateTokenPair(current.UserID, claims.Email, scopes, next.FamilyID, next.ID)
+		return err
+	})
+
+	if reused {
+		return nil, rt, ErrRefreshTokenReused
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			return nil, rt, err
+		}
+		return nil, rt, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return pair, rt, nil
+}
+
+// RevokeRefreshToken signs out the session the refresh token belongs to.
+func (s *TokenService) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	_, rt, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return s.revokeFamily(s.db, rt.FamilyID, time.Now())
+}
+
+// RevokeAllForUser signs out every session of the user.
+func (s *TokenService) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// ListSessions returns the sessions of the user that still hold a usable
+// refresh token, most recently used first.
+func (s *TokenService) ListSessions(ctx context.Context, userID uuid.UUID) ([]sessionapp.Session, error) {
+	var modelSessions []models.Session
+
+	result := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("EXISTS (SELECT 1 FROM refresh_tokens rt WHERE rt.family_id = sessions.id AND rt.revoked_at IS NULL AND rt.expires_at > ?)", time.Now()).
+		Order("last_used_at DESC").
+		Find(&modelSessions)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	sessions := make([]sessionapp.Session, 0, len(modelSessions))
+	for _, ms := range modelSessions {
+		sessions = append(sessions, sessionapp.ToAppSession(ms))
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs out a single session of the user.
+func (s *TokenService) RevokeSession(ctx context.Context, userID uuid.UUID, sessionStringID string) error {
+	sessionID, err := uuid.Parse(sessionStringID)
+	if err != nil {
+		return ErrInvalidId
+	}
+
+	var session models.Session
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return ErrSessionNotFound
+		}
+
+		return result.Error
+	}
+
+	return s.revokeFamily(s.db, session.ID, time.Now())
+}
+
+func (s *TokenService) lookup(ctx context.Context, refreshToken string) (*auth.Claims, *models.RefreshToken, error) {
+	claims, err := s.jwt.ValidateToken(refreshToken, auth.RefreshToken)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var rt models.RefreshToken
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", jti, claims.UserID).First(&rt)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+
+		return nil, nil, result.Error
+	}
+
+	return claims, &rt, nil
+}
+
+func (s *TokenService) revokeFamily(tx *gorm.DB, familyID uuid.UUID, at time.Time) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Model(&models.Session{}).
+			Where("id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", at).Error
+	})
+}
+
+func (s *TokenService) scopesForUser(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	var user models.User
+
+	result := tx.Select("id", "is_admin").First(&user, userID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrUserNotFound
+		}
+
+		return nil, result.Error
+	}
+
+	return auth.ScopesForUser(user.IsAdmin), nil
+}
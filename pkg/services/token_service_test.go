@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"share-docs/pkg/app/domain/sessionapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/auth"
+	"share-docs/pkg/config"
+	"share-docs/pkg/db"
+	"share-docs/pkg/db/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// testDB migrates and returns the Postgres database TEST_DATABASE_URL points
+// at, skipping the test when it isn't set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	return gormDB
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	gormDB := testDB(t)
+	ctx := context.Background()
+
+	jwt, err := auth.NewJWT(config.JWT{AccessTokenSecret: "access", RefreshTokenSecret: "refresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{Email: uuid.NewString() + "@example.com", Password: "unused"}
+	if err := gormDB.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	s := NewTokenService(gormDB, jwt, audit.Discard{})
+	client := sessionapp.ClientInfo{IPAddress: "192.0.2.1", UserAgent: "test"}
+
+	first, err := s.IssueTokenPair(ctx, user.ID, user.Email, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := s.RotateRefreshToken(ctx, first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("first rotation failed: %v", err)
+	}
+
+	if _, err := s.AuthenticateAccessToken(ctx, second.AccessToken); err != nil {
+		t.Fatalf("rotated access token rejected: %v", err)
+	}
+
+	// Replaying the rotated token revokes the whole family, including the
+	// pair the legitimate client got
+	steps := []struct {
+		name         string
+		refreshToken string
+		want         error
+	}{
+		{"replayed token", first.RefreshToken, ErrRefreshTokenReused},
+		{"latest token after replay", second.RefreshToken, ErrRefreshTokenReused},
+	}
+
+	for _, step := range steps {
+		if _, err := s.RotateRefreshToken(ctx, step.refreshToken, client); !errors.Is(err, step.want) {
+			t.Errorf("%s: got %v, want %v", step.name, err, step.want)
+		}
+	}
+
+	if _, err := s.AuthenticateAccessToken(ctx, second.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("access token of the revoked family: got %v, want %v", err, ErrSessionRevoked)
+	}
+
+	sessions, err := s.ListSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 0 {
+		t.Errorf("got %d active sessions after reuse, want 0", len(sessions))
+	}
+}
@@ -1,8 +1,30 @@
 package services
 
-import "errors"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
 
 var (
 	ErrInvalidId      = errors.New("Invalid ID (should be uuid.v4)")
 	ErrFailedToUpdate = errors.New("Failed to update")
 )
+
+// randomToken returns a 256-bit random hex token for links and one-time use
+// state.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is how tokens handed out by email or links are stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,516 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/uploadapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/mail"
+	"share-docs/pkg/storage"
+	"share-docs/pkg/util"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUploadRequestNotFound  = errors.New("upload request not found")
+	ErrUploadRequestExpired   = errors.New("upload request has expired")
+	ErrUploadPasswordRequired = errors.New("upload request requires a password")
+	ErrInvalidUploadPassword  = errors.New("invalid upload request password")
+	ErrUploadTooLarge         = errors.New("file is larger than the upload request allows")
+	ErrUploadTypeNotAllowed   = errors.New("file type is not allowed by the upload request")
+	ErrInvalidUploadType      = errors.New("invalid allowed type")
+)
+
+type UploadRequestServiceInterface interface {
+	CreateUploadRequest(ctx context.Context, userID uuid.UUID, req uploadapp.CreateUploadRequest) (*uploadapp.CreatedUploadRequest, error)
+	ListUploadRequests(ctx context.Context, userID uuid.UUID) ([]uploadapp.UploadRequest, error)
+	GetUploadRequest(ctx context.Context, userID uuid.UUID, requestID string) (*uploadapp.UploadRequest, error)
+	UpdateUploadRequest(ctx context.Context, userID uuid.UUID, requestID string, req uploadapp.UpdateUploadRequest) (*uploadapp.UploadRequest, error)
+	DeleteUploadRequest(ctx context.Context, userID uuid.UUID, requestID string) error
+	ListReceivedDocuments(ctx context.Context, userID uuid.UUID, requestID string, page, limit int) ([]documentapp.Document, int64, error)
+
+	PublicUploadRequest(ctx context.Context, token string) (*uploadapp.PublicUploadRequest, error)
+	AcceptUpload(ctx context.Context, token string, upload uploadapp.Upload) (*uploadapp.Target, error)
+	CompleteUpload(ctx context.Context, target *uploadapp.Target, o storage.StorageObject) (*uploadapp.ReceivedFile, error)
+}
+
+type UploadRequestService struct {
+	db         *gorm.DB
+	documents  *DocumentService
+	bcryptCost int
+	baseURL    string
+}
+
+func NewUploadRequestService(db *gorm.DB, documents *DocumentService, baseURL string) *UploadRequestService {
+	return &UploadRequestService{
+		db:         db,
+		documents:  documents,
+		bcryptCost: 5,
+		baseURL:    baseURL,
+	}
+}
+
+// CreateUploadRequest creates an upload link into the user's personal space
+// or an organisation, optionally into a folder. The token is only returned
+// here.
+func (s *UploadRequestService) CreateUploadRequest(ctx context.Context, userID uuid.UUID, req uploadapp.CreateUploadRequest) (*uploadapp.CreatedUploadRequest, error) {
+	placement := documentapp.Placement{}
+
+	if req.OrganizationID != nil {
+		orgID, err := uuid.Parse(*req.OrganizationID)
+		if err != nil {
+			return nil, ErrInvalidId
+		}
+		placement.OrganizationID = &orgID
+	}
+
+	if req.FolderID != nil {
+		folderID, err := uuid.Parse(*req.FolderID)
+		if err != nil {
+			return nil, ErrInvalidId
+		}
+		placement.FolderID = &folderID
+	}
+
+	if err := s.documents.checkPlacement(ctx, userID, placement); err != nil {
+		return nil, err
+	}
+
+	allowedTypes, err := normalizeUploadTypes(req.AllowedTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	request := &models.UploadRequest{
+		UserID:         userID,
+		OrganizationID: placement.OrganizationID,
+		FolderID:       placement.FolderID,
+		Name:           strings.TrimSpace(req.Name),
+		Message:        req.Message,
+		TokenHash:      hashToken(token),
+		ExpiresAt:      req.ExpiresAt,
+		MaxFileSize:    req.MaxFileSize,
+		AllowedTypes:   allowedTypes,
+	}
+
+	if req.Password != nil && *req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), s.bcryptCost)
+		if err != nil {
+			return nil, err
+		}
+
+		passwordHash := string(hash)
+		request.PasswordHash = &passwordHash
+	}
+
+	if result := s.db.WithContext(ctx).Omit("User").Create(request); result.Error != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", result.Error)
+	}
+
+	return &uploadapp.CreatedUploadRequest{
+		UploadRequest: uploadapp.ToAppUploadRequest(*request),
+		Token:         token,
+		URL:           fmt.Sprintf("%s/uploads/%s", s.baseURL, token),
+	}, nil
+}
+
+func (s *UploadRequestService) ListUploadRequests(ctx context.Context, userID uuid.UUID) ([]uploadapp.UploadRequest, error) {
+	var modelRequests []models.UploadRequest
+
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&modelRequests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var counts []struct {
+		UploadRequestID uuid.UUID
+		Uploads         int64
+	}
+
+	result = s.db.WithContext(ctx).Model(&models.Document{}).
+		Select("upload_request_id, COUNT(*) AS uploads").
+		Where("upload_request_id IN (?)", s.db.Model(&models.UploadRequest{}).Select("id").Where("user_id = ?", userID)).
+		Group("upload_request_id").
+		Scan(&counts)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	requests := make([]uploadapp.UploadRequest, 0, len(modelRequests))
+	for _, mr := range modelRequests {
+		r := uploadapp.ToAppUploadRequest(mr)
+		for _, c := range counts {
+			if c.UploadRequestID == mr.ID {
+				r.Uploads = c.Uploads
+			}
+		}
+		requests = append(requests, r)
+	}
+
+	return requests, nil
+}
+
+func (s *UploadRequestService) GetUploadRequest(ctx context.Context, userID uuid.UUID, requestID string) (*uploadapp.UploadRequest, error) {
+	request, err := s.ownUploadRequest(ctx, userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := uploadapp.ToAppUploadRequest(*request)
+	s.db.WithContext(ctx).Model(&models.Document{}).Where("upload_request_id = ?", request.ID).Count(&r.Uploads)
+
+	return &r, nil
+}
+
+func (s *UploadRequestService) UpdateUploadRequest(ctx context.Context, userID uuid.UUID, requestID string, req uploadapp.UpdateUploadRequest) (*uploadapp.UploadRequest, error) {
+	request, err := s.ownUploadRequest(ctx, userID, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+
+	if req.Name != nil {
+		request.Name = strings.TrimSpace(*req.Name)
+		updates["name"] = request.Name
+	}
+
+	if req.Message != nil {
+		request.Message = emptyToNil(*req.Message)
+		updates["message"] = request.Message
+	}
+
+	if req.Password != nil {
+		if *req.Password == "" {
+			request.PasswordHash = nil
+			updates["password_hash"] = nil
+		} else {
+			hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), s.bcryptCost)
+			if err != nil {
+				return nil, err
+			}
+
+			passwordHash := string(hash)
+			request.PasswordHash = &passwordHash
+			updates["password_hash"] = passwordHash
+		}
+	}
+
+	if req.RemoveExpiry {
+		request.ExpiresAt = nil
+		updates["expires_at"] = nil
+	} else if req.ExpiresAt != nil {
+		request.ExpiresAt = req.ExpiresAt
+		updates["expires_at"] = *req.ExpiresAt
+	}
+
+	if req.MaxFileSize != nil {
+		if *req.MaxFileSize == 0 {
+			request.MaxFileSize = nil
+			updates["max_file_size"] = nil
+		} else {
+			request.MaxFileSize = req.MaxFileSize
+			updates["max_file_size"] = *req.MaxFileSize
+		}
+	}
+
+	if req.AllowedTypes != nil {
+		allowedTypes, err := normalizeUploadTypes(*req.AllowedTypes)
+		if err != nil {
+			return nil, err
+		}
+
+		request.AllowedTypes = allowedTypes
+		updates["allowed_types"] = allowedTypes
+	}
+
+	if result := s.db.WithContext(ctx).Model(request).Updates(updates); result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	r := uploadapp.ToAppUploadRequest(*request)
+	return &r, nil
+}
+
+// DeleteUploadRequest closes the link. Documents received through it stay.
+func (s *UploadRequestService) DeleteUploadRequest(ctx context.Context, userID uuid.UUID, requestID string) error {
+	request, err := s.ownUploadRequest(ctx, userID, requestID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Delete(request).Error
+}
+
+// ListReceivedDocuments lists the documents received through a request that
+// the user can still read, newest first.
+func (s *UploadRequestService) ListReceivedDocuments(ctx context.Context, userID uuid.UUID, requestID string, page, limit int) ([]documentapp.Document, int64, error) {
+	request, err := s.ownUploadRequest(ctx, userID, requestID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.Document{}).
+		Where("documents.upload_request_id = ?", request.ID).
+		Where(readableDocuments(s.db, userID))
+
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelDocuments []models.Document
+
+	result := query.Preload("User").Order("documents.created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&modelDocuments)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	documents := make([]documentapp.Document, 0, len(modelDocuments))
+	for _, md := range modelDocuments {
+		documents = append(documents, documentapp.ToAppDocument(md))
+	}
+
+	return documents, total, nil
+}
+
+// PublicUploadRequest returns what the person uploading needs to know: who
+// asks, for what, and the limits.
+func (s *UploadRequestService) PublicUploadRequest(ctx context.Context, token string) (*uploadapp.PublicUploadRequest, error) {
+	request, err := s.activeUploadRequest(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	r := uploadapp.ToAppPublicUploadRequest(*request)
+	return &r, nil
+}
+
+// AcceptUpload checks a file against the request's password and limits and
+// tells where to store it. The document is created by CompleteUpload once the
+// file is stored.
+func (s *UploadRequestService) AcceptUpload(ctx context.Context, token string, upload uploadapp.Upload) (*uploadapp.Target, error) {
+	request, err := s.activeUploadRequest(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.PasswordHash != nil {
+		if upload.Password == "" {
+			return nil, ErrUploadPasswordRequired
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(*request.PasswordHash), []byte(upload.Password)) != nil {
+			return nil, ErrInvalidUploadPassword
+		}
+	}
+
+	if upload.Size > uploadapp.MaxFileSize(*request) {
+		return nil, ErrUploadTooLarge
+	}
+
+	// Stored file names are built around the extension
+	if filepath.Ext(upload.Filename) == "" || !uploadTypeAllowed(request.AllowedTypes, upload.Filename, upload.MimeType) {
+		return nil, ErrUploadTypeNotAllowed
+	}
+
+	placement := documentapp.Placement{
+		OrganizationID: request.OrganizationID,
+		FolderID:       request.FolderID,
+	}
+
+	// The folder may have gone since the request was made
+	if err := s.documents.checkPlacement(ctx, request.UserID, placement); err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("%s/", request.UserID)
+	if request.OrganizationID != nil {
+		path = fmt.Sprintf("orgs/%s/", request.OrganizationID)
+	}
+
+	uploader := documentapp.Uploader{
+		Name:  uploaderName(upload.Uploader.Name),
+		Email: strings.ToLower(strings.TrimSpace(upload.Uploader.Email)),
+	}
+
+	if uploader.Name == "" {
+		uploader.Name = uploader.Email
+	}
+
+	return &uploadapp.Target{
+		RequestID:   request.ID,
+		RequestName: request.Name,
+		OwnerID:     request.UserID,
+		OwnerEmail:  request.User.Email,
+		Path:        path,
+		Placement:   placement,
+		Uploader:    uploader,
+	}, nil
+}
+
+// CompleteUpload files a stored upload as a document of the request owner
+// and queues an email telling them.
+func (s *UploadRequestService) CompleteUpload(ctx context.Context, target *uploadapp.Target, o storage.StorageObject) (*uploadapp.ReceivedFile, error) {
+	received := &uploadapp.ReceivedFile{
+		Filename:   filepath.Base(o.Path),
+		Size:       o.FileSizeBytes,
+		ReceivedAt: time.Now(),
+	}
+
+	extra := func(md *models.Document) {
+		md.UploadRequestID = &target.RequestID
+		md.UploaderName = &target.Uploader.Name
+		md.UploaderEmail = &target.Uploader.Email
+	}
+
+	notify := func(tx *gorm.DB, document *models.Document) error {
+		return jobs.EnqueueEmail(tx, mail.Message{
+			To:      []string{target.OwnerEmail},
+			Subject: fmt.Sprintf("%s uploaded a file to %s", target.Uploader.Name, target.RequestName),
+			Body: fmt.Sprintf(
+				"%s <%s> uploaded %s (%d bytes) through your upload request %s.\n\nOpen it: %s/docs/%s\n",
+				target.Uploader.Name, target.Uploader.Email, received.Filename, received.Size,
+				target.RequestName, s.baseURL, document.ID,
+			),
+		})
+	}
+
+	if _, err := s.documents.createDocument(ctx, target.OwnerID, o, target.Placement, extra, notify); err != nil {
+		return nil, err
+	}
+
+	return received, nil
+}
+
+// maxUploaderNameLength caps the uploader's name, in bytes, as it ends up in
+// the subject of the owner's email.
+const maxUploaderNameLength = 100
+
+// uploaderName cleans the name an anonymous uploader typed in. Control and
+// formatting characters, bidi overrides included, are dropped and runs of
+// whitespace folded, so the name cannot break or disguise the mail it is put in.
+func uploaderName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || unicode.In(r, unicode.Cf) {
+			return ' '
+		}
+		return r
+	}, name)
+
+	name = strings.Join(strings.Fields(name), " ")
+	return strings.TrimSpace(util.Truncate(name, maxUploaderNameLength))
+}
+
+// activeUploadRequest resolves a token to a request that has not expired.
+func (s *UploadRequestService) activeUploadRequest(ctx context.Context, token string) (*models.UploadRequest, error) {
+	var request models.UploadRequest
+
+	result := s.db.WithContext(ctx).Preload("User").Where("token_hash = ?", hashToken(token)).First(&request)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrUploadRequestNotFound
+		}
+		return nil, result.Error
+	}
+
+	if request.IsExpired(time.Now()) {
+		return nil, ErrUploadRequestExpired
+	}
+
+	return &request, nil
+}
+
+// ownUploadRequest loads a request created by the user.
+func (s *UploadRequestService) ownUploadRequest(ctx context.Context, userID uuid.UUID, stringID string) (*models.UploadRequest, error) {
+	requestID, err := uuid.Parse(stringID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var request models.UploadRequest
+
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&request, requestID)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrUploadRequestNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &request, nil
+}
+
+// normalizeUploadTypes validates allowed types, lowercases them and stores
+// extensions with their leading dot.
+func normalizeUploadTypes(types []string) (string, error) {
+	normalized := make([]string, 0, len(types))
+
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+
+		if strings.Contains(t, "/") {
+			if _, _, err := mime.ParseMediaType(t); err != nil || strings.ContainsAny(t, "; ") {
+				return "", fmt.Errorf("%w: %s", ErrInvalidUploadType, t)
+			}
+		} else {
+			t = "." + strings.TrimPrefix(t, ".")
+			if strings.ContainsAny(t[1:], "./ ") || len(t) == 1 {
+				return "", fmt.Errorf("%w: %s", ErrInvalidUploadType, t)
+			}
+		}
+
+		normalized = append(normalized, t)
+	}
+
+	return uploadapp.JoinTypes(normalized), nil
+}
+
+// uploadTypeAllowed reports whether a file matches the allowed types by its
+// sniffed MIME type or its extension. An empty list allows any type.
+func uploadTypeAllowed(allowedTypes string, filename string, mimeType string) bool {
+	types := uploadapp.SplitTypes(allowedTypes)
+	if len(types) == 0 {
+		return true
+	}
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	mimeType, _, _ = mime.ParseMediaType(mimeType)
+
+	for _, t := range types {
+		switch {
+		case strings.HasPrefix(t, "."):
+			if t == ext {
+				return true
+			}
+		case strings.HasSuffix(t, "/*"):
+			if strings.HasPrefix(mimeType, strings.TrimSuffix(t, "*")) {
+				return true
+			}
+		case t == mimeType:
+			return true
+		}
+	}
+
+	return false
+}
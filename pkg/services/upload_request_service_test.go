@@ -0,0 +1,28 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUploaderName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "  Ada Lovelace ", "Ada Lovelace"},
+		{"line breaks", "Ada\r\nBcc: x@example.com", "Ada Bcc: x@example.com"},
+		{"bidi override", "Ada‮gpj.exe", "Ada gpj.exe"},
+		{"only controls", "\x00\x07\n", ""},
+		{"capped", strings.Repeat("a", 300), strings.Repeat("a", maxUploaderNameLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uploaderName(tt.in); got != tt.want {
+				t.Errorf("uploaderName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
 	"share-docs/pkg/app/domain/userapp"
+	"share-docs/pkg/audit"
+	"share-docs/pkg/auth"
 	"share-docs/pkg/db/models"
+	"share-docs/pkg/metrics"
 	"strings"
 	"time"
 
@@ -24,34 +28,38 @@ var (
 	ErrAccountInactive    = errors.New("account is inactive")
 	ErrInvalidEmail       = errors.New("invalid email format")
 	ErrWeakPassword       = errors.New("password does not meet requirements")
+	ErrUnverifiedSSOEmail = errors.New("identity provider did not verify the email of an existing account")
 )
 
 type UserServiceInterface interface {
-	CreateUser(email, password, firstName, lastName string, birthDate *time.Time) (*userapp.User, error)
-	GetUserByID(userID string) (*userapp.User, error)
-	GetUserByEmail(email string) (*userapp.User, error)
-	LoginWithEmailPassword(email, password string) (*userapp.User, error)
+	CreateUser(ctx context.Context, email, password, firstName, lastName string, birthDate *time.Time) (*userapp.User, error)
+	GetUserByID(ctx context.Context, userID string) (*userapp.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*userapp.User, error)
+	LoginWithEmailPassword(ctx context.Context, email, password string) (*userapp.User, error)
+	ProvisionOIDCUser(ctx context.Context, identity auth.OIDCIdentity) (*userapp.User, error)
 }
 
 type UserService struct {
 	db                *gorm.DB
+	auditor           audit.Recorder
 	emailRegex        *regexp.Regexp
 	passwordMinLength int
 	bcryptCost        int
 }
 
-func NewUserService(db *gorm.DB) *UserService {
+func NewUserService(db *gorm.DB, auditor audit.Recorder) *UserService {
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
 	return &UserService{
 		db:                db,
+		auditor:           auditor,
 		emailRegex:        emailRegex,
 		passwordMinLength: 8,
 		bcryptCost:        5,
 	}
 }
 
-func (s *UserService) CreateUser(email, password, firstName, lastName string, birthDate *time.Time) (*userapp.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, email, password, firstName, lastName string, birthDate *time.Time) (*userapp.User, error) {
 	if err := s.validateEmail(email); err != nil {
 		return nil, err
 	}
@@ -61,7 +69,7 @@ func (s *UserService) CreateUser(email, password, firstName, lastName string, bi
 	}
 
 	var existingUser models.User
-	res := s.db.Where("email = ?", email).First(&existingUser)
+	res := s.db.WithContext(ctx).Where("email = ?", email).First(&existingUser)
 
 	if res.Error == nil {
 		return nil, ErrEmailAlreadyExists
@@ -87,15 +95,17 @@ func (s *UserService) CreateUser(email, password, firstName, lastName string, bi
 		BirthDate:  birthDate,
 	}
 
-	if result := s.db.Create(modelUser); result.Error != nil {
+	if result := s.db.WithContext(ctx).Create(modelUser); result.Error != nil {
 		return nil, ErrFailedToCreateUser
 	}
 
+	metrics.UsersRegistered.Inc()
+
 	user := userapp.ToAppUser(*modelUser)
 	return &user, nil
 }
 
-func (s *UserService) GetUserByID(userIDString string) (*userapp.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, userIDString string) (*userapp.User, error) {
 	userID, err := uuid.Parse(userIDString)
 
 	if err != nil {
@@ -104,7 +114,7 @@ func (s *UserService) GetUserByID(userIDString string) (*userapp.User, error) {
 
 	var modelUser *models.User
 
-	result := s.db.First(&modelUser, userID)
+	result := s.db.WithContext(ctx).First(&modelUser, userID)
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
@@ -118,14 +128,14 @@ func (s *UserService) GetUserByID(userIDString string) (*userapp.User, error) {
 	return &user, nil
 }
 
-func (s *UserService) GetUserByEmail(email string) (*userapp.User, error) {
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*userapp.User, error) {
 	if err := s.validateEmail(email); err != nil {
 		return nil, ErrInvalidEmail
 	}
 
 	var modelUser *models.User
 
-	result := s.db.Where("email = ?", email).First(&modelUser)
+	result := s.db.WithContext(ctx).Where("email = ?", email).First(&modelUser)
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
@@ -139,14 +149,17 @@ func (s *UserService) GetUserByEmail(email string) (*userapp.User, error) {
 	return &user, nil
 }
 
-func (s *UserService) LoginWithEmailPassword(email, password string) (*userapp.User, error) {
+// LoginWithEmailPassword checks a user's credentials. Failed attempts are
+// audited here; a successful login is audited when its session starts.
+func (s *UserService) LoginWithEmailPassword(ctx context.Context, email, password string) (*userapp.User, error) {
 	var modelUser *models.User
 
-	result := s.db.Where("email = ?", email).First(&modelUser)
+	result := s.db.WithContext(ctx).Where("email = ?", email).First(&modelUser)
 
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			return nil, ErrUserNotFound
+			s.auditLoginFailure(ctx, nil, email, "unknown email")
+			return nil, ErrInvalidCredentials
 		}
 
 		return nil, result.Error
@@ -154,13 +167,98 @@ func (s *UserService) LoginWithEmailPassword(email, password string) (*userapp.U
 
 	err := bcrypt.CompareHashAndPassword([]byte(modelUser.Password), []byte(password))
 	if err != nil {
-		return nil, err
+		s.auditLoginFailure(ctx, &modelUser.ID, email, "wrong password")
+		return nil, ErrInvalidCredentials
 	}
 
 	user := userapp.ToAppUser(*modelUser)
 	return &user, nil
 }
 
+func (s *UserService) auditLoginFailure(ctx context.Context, userID *uuid.UUID, email string, reason string) {
+	entry := audit.Entry{
+		Action:     audit.ActionLogin,
+		Outcome:    audit.OutcomeFailure,
+		TargetType: audit.TargetUser,
+		Metadata:   map[string]any{"email": email, "reason": reason},
+	}
+
+	if userID != nil {
+		entry.TargetID = userID.String()
+	}
+
+	s.auditor.Record(ctx, entry)
+}
+
+// ProvisionOIDCUser resolves an OIDC identity to a user. Known identities map
+// to their user; otherwise the identity is linked to an existing account with
+// the same email, but only if the provider verified that email, or a new
+// passwordless user is created.
+func (s *UserService) ProvisionOIDCUser(ctx context.Context, identity auth.OIDCIdentity) (*userapp.User, error) {
+	var modelUser models.User
+	var created bool
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existingIdentity models.UserIdentity
+
+		result := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existingIdentity)
+		if result.Error == nil {
+			return tx.First(&modelUser, existingIdentity.UserID).Error
+		}
+
+		if result.Error != gorm.ErrRecordNotFound {
+			return result.Error
+		}
+
+		email := strings.ToLower(strings.TrimSpace(identity.Email))
+
+		result = tx.Where("LOWER(email) = ?", email).First(&modelUser)
+		if result.Error == nil {
+			if !identity.EmailVerified {
+				return ErrUnverifiedSSOEmail
+			}
+		} else if result.Error == gorm.ErrRecordNotFound {
+			if err := s.validateEmail(email); err != nil {
+				return err
+			}
+
+			// SSO users have no local password; an empty hash never matches
+			modelUser = models.User{
+				Email:      email,
+				IsActive:   true,
+				IsVerified: identity.EmailVerified,
+				FirstName:  identity.GivenName,
+				LastName:   identity.FamilyName,
+			}
+
+			if result := tx.Create(&modelUser); result.Error != nil {
+				return ErrFailedToCreateUser
+			}
+			created = true
+		} else {
+			return result.Error
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   modelUser.ID,
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    email,
+		}).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		metrics.UsersRegistered.Inc()
+	}
+
+	user := userapp.ToAppUser(modelUser)
+	return &user, nil
+}
+
 func (s *UserService) ValidatePassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var ErrWebhookAddressNotAllowed = errors.New("webhook URL must resolve to a public address")
+
+// Ranges that are neither private nor local by the net/netip predicates but
+// still must not be reachable from webhooks.
+var blockedWebhookPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),         // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),     // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),      // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),     // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),       // reserved, broadcast included
+	netip.MustParsePrefix("64:ff9b::/96"),      // NAT64 can reach IPv4 internals
+	netip.MustParsePrefix("fd00:ec2::254/128"), // AWS metadata over IPv6
+}
+
+// publicWebhookAddr reports whether webhooks may connect to addr: loopback,
+// private, link-local (cloud metadata included), unspecified and multicast
+// addresses are refused, so webhooks can't probe the internal network.
+func publicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+
+	for _, prefix := range blockedWebhookPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateWebhookURL checks the URL is absolute http(s) and that its host
+// resolves to public addresses only. The dialer checks again on every
+// connection, as DNS can change after registration.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrWebhookAddressNotAllowed
+	}
+
+	for _, addr := range addrs {
+		if !publicWebhookAddr(addr) {
+			return ErrWebhookAddressNotAllowed
+		}
+	}
+
+	return nil
+}
+
+// newWebhookClient returns the client deliveries are sent with. Its dialer
+// refuses non-public addresses after resolution, which also defeats DNS
+// rebinding, and redirects are not followed: they would resend the signed
+// payload somewhere else.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !publicWebhookAddr(addrPort.Addr()) {
+				return ErrWebhookAddressNotAllowed
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the endpoint, skipping the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"share-docs/pkg/db/models"
+	"testing"
+)
+
+func TestPublicWebhookAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := publicWebhookAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("publicWebhookAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"ftp://example.com/hook", ErrInvalidWebhookURL},
+		{"/relative/hook", ErrInvalidWebhookURL},
+		{"http://127.0.0.1:8080/hook", ErrWebhookAddressNotAllowed},
+		{"http://localhost/hook", ErrWebhookAddressNotAllowed},
+		{"http://169.254.169.254/latest/meta-data/", ErrWebhookAddressNotAllowed},
+		{"https://10.0.0.5/hook", ErrWebhookAddressNotAllowed},
+		{"http://[::1]/hook", ErrWebhookAddressNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := validateWebhookURL(tt.url); !errors.Is(err, tt.want) {
+				t.Errorf("validateWebhookURL(%q) = %v, want %v", tt.url, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer server.Close()
+
+	s := &WebhookService{client: newWebhookClient()}
+
+	endpoint := models.WebhookEndpoint{URL: server.URL, Secret: "secret"}
+	delivery := models.WebhookDelivery{EventType: "document.created", Payload: "{}"}
+
+	status, err := s.send(context.Background(), endpoint, delivery)
+	if !errors.Is(err, ErrWebhookAddressNotAllowed) {
+		t.Fatalf("send() error = %v, want %v", err, ErrWebhookAddressNotAllowed)
+	}
+	if status != 0 {
+		t.Errorf("send() status = %d, want 0", status)
+	}
+}
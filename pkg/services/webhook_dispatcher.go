@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/jobs"
+	"share-docs/pkg/logger"
+	"share-docs/pkg/mail"
+	"share-docs/pkg/metrics"
+	"share-docs/pkg/util"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookTimeout          = 10 * time.Second
+	webhookPollInterval     = 2 * time.Second
+	webhookBatchSize        = 50
+	webhookConcurrency      = 8
+	webhookLease            = time.Minute
+	webhookMaxAttempts      = 8
+	webhookMinBackoff       = 30 * time.Second
+	webhookMaxBackoff       = 6 * time.Hour
+	webhookDisableThreshold = 20
+
+	WebhookSignatureHeader = "X-ShareDocs-Signature"
+	WebhookTimestampHeader = "X-ShareDocs-Timestamp"
+	WebhookEventHeader     = "X-ShareDocs-Event"
+	WebhookDeliveryHeader  = "X-ShareDocs-Delivery"
+)
+
+// RunDeliveries sends queued webhook deliveries until ctx is cancelled. Like
+// the events relay, claiming a batch leases it, so any number of API
+// processes can run this and a crashed one leaves its batch to the others.
+func (s *WebhookService) RunDeliveries(ctx context.Context, log *logger.Logger) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := s.deliverBatch(ctx, log)
+			if err != nil && ctx.Err() == nil {
+				log.WithError(err).Error("Failed to send webhook deliveries")
+			}
+
+			if err != nil || n < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *WebhookService) deliverBatch(ctx context.Context, log *logger.Logger) (int, error) {
+	now := time.Now()
+
+	var deliveries []models.WebhookDelivery
+
+	result := s.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, next_attempt_at = ?, last_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			WHERE d.status = ? AND d.next_attempt_at <= ? AND d.deleted_at IS NULL
+				AND e.enabled AND e.deleted_at IS NULL
+			ORDER BY d.created_at
+			LIMIT ?
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(webhookLease), now, now, models.WebhookDeliveryPending, now, webhookBatchSize,
+	).Scan(&deliveries)
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to claim webhook deliveries: %w", result.Error)
+	}
+
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	endpointIDs := make([]uuid.UUID, 0, len(deliveries))
+	for _, d := range deliveries {
+		endpointIDs = append(endpointIDs, d.EndpointID)
+	}
+
+	var modelEndpoints []models.WebhookEndpoint
+	if result := s.db.WithContext(ctx).Preload("User").Find(&modelEndpoints, endpointIDs); result.Error != nil {
+		return 0, result.Error
+	}
+
+	endpoints := make(map[uuid.UUID]models.WebhookEndpoint, len(modelEndpoints))
+	for _, e := range modelEndpoints {
+		endpoints[e.ID] = e
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, webhookConcurrency)
+
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			s.deliver(ctx, log, endpoint, delivery)
+		}()
+	}
+
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// deliver sends one delivery and records the outcome on it and its endpoint.
+func (s *WebhookService) deliver(ctx context.Context, log *logger.Logger, endpoint models.WebhookEndpoint, delivery models.WebhookDelivery) {
+	status, sendErr := s.send(ctx, endpoint, delivery)
+
+	// A cancelled dispatcher still records the attempt it made
+	db := s.db.WithContext(context.WithoutCancel(ctx))
+	now := time.Now()
+
+	updates := map[string]any{
+		"response_status": nil,
+		"last_error":      nil,
+	}
+
+	if status != 0 {
+		updates["response_status"] = status
+	}
+
+	if sendErr == nil {
+		metrics.WebhookDeliveries.WithLabelValues("succeeded").Inc()
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+	} else {
+		metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		updates["last_error"] = util.Truncate(sendErr.Error(), 1000)
+
+		if delivery.Attempts >= webhookMaxAttempts {
+			updates["status"] = models.WebhookDeliveryDead
+		} else {
+			updates["next_attempt_at"] = now.Add(util.Backoff(delivery.Attempts, webhookMinBackoff, webhookMaxBackoff))
+		}
+	}
+
+	if result := db.Model(&delivery).Updates(updates); result.Error != nil {
+		log.WithError(result.Error).Error("Failed to record webhook delivery")
+		return
+	}
+
+	if sendErr == nil {
+		if endpoint.ConsecutiveFailures > 0 {
+			db.Model(&endpoint).UpdateColumn("consecutive_failures", 0)
+		}
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"webhook_id":  endpoint.ID,
+		"delivery_id": delivery.ID,
+		"attempts":    delivery.Attempts,
+	}).WithError(sendErr).Warn("Webhook delivery failed")
+
+	s.recordFailure(log, db, endpoint)
+}
+
+// recordFailure counts a failed attempt against the endpoint and disables it
+// once it keeps failing, telling the owner by email.
+func (s *WebhookService) recordFailure(log *logger.Logger, db *gorm.DB, endpoint models.WebhookEndpoint) {
+	result := db.Model(&endpoint).UpdateColumn("consecutive_failures", gorm.Expr("consecutive_failures + 1"))
+	if result.Error != nil {
+		log.WithError(result.Error).Error("Failed to count webhook failure")
+		return
+	}
+
+	reason := fmt.Sprintf("Disabled after %d consecutive failed deliveries", webhookDisableThreshold)
+
+	disabled := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Only one of the concurrent failures gets to disable it
+		result := tx.Model(&models.WebhookEndpoint{}).
+			Where("id = ? AND enabled AND consecutive_failures >= ?", endpoint.ID, webhookDisableThreshold).
+			Updates(map[string]any{
+				"enabled":         false,
+				"disabled_at":     time.Now(),
+				"disabled_reason": reason,
+			})
+
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		disabled = true
+
+		return jobs.EnqueueEmail(tx, mail.Message{
+			To:      []string{endpoint.User.Email},
+			Subject: "Your webhook was disabled",
+			Body: fmt.Sprintf(
+				"Deliveries to %s kept failing, so the webhook was disabled.\n\n%s. Fix the endpoint, then enable the webhook again; pending deliveries resume and dead ones can be redelivered.\n",
+				endpoint.URL, reason,
+			),
+		})
+	})
+
+	if err != nil {
+		log.WithError(err).Error("Failed to disable webhook")
+		return
+	}
+
+	if disabled {
+		log.WithField("webhook_id", endpoint.ID).Warn("Webhook disabled after repeated failures")
+	}
+}
+
+// send posts the payload and reports the response status. Anything but a 2xx
+// is a failure. The response body is discarded: showing it to the webhook
+// owner would turn any endpoint that slips past the address checks into a
+// way of reading internal services.
+func (s *WebhookService) send(ctx context.Context, endpoint models.WebhookEndpoint, delivery models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "share-docs-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(endpoint.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a little so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhook is the hex HMAC-SHA256 of "<timestamp>.<body>" under the
+// endpoint secret. Receivers recompute it and should reject old timestamps.
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"share-docs/pkg/util"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			name:      "known signature",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			body:      `{"type":"document.created"}`,
+			// printf '%s' '1700000000.{"type":"document.created"}' | openssl dgst -sha256 -hmac whsec_test
+			want: "4ee88fd44bb090767d1cb06b437d658d0a3799e772f18080cd28a0872d75b034",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhook(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("SignWebhook() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	base := SignWebhook("whsec_test", "1700000000", []byte("{}"))
+
+	changed := map[string]string{
+		"secret":    SignWebhook("whsec_other", "1700000000", []byte("{}")),
+		"timestamp": SignWebhook("whsec_test", "1700000001", []byte("{}")),
+		"body":      SignWebhook("whsec_test", "1700000000", []byte("{ }")),
+		// The separator keeps the timestamp and body from running together
+		"split": SignWebhook("whsec_test", "170000000", []byte("0.{}")),
+	}
+
+	for what, signature := range changed {
+		if signature == base {
+			t.Errorf("changing the %s kept the signature", what)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{9, 128 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, webhookMaxBackoff},
+		{webhookMaxAttempts, 64 * time.Minute},
+		{1000, webhookMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := util.Backoff(tt.attempts, webhookMinBackoff, webhookMaxBackoff); got != tt.want {
+			t.Errorf("Backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
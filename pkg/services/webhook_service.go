@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"share-docs/pkg/app/domain/documentapp"
+	"share-docs/pkg/app/domain/orgapp"
+	"share-docs/pkg/app/domain/webhookapp"
+	"share-docs/pkg/db/models"
+	"share-docs/pkg/events"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute http or https URL")
+)
+
+type WebhookServiceInterface interface {
+	CreateWebhook(ctx context.Context, userID uuid.UUID, req webhookapp.CreateEndpoint) (*webhookapp.EndpointWithSecret, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]webhookapp.Endpoint, error)
+	GetWebhook(ctx context.Context, userID uuid.UUID, webhookID string) (*webhookapp.Endpoint, error)
+	UpdateWebhook(ctx context.Context, userID uuid.UUID, webhookID string, req webhookapp.UpdateEndpoint) (*webhookapp.Endpoint, error)
+	DeleteWebhook(ctx context.Context, userID uuid.UUID, webhookID string) error
+	RotateSecret(ctx context.Context, userID uuid.UUID, webhookID string) (*webhookapp.EndpointWithSecret, error)
+
+	ListDeliveries(ctx context.Context, userID uuid.UUID, webhookID string, status string, page, limit int) ([]webhookapp.Delivery, int64, error)
+	ListDeadLetters(ctx context.Context, userID uuid.UUID, page, limit int) ([]webhookapp.Delivery, int64, error)
+	Redeliver(ctx context.Context, userID uuid.UUID, webhookID string, deliveryID string) (*webhookapp.Delivery, error)
+}
+
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db:     db,
+		client: newWebhookClient(),
+	}
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID uuid.UUID, req webhookapp.CreateEndpoint) (*webhookapp.EndpointWithSecret, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		UserID:      userID,
+		URL:         req.URL,
+		EventTypes:  normalizeEventTypes(req.EventTypes),
+		Secret:      secret,
+		Enabled:     true,
+		Description: req.Description,
+	}
+
+	if result := s.db.WithContext(ctx).Omit("User").Create(endpoint); result.Error != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", result.Error)
+	}
+
+	return &webhookapp.EndpointWithSecret{
+		Endpoint: webhookapp.ToAppEndpoint(*endpoint),
+		Secret:   secret,
+	}, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]webhookapp.Endpoint, error) {
+	var modelEndpoints []models.WebhookEndpoint
+
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&modelEndpoints)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	endpoints := make([]webhookapp.Endpoint, 0, len(modelEndpoints))
+	for _, me := range modelEndpoints {
+		endpoints = append(endpoints, webhookapp.ToAppEndpoint(me))
+	}
+
+	return endpoints, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, userID uuid.UUID, webhookID string) (*webhookapp.Endpoint, error) {
+	endpoint, err := s.ownWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	e := webhookapp.ToAppEndpoint(*endpoint)
+	return &e, nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID uuid.UUID, webhookID string, req webhookapp.UpdateEndpoint) (*webhookapp.Endpoint, error) {
+	endpoint, err := s.ownWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+
+		endpoint.URL = *req.URL
+		updates["url"] = endpoint.URL
+	}
+
+	if req.Description != nil {
+		endpoint.Description = emptyToNil(*req.Description)
+		updates["description"] = endpoint.Description
+	}
+
+	if req.EventTypes != nil {
+		endpoint.EventTypes = normalizeEventTypes(*req.EventTypes)
+		updates["event_types"] = endpoint.EventTypes
+	}
+
+	if req.Enabled != nil && *req.Enabled != endpoint.Enabled {
+		endpoint.Enabled = *req.Enabled
+		updates["enabled"] = endpoint.Enabled
+
+		if endpoint.Enabled {
+			endpoint.ConsecutiveFailures = 0
+			endpoint.DisabledAt = nil
+			endpoint.DisabledReason = nil
+		} else {
+			now := time.Now()
+			reason := "Disabled by user"
+			endpoint.DisabledAt = &now
+			endpoint.DisabledReason = &reason
+		}
+
+		updates["consecutive_failures"] = endpoint.ConsecutiveFailures
+		updates["disabled_at"] = endpoint.DisabledAt
+		updates["disabled_reason"] = endpoint.DisabledReason
+	}
+
+	if len(updates) > 0 {
+		if result := s.db.WithContext(ctx).Model(endpoint).Updates(updates); result.Error != nil {
+			return nil, ErrFailedToUpdate
+		}
+	}
+
+	e := webhookapp.ToAppEndpoint(*endpoint)
+	return &e, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID uuid.UUID, webhookID string) error {
+	endpoint, err := s.ownWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Delete(endpoint).Error
+}
+
+// RotateSecret replaces the signing secret. Deliveries sent from now on,
+// including retries, are signed with the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, userID uuid.UUID, webhookID string) (*webhookapp.EndpointWithSecret, error) {
+	endpoint, err := s.ownWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if result := s.db.WithContext(ctx).Model(endpoint).Update("secret", secret); result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	return &webhookapp.EndpointWithSecret{
+		Endpoint: webhookapp.ToAppEndpoint(*endpoint),
+		Secret:   secret,
+	}, nil
+}
+
+// ListDeliveries is the delivery log of an endpoint, newest first, optionally
+// filtered by status.
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID uuid.UUID, webhookID string, status string, page, limit int) ([]webhookapp.Delivery, int64, error) {
+	endpoint, err := s.ownWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("endpoint_id = ?", endpoint.ID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	return s.listDeliveries(query, page, limit)
+}
+
+// ListDeadLetters lists the deliveries across the user's endpoints that ran
+// out of attempts.
+func (s *WebhookService) ListDeadLetters(ctx context.Context, userID uuid.UUID, page, limit int) ([]webhookapp.Delivery, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("status = ?", models.WebhookDeliveryDead).
+		Where("endpoint_id IN (?)", s.db.Model(&models.WebhookEndpoint{}).Select("id").Where("user_id = ?", userID))
+
+	return s.listDeliveries(query, page, limit)
+}
+
+func (s *WebhookService) listDeliveries(query *gorm.DB, page, limit int) ([]webhookapp.Delivery, int64, error) {
+	var total int64
+	if result := query.Count(&total); result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	var modelDeliveries []models.WebhookDelivery
+
+	result := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&modelDeliveries)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	deliveries := make([]webhookapp.Delivery, 0, len(modelDeliveries))
+	for _, md := range modelDeliveries {
+		deliveries = append(deliveries, webhookapp.ToAppDelivery(md))
+	}
+
+	return deliveries, total, nil
+}
+
+// Redeliver queues a delivery again with a fresh set of attempts, whatever
+// its status. It goes out once the endpoint is enabled.
+func (s *WebhookService) Redeliver(ctx context.Context, userID uuid.UUID, webhookID string, deliveryID string) (*webhookapp.Delivery, error) {
+	endpoint, err := s.ownWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var delivery models.WebhookDelivery
+
+	result := s.db.WithContext(ctx).Where("endpoint_id = ?", endpoint.ID).First(&delivery, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, result.Error
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+
+	result = s.db.WithContext(ctx).Model(&delivery).Updates(map[string]any{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+	})
+	if result.Error != nil {
+		return nil, ErrFailedToUpdate
+	}
+
+	d := webhookapp.ToAppDelivery(delivery)
+	return &d, nil
+}
+
+// HandleEvent is an events bus handler that queues a delivery of the event
+// for every enabled endpoint subscribed to it whose owner can reach the
+// document. Public documents don't count, or every endpoint would hear about
+// them. Queuing is idempotent, so a redelivered event isn't sent twice.
+func (s *WebhookService) HandleEvent(ctx context.Context, event events.Event) error {
+	if !slices.Contains(webhookapp.EventTypes, event.Type) {
+		return nil
+	}
+
+	documentID := event.AggregateID
+	if event.Type == models.EventLinkViewed {
+		var viewed models.LinkViewedEvent
+		if err := json.Unmarshal(event.Payload, &viewed); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		documentID = viewed.DocumentID
+	}
+
+	db := s.db.WithContext(ctx)
+
+	var endpoints []models.WebhookEndpoint
+
+	result := db.Where("enabled = ? AND (' ' || event_types || ' ') LIKE ?", true, "% "+event.Type+" %").Find(&endpoints)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+
+	for _, endpoint := range endpoints {
+		var reachable int64
+
+		// Deleted documents still belong to someone
+		result := db.Unscoped().Model(&models.Document{}).
+			Where("documents.id = ?", documentID).
+			Where(accessibleBy(db, "documents", endpoint.UserID, orgapp.RoleViewer).
+				Or("documents.id IN (?)", sharedWith(db, endpoint.UserID, documentapp.PermissionViewer))).
+			Count(&reachable)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if reachable == 0 {
+			continue
+		}
+
+		deliveries = append(deliveries, models.WebhookDelivery{
+			EndpointID: endpoint.ID,
+			EventID:    event.ID,
+			EventType:  event.Type,
+			Payload:    string(payload),
+			Status:     models.WebhookDeliveryPending,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (s *WebhookService) ownWebhook(ctx context.Context, userID uuid.UUID, webhookID string) (*models.WebhookEndpoint, error) {
+	id, err := uuid.Parse(webhookID)
+	if err != nil {
+		return nil, ErrInvalidId
+	}
+
+	var endpoint models.WebhookEndpoint
+
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&endpoint, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, result.Error
+	}
+
+	return &endpoint, nil
+}
+
+// normalizeEventTypes drops duplicates and sorts, so the stored list reads
+// the same however it was given.
+func normalizeEventTypes(types []string) string {
+	normalized := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t != "" && !slices.Contains(normalized, t) {
+			normalized = append(normalized, t)
+		}
+	}
+
+	slices.Sort(normalized)
+	return webhookapp.JoinEventTypes(normalized)
+}
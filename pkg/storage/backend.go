@@ -29,6 +29,8 @@ var (
 
 type StorageBackendInterface interface {
 	Upload(file multipart.File, path string, filename string) (*StorageObject, error)
+	// Probe checks that objects can be written
+	Probe() error
 	// Get(object string) (*StorageObject, error)
 	// Delete(object string) error
 }
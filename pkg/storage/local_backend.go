@@ -2,6 +2,7 @@ package storage
 
 import (
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -33,24 +34,20 @@ func (s *LocalStorage) Upload(file multipart.File, path string, filename string)
 		return nil, err
 	}
 
-	f, err := os.Create(fileName)
-	defer f.Close()
+	filebytes, err := io.ReadAll(file)
 
 	if err != nil {
 		return nil, err
 	}
 
-	mimeType, err := mimetype.DetectReader(f)
-
-	if err != nil {
-		return nil, err
-	}
+	mimeType := mimetype.Detect(filebytes)
 
-	filebytes, err := io.ReadAll(file)
+	f, err := os.Create(fileName)
 
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	bytesWritten, err := f.Write(filebytes)
 
@@ -74,3 +71,17 @@ func (s *LocalStorage) Upload(file multipart.File, path string, filename string)
 
 	return so, nil
 }
+
+// Probe writes and removes a small file in the upload directory.
+func (s *LocalStorage) Probe() error {
+	f, err := os.CreateTemp(s.UploadPath, ".probe-*")
+	if err != nil {
+		return err
+	}
+
+	_, writeErr := f.Write([]byte("ok"))
+	closeErr := f.Close()
+	removeErr := os.Remove(f.Name())
+
+	return errors.Join(writeErr, closeErr, removeErr)
+}
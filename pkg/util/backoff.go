@@ -0,0 +1,14 @@
+package util
+
+import "time"
+
+// Backoff is the delay before retrying after the given number of attempts.
+// It starts at base and doubles with each attempt, up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	return min(delay, max)
+}
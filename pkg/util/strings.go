@@ -0,0 +1,21 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Truncate shortens s to at most max bytes. Invalid UTF-8 is replaced and the
+// cut never splits a multi-byte character, as Postgres rejects either.
+func Truncate(s string, max int) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
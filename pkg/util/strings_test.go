@@ -0,0 +1,28 @@
+package util
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		max  int
+		want string
+	}{
+		{"short", "abc", 5, "abc"},
+		{"exact", "abcde", 5, "abcde"},
+		{"ascii", "abcdef", 5, "abcde"},
+		{"rune boundary", "ab€cd", 4, "ab"},
+		{"after rune", "ab€cd", 5, "ab€"},
+		{"invalid utf-8", "ab\xffcd", 10, "ab�cd"},
+		{"zero", "abc", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Truncate(tt.s, tt.max); got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+			}
+		})
+	}
+}
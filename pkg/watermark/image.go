@@ -0,0 +1,91 @@
+package watermark
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// maxImagePixels bounds the images stampImage decodes. A few kilobytes of
+// compressed image can claim dimensions that take gigabytes to decode.
+const maxImagePixels = 25_000_000
+
+// stampImage tiles the text over the image, scaled to roughly half its width
+// so it stays legible on large images.
+func stampImage(r io.ReadSeeker, w io.Writer, mimeType string, text string) error {
+	// The header gives the dimensions without decoding the pixels
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return ErrImageTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind image: %w", err)
+	}
+
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	xdraw.Draw(out, bounds, src, bounds.Min, xdraw.Src)
+
+	label := renderText(text)
+
+	width := max(bounds.Dx()/2, 1)
+	height := max(width*label.Bounds().Dy()/label.Bounds().Dx(), 1)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.BiLinear.Scale(scaled, scaled.Bounds(), label, label.Bounds(), xdraw.Src, nil)
+
+	// Stagger the rows so cropping can't remove every copy
+	for row, y := 0, bounds.Min.Y; y < bounds.Max.Y; row, y = row+1, y+height*3 {
+		offset := (row % 2) * width / 2
+		for x := bounds.Min.X - offset; x < bounds.Max.X; x += width + width/4 {
+			dst := image.Rect(x, y, x+width, y+height)
+			xdraw.Draw(out, dst, scaled, image.Point{}, xdraw.Over)
+		}
+	}
+
+	switch mimeType {
+	case "image/jpeg":
+		return jpeg.Encode(w, out, &jpeg.Options{Quality: 90})
+	case "image/gif":
+		return gif.Encode(w, out, nil)
+	default:
+		return png.Encode(w, out)
+	}
+}
+
+// renderText draws the text in a translucent grey on a transparent canvas.
+func renderText(text string) *image.RGBA {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil() + 4
+	height := face.Metrics().Height.Ceil() + 4
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.NRGBA{R: 128, G: 128, B: 128, A: 96}),
+		Face: face,
+		Dot:  fixed.P(2, 2+face.Metrics().Ascent.Ceil()),
+	}
+	d.DrawString(text)
+
+	return img
+}
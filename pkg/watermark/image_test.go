@@ -0,0 +1,51 @@
+package watermark
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func TestStampImageRefusesHugeImages(t *testing.T) {
+	// Only the header is read, so a small image with its IHDR dimensions
+	// rewritten, and the chunk checksum redone, claims 100000x100000 pixels.
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	err := Stamp(bytes.NewReader(data), &bytes.Buffer{}, "image/png", "viewer")
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("Stamp() error = %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestStampImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 200, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Stamp(bytes.NewReader(buf.Bytes()), &out, "image/png", "viewer"); err != nil {
+		t.Fatalf("Stamp() error = %v", err)
+	}
+
+	cfg, err := png.DecodeConfig(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Width != 200 || cfg.Height != 100 {
+		t.Errorf("stamped image is %dx%d, want 200x100", cfg.Width, cfg.Height)
+	}
+}
@@ -0,0 +1,30 @@
+package watermark
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const pdfWatermarkDescription = "fontname:Helvetica, points:24, scalefactor:0.8 rel, opacity:0.25, fillcolor:#808080, diagonal:1"
+
+func init() {
+	// Keep pdfcpu from writing a config directory on first use
+	model.ConfigPath = "disable"
+}
+
+func stampPDF(r io.ReadSeeker, w io.Writer, text string) error {
+	wm, err := api.TextWatermark(text, pdfWatermarkDescription, true, false, types.POINTS)
+	if err != nil {
+		return fmt.Errorf("failed to build PDF watermark: %w", err)
+	}
+
+	if err := api.AddWatermarks(r, w, nil, wm, model.NewDefaultConfiguration()); err != nil {
+		return fmt.Errorf("failed to watermark PDF: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package watermark
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrUnsupportedType = errors.New("watermarking is not supported for this file type")
+	ErrImageTooLarge   = errors.New("image is too large to watermark")
+)
+
+// Supported reports whether files of the MIME type can be watermarked.
+func Supported(mimeType string) bool {
+	switch mimeType {
+	case "application/pdf", "image/png", "image/jpeg", "image/gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// Stamp writes a copy of the file with text stamped across every page or
+// over the whole image. The original is left untouched.
+func Stamp(r io.ReadSeeker, w io.Writer, mimeType string, text string) error {
+	switch mimeType {
+	case "application/pdf":
+		return stampPDF(r, w, text)
+	case "image/png", "image/jpeg", "image/gif":
+		return stampImage(r, w, mimeType, text)
+	default:
+		return ErrUnsupportedType
+	}
+}